@@ -53,12 +53,239 @@ type TriggerServiceList struct {
 
 // TriggerMeta is the meta data of a trigger.
 type TriggerMeta struct {
-	Source Source `json:"source"`
+	// Source is where events come from. It is not used when Correlate is set.
+	// +optional
+	Source Source `json:"source,omitempty"`
+	// Correlate fires the trigger when a pattern across events from several
+	// sources happens within a window, instead of on every event of Source.
+	// +optional
+	Correlate *Correlation `json:"correlate,omitempty"`
+	// Enrich fetches objects related to the event before filtering.
+	// +optional
+	Enrich *Enrich `json:"enrich,omitempty"`
+	// +optional
+	Filter string `json:"filter,omitempty"`
+	// FilterLanguage is the language of Filter, cue or cel. Defaults to cue.
+	// Filters prefixed by cel: are always CEL.
+	// +optional
+	FilterLanguage string `json:"filterLanguage,omitempty"`
+	// FilterRef refers to named filters, defined by trigger-filter
+	// Definitions, that events must pass as well as Filter.
+	// +optional
+	FilterRef *FilterRef `json:"filterRef,omitempty"`
+	// FilterPlugin is a WebAssembly module that events must pass as well as
+	// Filter.
+	// +optional
+	FilterPlugin *Plugin `json:"filterPlugin,omitempty"`
+	// OnFilterError is what to do with an event when the filter cannot be
+	// evaluated against it: drop, pass, or retry (with backoff, then drop).
+	// Defaults to drop. Events missing fields referenced by the filter do not
+	// match, and are not errors.
+	// +optional
+	OnFilterError string `json:"onFilterError,omitempty"`
+	// State is where stateful filter builtins, like trigger.#Dedupe, keep
+	// their state. Defaults to memory.
 	// +optional
-	Filter string     `json:"filter,omitempty"`
+	State *State `json:"state,omitempty"`
+	// Batch accumulates events that passed the filter, and runs the action
+	// once for them.
+	// +optional
+	Batch *Batch `json:"batch,omitempty"`
+	// Storm protects the executor and downstream systems from event storms,
+	// summarizing events instead of running the action for each of them.
+	// +optional
+	Storm  *Storm     `json:"storm,omitempty"`
 	Action ActionMeta `json:"action"`
 }
 
+// Storm describes when a trigger is in storm mode, and what it does then. In
+// storm mode, the action runs once every Window for the events that are not
+// sampled, with the context of the latest one, and a summary of them in
+// context.storm.
+type Storm struct {
+	// Threshold is the number of events passing the filter in a Window
+	// above which the trigger is in storm mode. It leaves storm mode after a
+	// Window with no more events than Threshold.
+	Threshold int `json:"threshold"`
+	// Window is how long events are counted for, and how often summaries are
+	// given to the action, e.g. 30s. Defaults to 10s.
+	// +optional
+	Window string `json:"window,omitempty"`
+	// SamplePercent is the percentage of events in storm mode that still run
+	// the action on their own, from 0 to 100. Defaults to 0.
+	// +optional
+	SamplePercent int `json:"samplePercent,omitempty"`
+}
+
+// Batch describes how events are accumulated before running an action. The
+// action runs with the contexts of the accumulated events in context.events,
+// and the group key in context.key.
+type Batch struct {
+	// MaxSize is the max number of events in a batch. A batch is run as soon
+	// as it is full. Unlimited if not set.
+	// +optional
+	MaxSize int `json:"maxSize,omitempty"`
+	// MaxWait is how long to wait for more events after the first event of a
+	// batch, e.g. 30s. Defaults to 10s.
+	// +optional
+	MaxWait string `json:"maxWait,omitempty"`
+	// GroupBy is a CUE expression evaluated against the context of each event,
+	// e.g. context.data.metadata.namespace. Events with different keys are
+	// batched separately. All events are in one batch if not set. It is a
+	// plain expression, so packages cannot be imported.
+	// +optional
+	GroupBy string `json:"groupBy,omitempty"`
+}
+
+// Enrich describes the objects related to the object of an event to fetch
+// before the filter runs. They are available to filters and actions under
+// context.related.
+type Enrich struct {
+	// Owners adds the chain of controller owners, from the direct owner to the
+	// root, under context.related.owners.
+	// +optional
+	Owners bool `json:"owners,omitempty"`
+	// ConfigMaps adds the ConfigMaps referenced by the pod spec of the object,
+	// keyed by name, under context.related.configMaps.
+	// +optional
+	ConfigMaps bool `json:"configMaps,omitempty"`
+	// Secrets adds the Secrets referenced by the pod spec of the object, keyed
+	// by name, under context.related.secrets.
+	// +optional
+	Secrets bool `json:"secrets,omitempty"`
+	// Application adds the KubeVela Application that the object belongs to,
+	// found by the app.oam.dev/name label, under context.related.application.
+	// +optional
+	Application bool `json:"application,omitempty"`
+	// Namespace adds the Namespace of the object under
+	// context.related.namespace.
+	// +optional
+	Namespace bool `json:"namespace,omitempty"`
+	// TTL is how long fetched objects are cached, e.g. 1m. Defaults to 30s.
+	// +optional
+	TTL string `json:"ttl,omitempty"`
+}
+
+// Correlation describes a pattern across events. Events are grouped by their
+// key, and only events with the same key are matched against each other. When
+// the pattern matches, the filter and action of the trigger run with the
+// matched events in context.data.events, keyed by event name.
+type Correlation struct {
+	// Events are the events taking part in the pattern.
+	Events []CorrelatedEvent `json:"events"`
+	// Pattern is how the events are matched.
+	Pattern CorrelationPattern `json:"pattern"`
+	// Window is how long a pattern can take to match, e.g. 5m.
+	Window string `json:"window"`
+}
+
+// CorrelatedEvent is an event taking part in a Correlation.
+type CorrelatedEvent struct {
+	// Name identifies the event in the pattern and in context.data.events.
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+	// Filter is applied to the event before it is correlated. It is CEL if
+	// prefixed by cel:, CUE otherwise.
+	// +optional
+	Filter string `json:"filter,omitempty"`
+	// FilterLanguage is the language of Filter, cue or cel. Defaults to cue.
+	// +optional
+	FilterLanguage string `json:"filterLanguage,omitempty"`
+	// Key is a CUE expression that events are correlated by, evaluated
+	// against the context of the event, e.g. context.data.metadata.name.
+	// Events without a key all share the same key. It is a plain expression, so
+	// packages cannot be imported.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// CorrelationPattern is how correlated events are matched.
+type CorrelationPattern struct {
+	// Type is one of:
+	//  - all: every event is seen, in any order.
+	//  - sequence: every event is seen, in the listed order.
+	//  - count: Count events are seen.
+	//  - absence: the first event is seen, and none of the others follow.
+	//    It fires when the window ends.
+	Type string `json:"type"`
+	// Count is the number of events needed by the count pattern.
+	// +optional
+	Count int `json:"count,omitempty"`
+}
+
+// FilterRef is a named filter with its properties, or a composition of
+// FilterRefs. Exactly one of Name, AllOf, AnyOf and Not is set.
+type FilterRef struct {
+	// Name is the name of a trigger-filter Definition.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Properties are the parameters of the named filter.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Properties *runtime.RawExtension `json:"properties,omitempty"`
+	// AllOf passes events that pass all of the filters.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	AllOf []FilterRef `json:"allOf,omitempty"`
+	// AnyOf passes events that pass any of the filters.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	AnyOf []FilterRef `json:"anyOf,omitempty"`
+	// Not passes events that do not pass the filter.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Not *FilterRef `json:"not,omitempty"`
+}
+
+// State describes where the state of a trigger is kept.
+type State struct {
+	// Backend is memory or configmap. State in memory is lost on restart.
+	// State in a ConfigMap is kept in memory too, and written through to the
+	// ConfigMap.
+	// +optional
+	Backend string `json:"backend,omitempty"`
+	// ConfigMap is the ConfigMap keeping the state, with the configmap
+	// backend. It is created if it does not exist.
+	// +optional
+	ConfigMap *ConfigMapReference `json:"configMap,omitempty"`
+}
+
+// ConfigMapReference refers to a ConfigMap.
+type ConfigMapReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Plugin is a WebAssembly module, run as a filter or an action in a sandbox.
+// Exactly one of ConfigMap and Image is set.
+type Plugin struct {
+	// ConfigMap holds the module in its binaryData.
+	// +optional
+	ConfigMap *ConfigMapReference `json:"configMap,omitempty"`
+	// Key is the key of the module in the ConfigMap. Defaults to plugin.wasm.
+	// +optional
+	Key string `json:"key,omitempty"`
+	// Image is an OCI artifact with the module as its layer, e.g.
+	// ghcr.io/org/filter:v1. Only public images can be pulled.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Config is given to the module as config, with every event.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Config *runtime.RawExtension `json:"config,omitempty"`
+	// MaxMemory is the memory that the module can use, e.g. 16Mi. Defaults
+	// to 16Mi.
+	// +optional
+	MaxMemory string `json:"maxMemory,omitempty"`
+	// Timeout is how long the module can run for an event, e.g. 100ms.
+	// Defaults to 1s.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+}
+
 // ActionMeta is what users type in their configurations, specifying what action
 // they want to use and what properties they provided.
 type ActionMeta struct {
@@ -91,6 +318,20 @@ const (
 	SourceTypeWebhookTrigger string = "webhook-trigger"
 )
 
+// ActionTypeWASM is the type of actions run by WebAssembly modules. Their
+// properties are a Plugin.
+const ActionTypeWASM string = "wasm"
+
+const (
+	// OnFilterErrorDrop drops events that the filter fails on.
+	OnFilterErrorDrop string = "drop"
+	// OnFilterErrorPass passes events that the filter fails on.
+	OnFilterErrorPass string = "pass"
+	// OnFilterErrorRetry evaluates the filter again with backoff, and drops
+	// events that it still fails on.
+	OnFilterErrorRetry string = "retry"
+)
+
 func init() {
 	SchemeBuilder.Register(&TriggerService{}, &TriggerServiceList{})
 }
@@ -44,6 +44,105 @@ func (in *ActionMeta) DeepCopy() *ActionMeta {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Batch) DeepCopyInto(out *Batch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Batch.
+func (in *Batch) DeepCopy() *Batch {
+	if in == nil {
+		return nil
+	}
+	out := new(Batch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
+func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CorrelatedEvent) DeepCopyInto(out *CorrelatedEvent) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CorrelatedEvent.
+func (in *CorrelatedEvent) DeepCopy() *CorrelatedEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(CorrelatedEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Correlation) DeepCopyInto(out *Correlation) {
+	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]CorrelatedEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Pattern = in.Pattern
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Correlation.
+func (in *Correlation) DeepCopy() *Correlation {
+	if in == nil {
+		return nil
+	}
+	out := new(Correlation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CorrelationPattern) DeepCopyInto(out *CorrelationPattern) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CorrelationPattern.
+func (in *CorrelationPattern) DeepCopy() *CorrelationPattern {
+	if in == nil {
+		return nil
+	}
+	out := new(CorrelationPattern)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Enrich) DeepCopyInto(out *Enrich) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Enrich.
+func (in *Enrich) DeepCopy() *Enrich {
+	if in == nil {
+		return nil
+	}
+	out := new(Enrich)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Event) DeepCopyInto(out *Event) {
 	*out = *in
@@ -145,6 +244,70 @@ func (in *EventResource) DeepCopy() *EventResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilterRef) DeepCopyInto(out *FilterRef) {
+	*out = *in
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllOf != nil {
+		in, out := &in.AllOf, &out.AllOf
+		*out = make([]FilterRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnyOf != nil {
+		in, out := &in.AnyOf, &out.AnyOf
+		*out = make([]FilterRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Not != nil {
+		in, out := &in.Not, &out.Not
+		*out = new(FilterRef)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilterRef.
+func (in *FilterRef) DeepCopy() *FilterRef {
+	if in == nil {
+		return nil
+	}
+	out := new(FilterRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Plugin) DeepCopyInto(out *Plugin) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapReference)
+		**out = **in
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Plugin.
+func (in *Plugin) DeepCopy() *Plugin {
+	if in == nil {
+		return nil
+	}
+	out := new(Plugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Source) DeepCopyInto(out *Source) {
 	*out = *in
@@ -165,10 +328,80 @@ func (in *Source) DeepCopy() *Source {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *State) DeepCopyInto(out *State) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new State.
+func (in *State) DeepCopy() *State {
+	if in == nil {
+		return nil
+	}
+	out := new(State)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Storm) DeepCopyInto(out *Storm) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storm.
+func (in *Storm) DeepCopy() *Storm {
+	if in == nil {
+		return nil
+	}
+	out := new(Storm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TriggerMeta) DeepCopyInto(out *TriggerMeta) {
 	*out = *in
 	in.Source.DeepCopyInto(&out.Source)
+	if in.Correlate != nil {
+		in, out := &in.Correlate, &out.Correlate
+		*out = new(Correlation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Enrich != nil {
+		in, out := &in.Enrich, &out.Enrich
+		*out = new(Enrich)
+		**out = **in
+	}
+	if in.FilterRef != nil {
+		in, out := &in.FilterRef, &out.FilterRef
+		*out = new(FilterRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FilterPlugin != nil {
+		in, out := &in.FilterPlugin, &out.FilterPlugin
+		*out = new(Plugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.State != nil {
+		in, out := &in.State, &out.State
+		*out = new(State)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(Batch)
+		**out = **in
+	}
+	if in.Storm != nil {
+		in, out := &in.Storm, &out.Storm
+		*out = new(Storm)
+		**out = **in
+	}
 	in.Action.DeepCopyInto(&out.Action)
 }
 
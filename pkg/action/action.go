@@ -18,15 +18,19 @@ package action
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
 
 	"github.com/kubevela/pkg/cue/cuex"
 	"github.com/kubevela/pkg/util/template/definition"
 	"github.com/mitchellh/hashstructure/v2"
+	"github.com/pkg/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kubevela/kube-trigger/api/v1alpha1"
 	"github.com/kubevela/kube-trigger/pkg/executor"
+	"github.com/kubevela/kube-trigger/pkg/filter/library/registry"
+	"github.com/kubevela/kube-trigger/pkg/plugin"
 	"github.com/kubevela/kube-trigger/pkg/types"
 )
 
@@ -34,9 +38,13 @@ import (
 type Job struct {
 	sourceType string
 	id         string
-	context    any
+	context    map[string]interface{}
 	properties any
 	template   string
+	// plugin runs the job instead of template, for wasm actions. It is got
+	// when the job runs, so that the latest module is run.
+	plugin *v1alpha1.Plugin
+	cli    client.Client
 }
 
 var _ executor.Job = &Job{}
@@ -45,6 +53,9 @@ var _ executor.Job = &Job{}
 // using provided ActionMeta. sourceType and event will be passed to the Action.Run
 // method.
 func New(ctx context.Context, cli client.Client, meta v1alpha1.ActionMeta, contextData map[string]interface{}) (*Job, error) {
+	if meta.Type == v1alpha1.ActionTypeWASM {
+		return newPluginJob(cli, meta, contextData)
+	}
 	template, err := definition.NewTemplateLoader(ctx, cli).LoadTemplate(ctx, meta.Type, definition.WithType(types.DefinitionTypeTriggerAction))
 	if err != nil {
 		return nil, err
@@ -64,6 +75,36 @@ func New(ctx context.Context, cli client.Client, meta v1alpha1.ActionMeta, conte
 	return &ret, nil
 }
 
+func newPluginJob(cli client.Client, meta v1alpha1.ActionMeta, contextData map[string]interface{}) (*Job, error) {
+	spec, err := ParsePlugin(meta)
+	if err != nil {
+		return nil, err
+	}
+	id, err := computeHash(meta)
+	if err != nil {
+		return nil, err
+	}
+	return &Job{
+		id:         id,
+		sourceType: meta.Type,
+		context:    contextData,
+		plugin:     spec,
+		cli:        cli,
+	}, nil
+}
+
+// ParsePlugin parses the properties of a wasm action.
+func ParsePlugin(meta v1alpha1.ActionMeta) (*v1alpha1.Plugin, error) {
+	p := &v1alpha1.Plugin{}
+	if meta.Properties == nil {
+		return p, nil
+	}
+	if err := json.Unmarshal(meta.Properties.Raw, p); err != nil {
+		return nil, errors.Wrapf(err, "error when parsing properties for %s", meta.Type)
+	}
+	return p, nil
+}
+
 func computeHash(obj interface{}) (string, error) {
 	// compute a hash value of any resource spec
 	specHash, err := hashstructure.Hash(obj, hashstructure.FormatV2, nil)
@@ -86,6 +127,16 @@ func (j *Job) ID() string {
 
 // Run execute action
 func (j *Job) Run(ctx context.Context) error {
+	if j.plugin != nil {
+		p, err := plugin.New(ctx, j.cli, *j.plugin, plugin.FunctionAction)
+		if err != nil {
+			return err
+		}
+		defer p.Release()
+		return p.Run(ctx, j.context)
+	}
+	// Actions can import the packages of kube-trigger, like filters.
+	registry.Load()
 	v, err := cuex.CompileStringWithOptions(ctx, j.template, cuex.WithExtraData("parameter", j.properties), cuex.WithExtraData("context", j.context))
 	if err != nil {
 		return err
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package batch accumulates events into batches, so that an action runs once
+// for many events.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+const defaultMaxWait = 10 * time.Second
+
+var logger = logrus.WithField("batch", "batcher")
+
+// FlushFunc is called with the group key and the contexts of the events in a
+// batch.
+type FlushFunc func(key string, events []interface{})
+
+// Validate validates a Batch.
+func Validate(c v1alpha1.Batch) error {
+	_, err := parseMaxWait(c)
+	if err != nil {
+		return err
+	}
+	if c.MaxSize < 0 {
+		return fmt.Errorf("batch maxSize must not be negative")
+	}
+	return nil
+}
+
+func parseMaxWait(c v1alpha1.Batch) (time.Duration, error) {
+	if c.MaxWait == "" {
+		return defaultMaxWait, nil
+	}
+	d, err := time.ParseDuration(c.MaxWait)
+	if err != nil {
+		return 0, fmt.Errorf("invalid batch maxWait %q: %w", c.MaxWait, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("batch maxWait must be positive")
+	}
+	return d, nil
+}
+
+type group struct {
+	events []interface{}
+	timer  *time.Timer
+}
+
+// Batcher accumulates events by group key. A batch is flushed when it has
+// MaxSize events, or MaxWait after its first event.
+type Batcher struct {
+	maxSize int
+	maxWait time.Duration
+	flush   FlushFunc
+
+	mu     sync.Mutex
+	groups map[string]*group
+	closed bool
+}
+
+// New creates a Batcher calling flush with each batch.
+func New(c v1alpha1.Batch, flush FlushFunc) (*Batcher, error) {
+	if err := Validate(c); err != nil {
+		return nil, err
+	}
+	maxWait, _ := parseMaxWait(c)
+	return &Batcher{
+		maxSize: c.MaxSize,
+		maxWait: maxWait,
+		flush:   flush,
+		groups:  make(map[string]*group),
+	}, nil
+}
+
+// Add adds the context of an event to the batch of key. The batch is flushed
+// synchronously if it is full.
+func (b *Batcher) Add(key string, event interface{}) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		logger.Infof("dropping event of batch %q, batcher is closed", key)
+		return
+	}
+	g, ok := b.groups[key]
+	if !ok {
+		g = &group{}
+		b.groups[key] = g
+		g.timer = time.AfterFunc(b.maxWait, func() {
+			b.flushGroup(key, g)
+		})
+	}
+	g.events = append(g.events, event)
+	full := b.maxSize > 0 && len(g.events) >= b.maxSize
+	b.mu.Unlock()
+	if full {
+		b.flushGroup(key, g)
+	}
+}
+
+// flushGroup flushes g, if it is still the batch of key. Either the timer or a
+// full batch may flush it first.
+func (b *Batcher) flushGroup(key string, g *group) {
+	b.mu.Lock()
+	if b.groups[key] != g {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.groups, key)
+	g.timer.Stop()
+	events := g.events
+	b.mu.Unlock()
+	b.flush(key, events)
+}
+
+// Close stops b, and flushes its pending batches until ctx is done. Batches
+// that are not flushed by then are dropped. Events added after Close are
+// dropped too.
+func (b *Batcher) Close(ctx context.Context) {
+	b.mu.Lock()
+	b.closed = true
+	groups := b.groups
+	b.groups = make(map[string]*group)
+	for _, g := range groups {
+		g.timer.Stop()
+	}
+	b.mu.Unlock()
+
+	dropped := 0
+	for key, g := range groups {
+		if ctx.Err() != nil {
+			dropped += len(g.events)
+			continue
+		}
+		b.flush(key, g.events)
+	}
+	if dropped > 0 {
+		logger.Warnf("dropped %d events of pending batches on close", dropped)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+type flushed struct {
+	mu      sync.Mutex
+	batches map[string][][]interface{}
+}
+
+func (f *flushed) flush(key string, events []interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches[key] = append(f.batches[key], events)
+}
+
+func (f *flushed) get(key string) [][]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches[key]
+}
+
+func TestMaxSize(t *testing.T) {
+	f := &flushed{batches: make(map[string][][]interface{})}
+	b, err := New(v1alpha1.Batch{MaxSize: 100, MaxWait: "1h"}, f.flush)
+	require.NoError(t, err)
+	for i := 0; i < 300; i++ {
+		b.Add("default", i)
+	}
+	b.Add("other", 0)
+	batches := f.get("default")
+	require.Len(t, batches, 3)
+	for _, batch := range batches {
+		assert.Len(t, batch, 100)
+	}
+	assert.Empty(t, f.get("other"))
+}
+
+func TestMaxWait(t *testing.T) {
+	f := &flushed{batches: make(map[string][][]interface{})}
+	b, err := New(v1alpha1.Batch{MaxWait: "50ms"}, f.flush)
+	require.NoError(t, err)
+	b.Add("a", 1)
+	b.Add("b", 1)
+	b.Add("a", 2)
+	assert.Eventually(t, func() bool {
+		return len(f.get("a")) == 1 && len(f.get("b")) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []interface{}{1, 2}, f.get("a")[0])
+
+	// A new batch starts after a flush.
+	b.Add("a", 3)
+	assert.Eventually(t, func() bool {
+		return len(f.get("a")) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClose(t *testing.T) {
+	f := &flushed{batches: make(map[string][][]interface{})}
+	b, err := New(v1alpha1.Batch{MaxWait: "1h"}, f.flush)
+	require.NoError(t, err)
+	b.Add("a", 1)
+	b.Add("a", 2)
+	b.Close(context.Background())
+	assert.Equal(t, [][]interface{}{{1, 2}}, f.get("a"))
+
+	// Added after Close, dropped.
+	b.Add("a", 3)
+	assert.Len(t, f.get("a"), 1)
+
+	// Pending batches are dropped once ctx is done.
+	b, err = New(v1alpha1.Batch{MaxWait: "1h"}, f.flush)
+	require.NoError(t, err)
+	b.Add("b", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.Close(ctx)
+	assert.Empty(t, f.get("b"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(v1alpha1.Batch{}))
+	assert.Error(t, Validate(v1alpha1.Batch{MaxWait: "soon"}))
+	assert.Error(t, Validate(v1alpha1.Batch{MaxWait: "0s"}))
+	assert.Error(t, Validate(v1alpha1.Batch{MaxSize: -1}))
+}
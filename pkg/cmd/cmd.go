@@ -19,6 +19,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
@@ -26,7 +27,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/kubevela/pkg/util/singleton"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,12 +41,16 @@ import (
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
 	"github.com/kubevela/kube-trigger/pkg/config"
+	"github.com/kubevela/kube-trigger/pkg/correlation"
 	"github.com/kubevela/kube-trigger/pkg/eventhandler"
 	"github.com/kubevela/kube-trigger/pkg/executor"
+	"github.com/kubevela/kube-trigger/pkg/filter"
 	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher"
 	sourceregistry "github.com/kubevela/kube-trigger/pkg/source/registry"
 	"github.com/kubevela/kube-trigger/pkg/source/types"
+	"github.com/kubevela/kube-trigger/pkg/storm"
 	"github.com/kubevela/kube-trigger/pkg/util/client"
 	"github.com/kubevela/kube-trigger/pkg/version"
 )
@@ -64,6 +71,11 @@ const (
 
 	FlagRegistrySize = "registry-size"
 
+	FlagStormThreshold = "storm-threshold"
+	FlagStormWindow    = "storm-window"
+
+	FlagMetricsAddr = "metrics-bind-address"
+
 	FlagLeaderElect                 = "leader-elect"
 	FlagLeaderElectionLeaseDuration = "leader-election-lease-duration"
 	FlagLeaderElectionRenewDeadline = "leader-election-renew-deadline"
@@ -132,7 +144,10 @@ func addFlags(opt *option, f *pflag.FlagSet) {
 	f.IntVar(&opt.MaxRetry, FlagMaxRetry, defaultMaxRetry, "Retry count after action failed, valid only when action retrying is enabled")
 	f.IntVar(&opt.RetryDelay, FlagRetryDelay, defaultRetryDelay, "First delay to retry actions in seconds, subsequent delay will grow exponentially")
 	f.IntVar(&opt.Timeout, FlagTimeout, defaultTimeout, "Timeout for running each action")
-	f.IntVar(&opt.RegistrySize, FlagRegistrySize, defaultRegistrySize, "Cache size for filters and actions")
+	f.IntVar(&opt.RegistrySize, FlagRegistrySize, defaultRegistrySize, "Cache size for compiled filters")
+	f.IntVar(&opt.StormThreshold, FlagStormThreshold, defaultStormThreshold, "Number of events passing filters of all triggers in a storm window, above which all triggers are in storm mode. 0 disables it")
+	f.IntVar(&opt.StormWindow, FlagStormWindow, defaultStormWindow, "Storm window in seconds, for storms across all triggers")
+	f.StringVar(&opt.MetricsAddr, FlagMetricsAddr, "", "The address the metrics endpoint binds to, e.g. :8080. Metrics are not served if empty")
 	f.StringVar(&k8sresourcewatcher.MultiClusterConfigType, "multi-cluster-config-type", k8sresourcewatcher.TypeClusterGateway, "Multi-cluster config type, supported types: cluster-gateway, cluster-gateway-kubeconfig")
 	f.BoolVar(&enableLeaderElection, FlagLeaderElect, false, "Enable leader election for kube-trigger. Enabling this will ensure there is only one active kube-trigger.")
 	f.DurationVar(&leaseDuration, FlagLeaderElectionLeaseDuration, defaultLeaseDuration, "The duration that non-leader candidates will wait to force acquire leadership.")
@@ -172,6 +187,12 @@ func run(ctx context.Context) error {
 		return err
 	}
 
+	filter.SetCacheSize(opt.RegistrySize)
+	storm.SetGlobal(opt.StormThreshold, time.Second*time.Duration(opt.StormWindow))
+	if opt.MetricsAddr != "" {
+		go serveMetrics(opt.MetricsAddr)
+	}
+
 	// Create registries for Sources
 	sourceReg := sourceregistry.NewWithBuiltinSources()
 
@@ -196,32 +217,31 @@ func run(ctx context.Context) error {
 	instances := make(map[string]types.Source)
 
 	// Run watchers.
-	for _, w := range conf.Triggers {
-		// Make this Source type exists.
-		s, ok := sourceReg.Get(w.Source.Type)
-		if !ok {
-			logger.Errorf("source type %s does not exist", w.Source.Type)
+	for i, w := range conf.Triggers {
+		// Create a EventHandler. Related objects may be in other clusters, so
+		// enrich with the multi-cluster client.
+		eh, err := eventhandler.NewFromConfig(ctx, cli, singleton.KubeClient.Get(), fmt.Sprintf("%d", i), w, exe)
+		if err != nil {
+			logger.Errorf("failed to create event handler for trigger: %s", err)
 			continue
 		}
 
-		source := s.New()
-		if s.Singleton() {
-			if s, ok := instances[w.Source.Type]; ok {
-				source = s
-			}
+		if w.Correlate == nil {
+			initSource(sourceReg, instances, fmt.Sprintf("%d", i), w.Source, eh)
+			continue
 		}
 
-		// Create a EventHandler
-		eh := eventhandler.NewFromConfig(ctx, cli, w.Action, w.Filter, exe)
-
-		// Initialize Source, with user-provided prop and event handler
-		err = source.Init(w.Source.Properties, eh)
+		// Feed the events of all correlated sources to a correlator, which
+		// calls eh when the pattern matches.
+		correlator, err := correlation.New(ctx, *w.Correlate, eh)
 		if err != nil {
-			logger.Errorf("failed to initialize source %s: %s", source.Type(), err)
+			logger.Errorf("failed to create correlator: %s", err)
 			continue
 		}
-
-		instances[w.Source.Type] = source
+		for _, e := range w.Correlate.Events {
+			initSource(sourceReg, instances, fmt.Sprintf("%d/%s", i, e.Name), e.Source, correlator.EventHandler(ctx, e.Name))
+		}
+		go correlator.Run(ctx)
 	}
 
 	for _, instance := range instances {
@@ -237,6 +257,37 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+// initSource initializes a Source with user-provided properties and an event
+// handler, and adds it to instances. Singleton Sources are keyed by their
+// type, and initialized again if they are already in instances. Other Sources
+// are keyed by id, which identifies the trigger and the correlated event.
+func initSource(sourceReg *sourceregistry.Registry, instances map[string]types.Source, id string, src v1alpha1.Source, eh eventhandler.EventHandler) {
+	// Make this Source type exists.
+	s, ok := sourceReg.Get(src.Type)
+	if !ok {
+		logger.Errorf("source type %s does not exist", src.Type)
+		return
+	}
+
+	source := s.New()
+	key := src.Type + "/" + id
+	if s.Singleton() {
+		key = src.Type
+		if s, ok := instances[key]; ok {
+			source = s
+		}
+	}
+
+	// Initialize Source, with user-provided prop and event handler
+	err := source.Init(src.Properties, eh)
+	if err != nil {
+		logger.Errorf("failed to initialize source %s: %s", source.Type(), err)
+		return
+	}
+
+	instances[key] = source
+}
+
 // Runner manages the task execution.
 type Runner struct {
 	errChan chan error
@@ -317,3 +368,14 @@ func (r *Runner) startLeaderElection(ctx context.Context) error {
 func (r *Runner) Err() chan error {
 	return r.errChan
 }
+
+// serveMetrics serves the metrics of kube-trigger at addr.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	logger.Infof("serving metrics at %s", addr)
+	if err := srv.ListenAndServe(); err != nil {
+		logger.Errorf("cannot serve metrics: %s", err)
+	}
+}
@@ -38,6 +38,11 @@ type option struct {
 	Timeout      int
 
 	RegistrySize int
+
+	StormThreshold int
+	StormWindow    int
+
+	MetricsAddr string
 }
 
 const (
@@ -54,6 +59,9 @@ const (
 
 	defaultRegistrySize = 100
 
+	defaultStormThreshold = 0
+	defaultStormWindow    = 10
+
 	// Values taken from: https://github.com/kubernetes/component-base/blob/master/config/v1alpha1/defaults.go
 	defaultLeaseDuration = 15 * time.Second
 	defaultRenewDeadline = 10 * time.Second
@@ -93,6 +101,12 @@ func (o *option) validate() error {
 	if o.RegistrySize <= 0 {
 		return fmt.Errorf("%s must be greater than 0", FlagRegistrySize)
 	}
+	if o.StormThreshold < 0 {
+		return fmt.Errorf("%s must be greater or equal to 0", FlagStormThreshold)
+	}
+	if o.StormWindow <= 0 {
+		return fmt.Errorf("%s must be greater than 0", FlagStormWindow)
+	}
 	return nil
 }
 
@@ -22,10 +22,20 @@ import (
 
 	"github.com/kubevela/pkg/util/template/definition"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kubevela/kube-trigger/api/v1alpha1"
+	"github.com/kubevela/kube-trigger/pkg/action"
+	"github.com/kubevela/kube-trigger/pkg/batch"
+	"github.com/kubevela/kube-trigger/pkg/correlation"
+	"github.com/kubevela/kube-trigger/pkg/enrich"
+	"github.com/kubevela/kube-trigger/pkg/filter"
+	"github.com/kubevela/kube-trigger/pkg/plugin"
 	sourceregistry "github.com/kubevela/kube-trigger/pkg/source/registry"
+	sourcetypes "github.com/kubevela/kube-trigger/pkg/source/types"
+	"github.com/kubevela/kube-trigger/pkg/state"
+	"github.com/kubevela/kube-trigger/pkg/storm"
 	"github.com/kubevela/kube-trigger/pkg/types"
 )
 
@@ -43,13 +53,133 @@ func (c *Config) Validate(ctx context.Context, cli client.Client, sourceReg *sou
 	}
 	// TODO(charlie0129): gather all errors before returning
 	for _, w := range c.Triggers {
-		if _, ok := sourceReg.Get(w.Source.Type); !ok {
-			return fmt.Errorf("no such source found: %s", w.Source.Type)
+		if w.Correlate == nil {
+			if _, ok := sourceReg.Get(w.Source.Type); !ok {
+				return fmt.Errorf("no such source found: %s", w.Source.Type)
+			}
+		} else {
+			if err := correlation.Validate(ctx, *w.Correlate); err != nil {
+				return err
+			}
+			for _, e := range w.Correlate.Events {
+				if _, ok := sourceReg.Get(e.Source.Type); !ok {
+					return fmt.Errorf("no such source found: %s", e.Source.Type)
+				}
+			}
 		}
-		if _, err := definition.NewTemplateLoader(ctx, cli).LoadTemplate(ctx, w.Action.Type, definition.WithType(types.DefinitionTypeTriggerAction)); err != nil {
-			return errors.WithMessagef(err, "no such action found: %s", w.Action.Type)
+		var actionTemplate string
+		if w.Action.Type == v1alpha1.ActionTypeWASM {
+			p, err := action.ParsePlugin(w.Action)
+			if err != nil {
+				return err
+			}
+			if err := plugin.Validate(*p); err != nil {
+				return errors.WithMessage(err, "invalid wasm action")
+			}
+		} else {
+			template, err := definition.NewTemplateLoader(ctx, cli).LoadTemplate(ctx, w.Action.Type, definition.WithType(types.DefinitionTypeTriggerAction))
+			if err != nil {
+				return errors.WithMessagef(err, "no such action found: %s", w.Action.Type)
+			}
+			actionTemplate = template.Compile()
+		}
+		if _, err := filter.New(ctx, w.Filter, w.FilterLanguage); err != nil {
+			return errors.WithMessage(err, "invalid filter")
+		}
+		if err := checkReferences(ctx, cli, sourceReg, w, actionTemplate); err != nil {
+			return err
+		}
+		if w.FilterRef != nil {
+			if _, err := filter.NewFromRef(ctx, cli, *w.FilterRef); err != nil {
+				return errors.WithMessage(err, "invalid filterRef")
+			}
+		}
+		if w.FilterPlugin != nil {
+			if err := plugin.Validate(*w.FilterPlugin); err != nil {
+				return errors.WithMessage(err, "invalid filterPlugin")
+			}
+		}
+		switch w.OnFilterError {
+		case "", v1alpha1.OnFilterErrorDrop, v1alpha1.OnFilterErrorPass, v1alpha1.OnFilterErrorRetry:
+		default:
+			return fmt.Errorf("unknown onFilterError %q, must be drop, pass or retry", w.OnFilterError)
+		}
+		if w.Batch != nil {
+			if err := batch.Validate(*w.Batch); err != nil {
+				return err
+			}
+			if _, err := filter.CompileKey(ctx, w.Batch.GroupBy); err != nil {
+				return fmt.Errorf("invalid batch groupBy: %w", err)
+			}
+		}
+		if w.Enrich != nil {
+			if _, err := enrich.ParseTTL(*w.Enrich); err != nil {
+				return err
+			}
+		}
+		if w.State != nil {
+			if err := state.Validate(*w.State); err != nil {
+				return err
+			}
+		}
+		if w.Storm != nil {
+			if err := storm.Validate(*w.Storm); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
+
+// checkReferences checks that the filters and the action of a trigger only
+// refer to fields of context that the events of its sources have, if the
+// sources have schemas.
+func checkReferences(ctx context.Context, cli client.Client, sourceReg *sourceregistry.Registry, w v1alpha1.TriggerMeta, actionTemplate string) error {
+	if w.Correlate != nil {
+		for _, e := range w.Correlate.Events {
+			s := schemaOf(ctx, cli, sourceReg, e.Source)
+			if s == nil {
+				continue
+			}
+			if err := filter.CheckFilterReferences(e.Filter, e.FilterLanguage, s.Event, s.Data); err != nil {
+				return errors.WithMessagef(err, "invalid filter of event %s", e.Name)
+			}
+		}
+		return nil
+	}
+	s := schemaOf(ctx, cli, sourceReg, w.Source)
+	if s == nil {
+		return nil
+	}
+	if err := filter.CheckFilterReferences(w.Filter, w.FilterLanguage, s.Event, s.Data); err != nil {
+		return errors.WithMessage(err, "invalid filter")
+	}
+	// Batched actions get the contexts of events in context.events instead.
+	if w.Batch != nil || actionTemplate == "" {
+		return nil
+	}
+	if err := filter.CheckReferences(actionTemplate, s.Event, s.Data); err != nil {
+		return errors.WithMessagef(err, "action %s does not match the events of source %s", w.Action.Type, w.Source.Type)
+	}
+	return nil
+}
+
+// schemaOf gets the schema of the events of a source, or nil if it has none.
+// Schemas that cannot be got are not checked.
+func schemaOf(ctx context.Context, cli client.Client, sourceReg *sourceregistry.Registry, source v1alpha1.Source) *sourcetypes.Schema {
+	src, ok := sourceReg.Get(source.Type)
+	if !ok {
+		return nil
+	}
+	schemaSource, ok := src.(sourcetypes.SchemaSource)
+	if !ok {
+		return nil
+	}
+	s, err := schemaSource.Schema(ctx, cli, source.Properties)
+	if err != nil {
+		logrus.Warnf("cannot get the schema of events of source %s, filters and actions are not checked against it: %s", source.Type, err)
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package correlation matches patterns across events from several sources
+// within a window, i.e., complex event processing.
+package correlation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/filter"
+	"github.com/kubevela/kube-trigger/pkg/state"
+)
+
+// SourceType is the sourceType of events fired by a Correlator.
+const SourceType = "correlation"
+
+// Pattern types.
+const (
+	PatternAll      = "all"
+	PatternSequence = "sequence"
+	PatternCount    = "count"
+	PatternAbsence  = "absence"
+)
+
+// tick is how often expired partial matches are checked.
+var tick = time.Second
+
+var logger = logrus.WithField("correlation", "correlator")
+
+// Event is the event fired when a pattern matches.
+type Event struct {
+	Pattern string `json:"pattern"`
+	Key     string `json:"key"`
+}
+
+// Data is the data fired when a pattern matches.
+type Data struct {
+	Key string `json:"key"`
+	// Events are the contexts of the matched events, keyed by event name.
+	Events map[string][]map[string]interface{} `json:"events"`
+}
+
+// Validate validates a Correlation, compiling the filters and keys of its
+// events.
+func Validate(ctx context.Context, c v1alpha1.Correlation) error {
+	_, err := parse(ctx, c)
+	return err
+}
+
+type config struct {
+	pattern string
+	count   int
+	window  time.Duration
+	events  []v1alpha1.CorrelatedEvent
+	filters []filter.Filter
+	keys    []*filter.Key
+	index   map[string]int
+}
+
+func parse(ctx context.Context, c v1alpha1.Correlation) (*config, error) {
+	conf := &config{
+		pattern: c.Pattern.Type,
+		count:   c.Pattern.Count,
+		events:  c.Events,
+		index:   make(map[string]int),
+	}
+	window, err := time.ParseDuration(c.Window)
+	if err != nil {
+		return nil, fmt.Errorf("invalid correlation window %q: %w", c.Window, err)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("correlation window must be positive")
+	}
+	conf.window = window
+	if len(c.Events) == 0 {
+		return nil, fmt.Errorf("correlation needs at least one event")
+	}
+	for i, e := range c.Events {
+		if e.Name == "" {
+			return nil, fmt.Errorf("correlated event %d has no name", i)
+		}
+		if _, ok := conf.index[e.Name]; ok {
+			return nil, fmt.Errorf("duplicate correlated event %s", e.Name)
+		}
+		conf.index[e.Name] = i
+		f, err := filter.New(ctx, e.Filter, e.FilterLanguage)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter of correlated event %s: %w", e.Name, err)
+		}
+		conf.filters = append(conf.filters, f)
+		k, err := filter.CompileKey(ctx, e.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key of correlated event %s: %w", e.Name, err)
+		}
+		conf.keys = append(conf.keys, k)
+	}
+	switch c.Pattern.Type {
+	case PatternAll, PatternSequence:
+	case PatternCount:
+		if c.Pattern.Count < 1 {
+			return nil, fmt.Errorf("count pattern needs a positive count")
+		}
+	case PatternAbsence:
+		if len(c.Events) < 2 {
+			return nil, fmt.Errorf("absence pattern needs at least two events")
+		}
+	default:
+		return nil, fmt.Errorf("unknown correlation pattern %q", c.Pattern.Type)
+	}
+	return conf, nil
+}
+
+type record struct {
+	name    string
+	context map[string]interface{}
+	time    time.Time
+}
+
+// partial is a partial match of a key.
+type partial struct {
+	records []record
+	// next is the index of the next expected event of a sequence.
+	next int
+}
+
+// Correlator is fed with events from sources, and fires its EventHandler when
+// a pattern matches.
+type Correlator struct {
+	*config
+	handler eventhandler.EventHandler
+	now     func() time.Time
+	// store keeps the state of stateful builtins in event filters.
+	store state.Store
+
+	mu       sync.Mutex
+	partials map[string]*partial
+}
+
+// New creates a Correlator, calling handler when the pattern of c matches.
+func New(ctx context.Context, c v1alpha1.Correlation, handler eventhandler.EventHandler) (*Correlator, error) {
+	conf, err := parse(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return &Correlator{
+		config:   conf,
+		handler:  handler,
+		now:      time.Now,
+		store:    state.NewMemory(),
+		partials: make(map[string]*partial),
+	}, nil
+}
+
+// EventHandler returns the EventHandler to give to the source of the named
+// event.
+func (c *Correlator) EventHandler(ctx context.Context, name string) eventhandler.EventHandler {
+	e := c.events[c.index[name]]
+	f := c.filters[c.index[name]]
+	k := c.keys[c.index[name]]
+	l := logger.WithField("event", name)
+	return func(sourceType string, event interface{}, data interface{}) error {
+		// Actions of correlated events are not tracked per event.
+		data, _ = eventhandler.Untrack(data)
+		now := c.now()
+		context := map[string]interface{}{
+			"sourceType": sourceType,
+			"event":      event,
+			"data":       data,
+			"timestamp":  now.Format(time.RFC3339),
+		}
+		if e.Filter != "" {
+			// Stateful builtins only update the state if the event is kept.
+			tx := state.NewTx(c.store)
+			res, err := f.Eval(state.WithStore(ctx, tx), context)
+			if err != nil {
+				l.Errorf("error when applying filter to event %v: %s", event, err)
+			}
+			if !res.Kept {
+				return eventhandler.ErrFilteredOut
+			}
+			if err := tx.Commit(ctx); err != nil {
+				l.Errorf("error when saving the state of filter: %s", err)
+			}
+			if res.Output != nil {
+				context["filter"] = res.Output
+			}
+		}
+		key, err := k.Evaluate(ctx, context)
+		if err != nil {
+			l.Errorf("error when evaluating key of event %v: %s", event, err)
+			return err
+		}
+		if matched := c.observe(record{name: name, context: context, time: now}, key); matched != nil {
+			return c.fire(key, matched)
+		}
+		return nil
+	}
+}
+
+// Run checks expired partial matches until ctx is done. Absence patterns fire
+// here.
+func (c *Correlator) Run(ctx context.Context) {
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			c.expire()
+		}
+	}
+}
+
+// observe adds r to the partial match of key, and returns the matched records
+// if the pattern matches.
+func (c *Correlator) observe(r record, key string) []record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.partials[key]
+	if p != nil && c.pattern != PatternAll && c.pattern != PatternCount && c.expired(p, r.time) {
+		delete(c.partials, key)
+		p = nil
+	}
+	if p == nil {
+		p = &partial{}
+	}
+	idx := c.index[r.name]
+
+	switch c.pattern {
+	case PatternAll, PatternCount:
+		// Windows of all and count slide.
+		cutoff := r.time.Add(-c.window)
+		kept := p.records[:0]
+		for _, old := range p.records {
+			if old.time.After(cutoff) {
+				kept = append(kept, old)
+			}
+		}
+		p.records = append(kept, r)
+		if c.pattern == PatternCount && len(p.records) < c.count {
+			break
+		}
+		if c.pattern == PatternAll && !c.seenAll(p) {
+			break
+		}
+		delete(c.partials, key)
+		return p.records
+	case PatternSequence:
+		if idx != p.next {
+			break
+		}
+		p.records = append(p.records, r)
+		p.next++
+		if p.next == len(c.events) {
+			delete(c.partials, key)
+			return p.records
+		}
+	case PatternAbsence:
+		if idx != 0 {
+			// The expected event happened.
+			delete(c.partials, key)
+			return nil
+		}
+		p.records = append(p.records, r)
+	}
+	if len(p.records) > 0 {
+		c.partials[key] = p
+	}
+	return nil
+}
+
+// expire drops partial matches older than the window, and fires absence
+// patterns.
+func (c *Correlator) expire() {
+	now := c.now()
+	matched := make(map[string][]record)
+	c.mu.Lock()
+	for key, p := range c.partials {
+		if !c.expired(p, now) {
+			continue
+		}
+		if c.pattern == PatternAbsence {
+			matched[key] = p.records
+		}
+		delete(c.partials, key)
+	}
+	c.mu.Unlock()
+	for key, records := range matched {
+		_ = c.fire(key, records)
+	}
+}
+
+// expired reports whether the window of p ended at now. The window of
+// all and count patterns starts from the latest event, otherwise from the
+// first.
+func (c *Correlator) expired(p *partial, now time.Time) bool {
+	start := p.records[0].time
+	if c.pattern == PatternAll || c.pattern == PatternCount {
+		start = p.records[len(p.records)-1].time
+	}
+	return !now.Before(start.Add(c.window))
+}
+
+func (c *Correlator) seenAll(p *partial) bool {
+	seen := make(map[string]bool)
+	for _, r := range p.records {
+		seen[r.name] = true
+	}
+	return len(seen) == len(c.events)
+}
+
+func (c *Correlator) fire(key string, records []record) error {
+	data := Data{Key: key, Events: make(map[string][]map[string]interface{})}
+	for _, r := range records {
+		data.Events[r.name] = append(data.Events[r.name], r.context)
+	}
+	logger.Infof("%s pattern matched for key %q", c.pattern, key)
+	return c.handler(SourceType, Event{Pattern: c.pattern, Key: key}, data)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package correlation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+)
+
+type fired struct {
+	event Event
+	data  Data
+}
+
+func newTestCorrelator(t *testing.T, c v1alpha1.Correlation) (*Correlator, *[]fired, *time.Time) {
+	var got []fired
+	corr, err := New(context.Background(), c, func(sourceType string, event, data interface{}) error {
+		assert.Equal(t, SourceType, sourceType)
+		got = append(got, fired{event: event.(Event), data: data.(Data)})
+		return nil
+	})
+	require.NoError(t, err)
+	now := time.Unix(0, 0)
+	corr.now = func() time.Time { return now }
+	return corr, &got, &now
+}
+
+func pod(name, reason string) map[string]interface{} {
+	return map[string]interface{}{"pod": name, "reason": reason}
+}
+
+func TestCount(t *testing.T) {
+	c, got, now := newTestCorrelator(t, v1alpha1.Correlation{
+		Window:  "10m",
+		Pattern: v1alpha1.CorrelationPattern{Type: PatternCount, Count: 3},
+		Events: []v1alpha1.CorrelatedEvent{{
+			Name:   "backoff",
+			Filter: `context.data.reason == "BackOff"`,
+			Key:    "context.data.pod",
+		}},
+	})
+	ctx := context.Background()
+	eh := c.EventHandler(ctx, "backoff")
+
+	assert.NoError(t, eh("", nil, pod("a", "BackOff")))
+	assert.NoError(t, eh("", nil, pod("b", "BackOff")))
+	assert.ErrorIs(t, eh("", nil, pod("a", "Pulled")), eventhandler.ErrFilteredOut)
+	*now = now.Add(11 * time.Minute)
+	// The first BackOff of a slid out of the window.
+	assert.NoError(t, eh("", nil, pod("a", "BackOff")))
+	assert.NoError(t, eh("", nil, pod("a", "BackOff")))
+	assert.Empty(t, *got)
+	assert.NoError(t, eh("", nil, pod("a", "BackOff")))
+	require.Len(t, *got, 1)
+	assert.Equal(t, Event{Pattern: PatternCount, Key: "a"}, (*got)[0].event)
+	assert.Len(t, (*got)[0].data.Events["backoff"], 3)
+}
+
+func TestAll(t *testing.T) {
+	c, got, now := newTestCorrelator(t, v1alpha1.Correlation{
+		Window:  "5m",
+		Pattern: v1alpha1.CorrelationPattern{Type: PatternAll},
+		Events: []v1alpha1.CorrelatedEvent{
+			{Name: "deploymentUpdated", Key: "context.data.app"},
+			{Name: "podCrashLooping", Key: "context.data.app", Filter: `context.data.app != ""`, FilterLanguage: "cel"},
+		},
+	})
+	ctx := context.Background()
+	deploy := c.EventHandler(ctx, "deploymentUpdated")
+	crash := c.EventHandler(ctx, "podCrashLooping")
+
+	assert.NoError(t, crash("", nil, map[string]interface{}{"app": "web"}))
+	assert.ErrorIs(t, crash("", nil, map[string]interface{}{"app": ""}), eventhandler.ErrFilteredOut)
+	assert.NoError(t, deploy("", nil, map[string]interface{}{"app": "api"}))
+	*now = now.Add(time.Minute)
+	assert.NoError(t, deploy("", nil, map[string]interface{}{"app": "web"}))
+	require.Len(t, *got, 1)
+	assert.Equal(t, "web", (*got)[0].data.Key)
+	assert.Len(t, (*got)[0].data.Events, 2)
+
+	// The partial match of api expires.
+	*now = now.Add(5 * time.Minute)
+	c.expire()
+	assert.Empty(t, c.partials)
+}
+
+func TestSequence(t *testing.T) {
+	c, got, now := newTestCorrelator(t, v1alpha1.Correlation{
+		Window:  "5m",
+		Pattern: v1alpha1.CorrelationPattern{Type: PatternSequence},
+		Events:  []v1alpha1.CorrelatedEvent{{Name: "first"}, {Name: "second"}},
+	})
+	ctx := context.Background()
+	first := c.EventHandler(ctx, "first")
+	second := c.EventHandler(ctx, "second")
+
+	assert.NoError(t, second("", nil, nil))
+	assert.NoError(t, first("", nil, nil))
+	*now = now.Add(6 * time.Minute)
+	assert.NoError(t, second("", nil, nil))
+	assert.Empty(t, *got)
+	assert.NoError(t, first("", nil, nil))
+	assert.NoError(t, second("", nil, nil))
+	assert.Len(t, *got, 1)
+}
+
+func TestAbsence(t *testing.T) {
+	c, got, now := newTestCorrelator(t, v1alpha1.Correlation{
+		Window:  "2m",
+		Pattern: v1alpha1.CorrelationPattern{Type: PatternAbsence},
+		Events: []v1alpha1.CorrelatedEvent{
+			{Name: "webhook", Key: "context.data.app"},
+			{Name: "appUpdated", Key: "context.data.app"},
+		},
+	})
+	ctx := context.Background()
+	webhook := c.EventHandler(ctx, "webhook")
+	updated := c.EventHandler(ctx, "appUpdated")
+
+	assert.NoError(t, webhook("", nil, map[string]interface{}{"app": "a"}))
+	assert.NoError(t, webhook("", nil, map[string]interface{}{"app": "b"}))
+	assert.NoError(t, updated("", nil, map[string]interface{}{"app": "a"}))
+	*now = now.Add(time.Minute)
+	c.expire()
+	assert.Empty(t, *got)
+	*now = now.Add(time.Minute)
+	c.expire()
+	require.Len(t, *got, 1)
+	assert.Equal(t, Event{Pattern: PatternAbsence, Key: "b"}, (*got)[0].event)
+	assert.Empty(t, c.partials)
+}
+
+func TestValidate(t *testing.T) {
+	events := []v1alpha1.CorrelatedEvent{{Name: "a"}}
+	testcases := map[string]v1alpha1.Correlation{
+		"bad window":       {Window: "x", Pattern: v1alpha1.CorrelationPattern{Type: PatternAll}, Events: events},
+		"no events":        {Window: "1m", Pattern: v1alpha1.CorrelationPattern{Type: PatternAll}},
+		"duplicate events": {Window: "1m", Pattern: v1alpha1.CorrelationPattern{Type: PatternAll}, Events: append(events, events...)},
+		"no count":         {Window: "1m", Pattern: v1alpha1.CorrelationPattern{Type: PatternCount}, Events: events},
+		"single absence":   {Window: "1m", Pattern: v1alpha1.CorrelationPattern{Type: PatternAbsence}, Events: events},
+		"unknown pattern":  {Window: "1m", Pattern: v1alpha1.CorrelationPattern{Type: "any"}, Events: events},
+		"bad key":          {Window: "1m", Pattern: v1alpha1.CorrelationPattern{Type: PatternAll}, Events: []v1alpha1.CorrelatedEvent{{Name: "a", Key: "context.("}}},
+		"bad language":     {Window: "1m", Pattern: v1alpha1.CorrelationPattern{Type: PatternAll}, Events: []v1alpha1.CorrelatedEvent{{Name: "a", FilterLanguage: "rego"}}},
+	}
+	for name, c := range testcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, Validate(context.Background(), c))
+		})
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package enrich fetches objects related to the object of an event, so that
+// filters and actions can use them.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+const (
+	defaultTTL = 30 * time.Second
+	// cacheSize is the max number of objects cached by an Enricher.
+	cacheSize = 1024
+	// maxOwnerDepth limits the owner chain, in case of cycles.
+	maxOwnerDepth = 10
+
+	labelAppName      = "app.oam.dev/name"
+	labelAppNamespace = "app.oam.dev/namespace"
+	localCluster      = "local"
+)
+
+var logger = logrus.WithField("enrich", "related")
+
+// ParseTTL parses the TTL of c.
+func ParseTTL(c v1alpha1.Enrich) (time.Duration, error) {
+	if c.TTL == "" {
+		return defaultTTL, nil
+	}
+	d, err := time.ParseDuration(c.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid enrich ttl %q: %w", c.TTL, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("enrich ttl must not be negative")
+	}
+	return d, nil
+}
+
+// Enricher fetches related objects, caching them for a TTL.
+type Enricher struct {
+	cli    client.Client
+	config v1alpha1.Enrich
+	ttl    time.Duration
+	cache  *cache.LRUExpireCache
+}
+
+// New creates an Enricher. cli must be able to fetch objects from other
+// clusters if events of them are enriched.
+func New(cli client.Client, c v1alpha1.Enrich) (*Enricher, error) {
+	ttl, err := ParseTTL(c)
+	if err != nil {
+		return nil, err
+	}
+	return &Enricher{
+		cli:    cli,
+		config: c,
+		ttl:    ttl,
+		cache:  cache.NewLRUExpireCache(cacheSize),
+	}, nil
+}
+
+// Related returns the objects related to the object in data, keyed as in
+// context.related. Missing objects are left out. event is used to find the
+// cluster of the object. Sources passing data that is not an object get an
+// empty result.
+func (e *Enricher) Related(ctx context.Context, event, data interface{}) map[string]interface{} {
+	related := make(map[string]interface{})
+	obj, ok := toUnstructured(data)
+	if !ok || obj.GetName() == "" {
+		return related
+	}
+	if cluster := clusterOf(event); cluster != "" && cluster != localCluster {
+		ctx = multicluster.WithCluster(ctx, cluster)
+	}
+	l := logger.WithField("object", obj.GetKind()+" "+obj.GetNamespace()+"/"+obj.GetName())
+
+	if e.config.Owners {
+		owners := make([]interface{}, 0)
+		cur := obj
+		for i := 0; i < maxOwnerDepth; i++ {
+			ref := controllerOf(cur)
+			if ref == nil {
+				break
+			}
+			owner, err := e.get(ctx, ref.APIVersion, ref.Kind, cur.GetNamespace(), ref.Name)
+			if err != nil {
+				l.Debugf("cannot get owner %s %s: %s", ref.Kind, ref.Name, err)
+				break
+			}
+			owners = append(owners, owner.Object)
+			cur = owner
+		}
+		related["owners"] = owners
+	}
+	if e.config.Namespace && obj.GetNamespace() != "" {
+		if ns, err := e.get(ctx, "v1", "Namespace", "", obj.GetNamespace()); err == nil {
+			related["namespace"] = ns.Object
+		} else {
+			l.Debugf("cannot get namespace: %s", err)
+		}
+	}
+	if e.config.Application {
+		if name := obj.GetLabels()[labelAppName]; name != "" {
+			namespace := obj.GetLabels()[labelAppNamespace]
+			if namespace == "" {
+				namespace = obj.GetNamespace()
+			}
+			if app, err := e.get(ctx, "core.oam.dev/v1beta1", "Application", namespace, name); err == nil {
+				related["application"] = app.Object
+			} else {
+				l.Debugf("cannot get application %s/%s: %s", namespace, name, err)
+			}
+		}
+	}
+	if e.config.ConfigMaps || e.config.Secrets {
+		configMaps, secrets := podSpecReferences(obj)
+		if e.config.ConfigMaps {
+			related["configMaps"] = e.getAll(ctx, l, "ConfigMap", obj.GetNamespace(), configMaps)
+		}
+		if e.config.Secrets {
+			related["secrets"] = e.getAll(ctx, l, "Secret", obj.GetNamespace(), secrets)
+		}
+	}
+	return related
+}
+
+func (e *Enricher) getAll(ctx context.Context, l *logrus.Entry, kind, namespace string, names []string) map[string]interface{} {
+	objs := make(map[string]interface{})
+	for _, name := range names {
+		obj, err := e.get(ctx, "v1", kind, namespace, name)
+		if err != nil {
+			l.Debugf("cannot get %s %s: %s", kind, name, err)
+			continue
+		}
+		objs[name] = obj.Object
+	}
+	return objs
+}
+
+type cacheEntry struct {
+	obj *unstructured.Unstructured
+	err error
+}
+
+// get gets an object from the cache, or from the cluster. Objects that are not
+// found are cached too.
+func (e *Enricher) get(ctx context.Context, apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	cluster, _ := multicluster.ClusterFrom(ctx)
+	key := strings.Join([]string{cluster, apiVersion, kind, namespace, name}, "/")
+	if v, ok := e.cache.Get(key); ok {
+		entry := v.(cacheEntry)
+		return entry.obj, entry.err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	err := e.cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err != nil {
+		obj = nil
+	}
+	if e.ttl > 0 {
+		e.cache.Add(key, cacheEntry{obj: obj, err: err}, e.ttl)
+	}
+	return obj, err
+}
+
+func controllerOf(obj metav1.Object) *metav1.OwnerReference {
+	if ref := metav1.GetControllerOf(obj); ref != nil {
+		return ref
+	}
+	if refs := obj.GetOwnerReferences(); len(refs) > 0 {
+		return &refs[0]
+	}
+	return nil
+}
+
+func toUnstructured(data interface{}) (*unstructured.Unstructured, bool) {
+	switch d := data.(type) {
+	case *unstructured.Unstructured:
+		return d, true
+	case map[string]interface{}:
+		return &unstructured.Unstructured{Object: d}, true
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, false
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, false
+	}
+	return &unstructured.Unstructured{Object: m}, true
+}
+
+func clusterOf(event interface{}) string {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return ""
+	}
+	var e struct {
+		Cluster string `json:"cluster"`
+	}
+	_ = json.Unmarshal(b, &e)
+	return e.Cluster
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package enrich
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+type countingClient struct {
+	client.Client
+	gets int
+}
+
+func (c *countingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.gets++
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func TestRelated(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"tier": "prod"}}}
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", UID: "d"}}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-1", Namespace: "prod", UID: "r",
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "d", Controller: pointer.Bool(true)}},
+	}}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "conf", Namespace: "prod"}, Data: map[string]string{"k": "v"}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "prod"}}
+	cli := &countingClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(ns, deploy, rs, cm, secret).Build()}
+
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web-1-abc", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-1", UID: "r", Controller: pointer.Bool(true)}},
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{Name: "c", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "conf"}}}}},
+			Containers: []corev1.Container{{
+				Name: "web",
+				EnvFrom: []corev1.EnvFromSource{
+					{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}}},
+					{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}}},
+				},
+			}},
+		},
+	}
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	require.NoError(t, err)
+
+	e, err := New(cli, v1alpha1.Enrich{Owners: true, ConfigMaps: true, Secrets: true, Namespace: true, Application: true})
+	require.NoError(t, err)
+	related := e.Related(context.Background(), map[string]string{"type": "update"}, data)
+
+	owners := related["owners"].([]interface{})
+	require.Len(t, owners, 2)
+	assert.Equal(t, "ReplicaSet", owners[0].(map[string]interface{})["kind"])
+	assert.Equal(t, "Deployment", owners[1].(map[string]interface{})["kind"])
+	assert.Equal(t, "prod", related["namespace"].(map[string]interface{})["metadata"].(map[string]interface{})["labels"].(map[string]interface{})["tier"])
+	assert.Contains(t, related["configMaps"], "conf")
+	assert.NotContains(t, related["configMaps"], "missing")
+	assert.Contains(t, related["secrets"], "creds")
+	assert.NotContains(t, related, "application")
+
+	// Everything, including the missing ConfigMap, is cached.
+	gets := cli.gets
+	e.Related(context.Background(), nil, data)
+	assert.Equal(t, gets, cli.gets)
+}
+
+func TestRelatedNotAnObject(t *testing.T) {
+	e, err := New(nil, v1alpha1.Enrich{Owners: true})
+	require.NoError(t, err)
+	assert.Empty(t, e.Related(context.Background(), nil, "not an object"))
+}
+
+func TestParseTTL(t *testing.T) {
+	_, err := ParseTTL(v1alpha1.Enrich{TTL: "abc"})
+	assert.Error(t, err)
+	d, err := ParseTTL(v1alpha1.Enrich{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultTTL, d)
+}
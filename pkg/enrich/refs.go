@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package enrich
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// podSpecPaths are where pod specs are in Pods, workloads and CronJobs.
+var podSpecPaths = [][]string{
+	{"spec"},
+	{"spec", "template", "spec"},
+	{"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// podSpecReferences returns the names of ConfigMaps and Secrets referenced by
+// the pod spec of obj, in volumes, env, envFrom and imagePullSecrets.
+func podSpecReferences(obj *unstructured.Unstructured) (configMaps, secrets []string) {
+	spec := podSpec(obj)
+	if spec == nil {
+		return nil, nil
+	}
+	cms := make(map[string]bool)
+	ss := make(map[string]bool)
+	add := func(set map[string]bool, name string) {
+		if name != "" {
+			set[name] = true
+		}
+	}
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			add(cms, v.ConfigMap.Name)
+		}
+		if v.Secret != nil {
+			add(ss, v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil {
+					add(cms, src.ConfigMap.Name)
+				}
+				if src.Secret != nil {
+					add(ss, src.Secret.Name)
+				}
+			}
+		}
+	}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				add(cms, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				add(ss, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				add(cms, from.ConfigMapRef.Name)
+			}
+			if from.SecretRef != nil {
+				add(ss, from.SecretRef.Name)
+			}
+		}
+	}
+	for _, s := range spec.ImagePullSecrets {
+		add(ss, s.Name)
+	}
+	return sortedKeys(cms), sortedKeys(ss)
+}
+
+// podSpec finds the pod spec of obj. A pod spec has containers.
+func podSpec(obj *unstructured.Unstructured) *corev1.PodSpec {
+	for _, path := range podSpecPaths {
+		m, found, err := unstructured.NestedMap(obj.Object, path...)
+		if err != nil || !found {
+			continue
+		}
+		if _, ok := m["containers"]; !ok {
+			continue
+		}
+		spec := &corev1.PodSpec{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, spec); err != nil {
+			continue
+		}
+		return spec
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
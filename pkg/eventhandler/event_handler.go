@@ -18,7 +18,9 @@ package eventhandler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -26,8 +28,13 @@ import (
 
 	"github.com/kubevela/kube-trigger/api/v1alpha1"
 	"github.com/kubevela/kube-trigger/pkg/action"
+	"github.com/kubevela/kube-trigger/pkg/batch"
+	"github.com/kubevela/kube-trigger/pkg/enrich"
 	"github.com/kubevela/kube-trigger/pkg/executor"
 	"github.com/kubevela/kube-trigger/pkg/filter"
+	"github.com/kubevela/kube-trigger/pkg/plugin"
+	"github.com/kubevela/kube-trigger/pkg/state"
+	"github.com/kubevela/kube-trigger/pkg/storm"
 )
 
 // EventHandler is given to Source to be called. Source is responsible to call
@@ -45,6 +52,43 @@ import (
 // in it.
 type EventHandler func(sourceType string, event interface{}, data interface{}) error
 
+// filterRetries and filterRetryBackoff are how many times and how soon filters
+// are evaluated again, if the trigger retries on filter errors. The backoff
+// doubles after each retry.
+var (
+	filterRetries      = 3
+	filterRetryBackoff = time.Second
+)
+
+// ErrFilteredOut is returned by EventHandlers created by NewFromConfig when
+// the event does not pass the filter.
+var ErrFilteredOut = errors.New("event is filtered out")
+
+// ErrActionFailed is returned by EventHandlers created by NewFromConfig when
+// the event passes the filter, but its action cannot be dispatched.
+var ErrActionFailed = errors.New("event passed filters, but its action failed")
+
+// Tracked is passed as the data of an event by sources that track the jobs of
+// the action of the event, e.g. to apply a concurrency policy. Filters and
+// actions get Data. Jobs of batched actions are not tracked.
+type Tracked struct {
+	Data interface{}
+	Run  *executor.Run
+}
+
+// Untrack returns the data of an event, and the Run tracking the jobs of its
+// action if the source tracks them.
+func Untrack(data interface{}) (interface{}, *executor.Run) {
+	if t, ok := data.(Tracked); ok {
+		return t.Data, t.Run
+	}
+	return data, nil
+}
+
+// runKey is where the Run of an event is kept in its context until its action
+// is dispatched. It is removed before filters or actions see the context.
+const runKey = "_run"
+
 // Config is the config for trigger
 type Config struct {
 	Handler  map[v1alpha1.ActionMeta]string
@@ -58,11 +102,103 @@ func New() EventHandler {
 	}
 }
 
-// NewFromConfig creates a new EventHandler from config.
-func NewFromConfig(ctx context.Context, cli client.Client, actionMeta v1alpha1.ActionMeta, filterMeta string, executor *executor.Executor) EventHandler {
+// NewFromConfig creates a new EventHandler from the config of a trigger.
+// enrichCli is used to fetch related objects if the trigger enriches events.
+// id tells the trigger apart from others in logs and metrics.
+func NewFromConfig(ctx context.Context, cli client.Client, enrichCli client.Client, id string, trigger v1alpha1.TriggerMeta, exe *executor.Executor) (EventHandler, error) {
 	filterLogger := logrus.WithField("eventhandler", "applyfilters")
 	actionLogger := logrus.WithField("eventhandler", "addactionjob")
+	actionMeta := trigger.Action
+	f, err := filter.New(ctx, trigger.Filter, trigger.FilterLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if trigger.FilterRef != nil {
+		ref, err := filter.NewFromRef(ctx, cli, *trigger.FilterRef)
+		if err != nil {
+			return nil, err
+		}
+		f = filter.AllOf{f, ref}
+	}
+	if trigger.FilterPlugin != nil {
+		p, err := plugin.NewFilter(ctx, cli, *trigger.FilterPlugin)
+		if err != nil {
+			return nil, err
+		}
+		f = filter.AllOf{f, p}
+	}
+	store, err := state.New(ctx, cli, trigger.State)
+	if err != nil {
+		return nil, err
+	}
+	var enricher *enrich.Enricher
+	if trigger.Enrich != nil {
+		enricher, err = enrich.New(enrichCli, *trigger.Enrich)
+		if err != nil {
+			return nil, err
+		}
+	}
+	runAction := func(context map[string]interface{}) error {
+		run, _ := context[runKey].(*executor.Run)
+		delete(context, runKey)
+		newJob, err := action.New(ctx, cli, actionMeta, context)
+		if err != nil {
+			actionLogger.Errorf("error when creating new job: %s", err)
+			return err
+		}
+		err = exe.AddJob(executor.Track(newJob, run))
+		if err != nil {
+			actionLogger.Errorf("error when adding job to executor: %s", err)
+			return err
+		}
+		return nil
+	}
+	var batcher *batch.Batcher
+	var groupBy *filter.Key
+	if trigger.Batch != nil {
+		groupBy, err = filter.CompileKey(ctx, trigger.Batch.GroupBy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch groupBy: %w", err)
+		}
+		batcher, err = batch.New(*trigger.Batch, func(key string, events []interface{}) {
+			actionLogger.Infof("running action for a batch of %d events", len(events))
+			_ = runAction(map[string]interface{}{
+				"key":       key,
+				"events":    events,
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Pending batches are dropped on shutdown, since actions no longer
+		// run.
+		go func() {
+			<-ctx.Done()
+			batcher.Close(ctx)
+		}()
+	}
+	afterFilter := func(context map[string]interface{}) error {
+		if batcher != nil {
+			key, err := groupBy.Evaluate(ctx, context)
+			if err != nil {
+				actionLogger.Errorf("error when evaluating batch key of event %v: %s", context["event"], err)
+				return err
+			}
+			delete(context, runKey)
+			batcher.Add(key, context)
+			return nil
+		}
+
+		// Run actions
+		return runAction(context)
+	}
+	if afterFilter, err = guardStorm(id, trigger, afterFilter); err != nil {
+		return nil, err
+	}
+	filterErrors := &errorLogger{logger: filterLogger}
 	return func(sourceType string, event interface{}, data interface{}) error {
+		data, run := Untrack(data)
 		// TODO: use handler to handle
 		// Apply filters
 		context := map[string]interface{}{
@@ -71,31 +207,133 @@ func NewFromConfig(ctx context.Context, cli client.Client, actionMeta v1alpha1.A
 			"data":       data,
 			"timestamp":  time.Now().Format(time.RFC3339),
 		}
-		kept, err := filter.ApplyFilter(ctx, context, filterMeta)
+		if enricher != nil {
+			context["related"] = enricher.Related(ctx, event, data)
+		}
+		res, tx, err := evalFilter(ctx, f, store, context)
 		if err != nil {
-			filterLogger.Errorf("error when applying filters to event %v: %s", event, err)
+			filterErrors.log(err)
+			switch trigger.OnFilterError {
+			case v1alpha1.OnFilterErrorPass:
+				res.Kept = true
+			case v1alpha1.OnFilterErrorRetry:
+				go retryFilter(ctx, f, store, context, func(context map[string]interface{}) error {
+					if run != nil {
+						context[runKey] = run
+					}
+					return afterFilter(context)
+				}, filterLogger)
+				return err
+			}
 		}
-		if !kept {
+		if !res.Kept {
 			filterLogger.Debugf("event %v is filtered out", event)
 			filterLogger.Infof("event is filtered out")
-			return fmt.Errorf("event is filtered out")
+			return ErrFilteredOut
 		}
 		filterLogger.Infof("event passed filters")
+		commit(ctx, tx, filterLogger)
+		setOutput(context, res)
+		if run != nil {
+			context[runKey] = run
+		}
 
-		// Run actions
-		newJob, err := action.New(ctx, cli, actionMeta, context)
-		if err != nil {
-			actionLogger.Errorf("error when creating new job: %s", err)
-			return err
+		if err := afterFilter(context); err != nil {
+			return fmt.Errorf("%w: %w", ErrActionFailed, err)
 		}
-		err = executor.AddJob(newJob)
-		if err != nil {
-			actionLogger.Errorf("error when adding job to executor: %s", err)
-			return err
+		return nil
+	}, nil
+}
+
+// evalFilter evaluates f against the context of an event. Stateful filter
+// builtins find the store in the context, and update it through the returned
+// Tx, which is committed only if the event is kept.
+func evalFilter(ctx context.Context, f filter.Filter, store state.Store, context map[string]interface{}) (filter.Result, *state.Tx, error) {
+	tx := state.NewTx(store)
+	res, err := f.Eval(state.WithStore(ctx, tx), context)
+	return res, tx, err
+}
+
+// commit commits the state updated by filtering a kept event.
+func commit(ctx context.Context, tx *state.Tx, logger *logrus.Entry) {
+	if err := tx.Commit(ctx); err != nil {
+		logger.Errorf("error when saving the state of filters: %s", err)
+	}
+}
+
+// retryFilter evaluates f again with backoff, and calls next if the event
+// passes. The event is dropped if f still fails.
+func retryFilter(ctx context.Context, f filter.Filter, store state.Store, context map[string]interface{}, next func(map[string]interface{}) error, logger *logrus.Entry) {
+	backoff := filterRetryBackoff
+	var err error
+	for i := 0; i < filterRetries; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		var res filter.Result
+		var tx *state.Tx
+		res, tx, err = evalFilter(ctx, f, store, context)
+		if err == nil {
+			if res.Kept {
+				logger.Infof("event passed filters after %d retries", i+1)
+				commit(ctx, tx, logger)
+				setOutput(context, res)
+				_ = next(context)
+			}
+			return
 		}
+		backoff *= 2
+	}
+	logger.Errorf("dropping event %v, filter still fails after %d retries: %s", context["event"], filterRetries, err)
+}
 
-		return nil
+// guardStorm guards next against event storms, if the trigger or all
+// triggers are guarded. Events summarized in storm mode do not call next.
+func guardStorm(id string, trigger v1alpha1.TriggerMeta, next func(map[string]interface{}) error) (func(map[string]interface{}) error, error) {
+	source := trigger.Source.Type
+	if trigger.Correlate != nil {
+		source = "correlation"
+	}
+	guard, err := storm.New(id+"/"+source+"/"+trigger.Action.Type, trigger.Storm, func(context map[string]interface{}) {
+		_ = next(context)
+	})
+	if err != nil || guard == nil {
+		return next, err
+	}
+	return func(context map[string]interface{}) error {
+		if !guard.Pass(context) {
+			return nil
+		}
+		return next(context)
+	}, nil
+}
+
+// setOutput gives the output of the filter to the action as context.filter.
+func setOutput(context map[string]interface{}, res filter.Result) {
+	if res.Output != nil {
+		context["filter"] = res.Output
+	}
+}
+
+// errorLogger logs filter errors, without repeating the same error for every
+// event. Repeated errors are logged at debug level.
+type errorLogger struct {
+	logger *logrus.Entry
+	mu     sync.Mutex
+	last   string
+}
+
+func (l *errorLogger) log(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if msg := err.Error(); msg != l.last {
+		l.last = msg
+		l.logger.Errorf("error when applying filters: %s", err)
+		return
 	}
+	l.logger.Debugf("error when applying filters: %s", err)
 }
 
 // AddHandlerBefore adds a new EventHandler to be called before e is called.
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventhandler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+	"github.com/kubevela/kube-trigger/pkg/filter"
+	"github.com/kubevela/kube-trigger/pkg/state"
+)
+
+// flakyFilter fails until it has been evaluated failures times.
+type flakyFilter struct {
+	failures int
+	evals    int
+}
+
+func (f *flakyFilter) Eval(_ context.Context, _ map[string]interface{}) (filter.Result, error) {
+	f.evals++
+	if f.evals <= f.failures {
+		return filter.Result{}, &filter.EvalError{Err: errors.New("provider unavailable")}
+	}
+	return filter.Result{Kept: true}, nil
+}
+
+func TestRetryFilter(t *testing.T) {
+	filterRetryBackoff = time.Millisecond
+	logger := logrus.WithField("test", "retry")
+	testcases := map[string]struct {
+		failures int
+		passed   bool
+	}{
+		"passes after retries":  {failures: 2, passed: true},
+		"dropped after retries": {failures: 10, passed: false},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			f := &flakyFilter{failures: tc.failures}
+			passed := false
+			// The first evaluation failed before retrying.
+			f.evals = 1
+			retryFilter(context.Background(), f, state.NewMemory(), map[string]interface{}{}, func(map[string]interface{}) error {
+				passed = true
+				return nil
+			}, logger)
+			assert.Equal(t, tc.passed, passed)
+			assert.LessOrEqual(t, f.evals, filterRetries+1)
+		})
+	}
+}
+
+func TestEvalFilterState(t *testing.T) {
+	ctx := context.Background()
+	f, err := filter.New(ctx, `
+	import "trigger"
+	dedupe: trigger.#Dedupe & {key: "web", window: "1h"}
+	filter: dedupe.$returns && context.data.ok`, "")
+	assert.NoError(t, err)
+	store := state.NewMemory()
+	kept := func(ok bool) bool {
+		res, tx, err := evalFilter(ctx, f, store, map[string]interface{}{"data": map[string]interface{}{"ok": ok}})
+		assert.NoError(t, err)
+		if res.Kept {
+			commit(ctx, tx, logrus.WithField("test", "state"))
+		}
+		return res.Kept
+	}
+	// Events dropped by the rest of the filter are not seen by #Dedupe.
+	assert.False(t, kept(false))
+	assert.True(t, kept(true))
+	assert.False(t, kept(true))
+}
+
+func TestGuardStorm(t *testing.T) {
+	calls := 0
+	next := func(map[string]interface{}) error {
+		calls++
+		return nil
+	}
+	guarded, err := guardStorm("0", v1alpha1.TriggerMeta{}, next)
+	assert.NoError(t, err)
+	_ = guarded(nil)
+	assert.Equal(t, 1, calls)
+
+	guarded, err = guardStorm("1", v1alpha1.TriggerMeta{Storm: &v1alpha1.Storm{Threshold: 1, Window: "1h"}}, next)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, guarded(map[string]interface{}{}))
+	}
+	// Events after the first are summarized.
+	assert.Equal(t, 2, calls)
+
+	_, err = guardStorm("2", v1alpha1.TriggerMeta{Storm: &v1alpha1.Storm{}}, next)
+	assert.Error(t, err)
+}
@@ -143,7 +143,15 @@ func (e *Executor) requeueJob(j Job) {
 		return
 	}
 	e.logger.Errorf("job %s (%s) cannot be requeued because it failed too many (%d/%d) times", j.Type(), j.ID(), e.queue.NumRequeues(j), e.maxRetries)
+	e.forgetJob(j)
+}
+
+// forgetJob forgets a job that will not run again.
+func (e *Executor) forgetJob(j Job) {
 	e.queue.Forget(j)
+	if run := runOf(j); run != nil {
+		run.done()
+	}
 }
 
 // AddJob adds a job to the queue.
@@ -153,6 +161,9 @@ func (e *Executor) AddJob(j Job) error {
 		e.logger.Error(msg)
 		return fmt.Errorf("%s", msg)
 	}
+	if run := runOf(j); run != nil {
+		run.add()
+	}
 	e.queue.Add(j)
 	e.logger.Debugf("job %s (%s) added to queue, currnet queue size: %d/%d", j.Type(), j.ID(), e.queue.Len(), e.maxQueueSize)
 	return nil
@@ -176,6 +187,13 @@ func (e *Executor) runJob(ctx context.Context) bool {
 
 	e.logger.Debugf("job %s (%s) is picked up by a worker", j.Type(), j.ID())
 
+	run := runOf(j)
+	if run != nil && run.cancelled() {
+		e.logger.Infof("job %s (%s) is cancelled, will be dropped", j.Type(), j.ID())
+		e.forgetJob(j)
+		return true
+	}
+
 	// This job does not allow concurrent runs, and it is already running.
 	// Requeue it to run it later.
 	if !j.AllowConcurrency() && e.getJobStatus(j) {
@@ -187,6 +205,10 @@ func (e *Executor) runJob(ctx context.Context) bool {
 	// Add a job timeout
 	timeoutCtx, cancel := context.WithDeadline(ctx, time.Now().Add(e.timeout))
 	defer cancel()
+	if run != nil {
+		stop := context.AfterFunc(run.ctx, cancel)
+		defer stop()
+	}
 
 	e.logger.Infof("job %s (%s) started executing", j.Type(), j.ID())
 	e.setJobRunning(j)
@@ -195,7 +217,14 @@ func (e *Executor) runJob(ctx context.Context) bool {
 
 	if err == nil && timeoutCtx.Err() == nil {
 		e.logger.Infof("job %s (%s) finished", j.Type(), j.ID())
-		e.queue.Forget(j)
+		e.forgetJob(j)
+		return true
+	}
+
+	// The job is cancelled by its Run, not the executor.
+	if run != nil && run.cancelled() && ctx.Err() == nil {
+		e.logger.Infof("job %s (%s) is cancelled", j.Type(), j.ID())
+		e.forgetJob(j)
 		return true
 	}
 
@@ -209,6 +238,8 @@ func (e *Executor) runJob(ctx context.Context) bool {
 	if e.allowRetries {
 		msg += fmt.Sprintf(", will retry job %s (%s) later", j.Type(), j.ID())
 		e.requeueJob(j)
+	} else {
+		e.forgetJob(j)
 	}
 	e.logger.Error(msg)
 
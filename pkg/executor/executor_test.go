@@ -285,6 +285,62 @@ func TestTimedOutRequeuing(t *testing.T) {
 	a.NoError(waitForAdded(e.queue, 0))
 }
 
+func TestTrackedJobs(t *testing.T) {
+	logrus.SetLevel(logrus.TraceLevel)
+	a := assert.New(t)
+	c := Config{
+		QueueSize:            5,
+		Workers:              1,
+		MaxJobRetries:        0,
+		BaseRetryDelay:       10 * time.Millisecond,
+		RetryJobAfterFailure: false,
+		PerWorkerQPS:         500,
+		Timeout:              5 * time.Second,
+	}
+
+	e, err := New(c)
+	a.NoError(err)
+
+	// Pending until the job finishes.
+	finished := NewRun()
+	a.False(finished.Pending())
+	a.NoError(e.AddJob(Track(&sleepingJob{100 * time.Millisecond, "1"}, finished)))
+	a.True(finished.Pending())
+	// Dropped without running, when cancelled while queued.
+	dropped := NewRun()
+	a.NoError(e.AddJob(Track(&sleepingJob{10 * time.Second, "2"}, dropped)))
+	dropped.Cancel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan struct{})
+	go func() {
+		e.RunJobs(ctx)
+		close(ch)
+	}()
+
+	err = wait.Poll(1*time.Millisecond, time.Second, func() (done bool, err error) {
+		return !finished.Pending() && !dropped.Pending(), nil
+	})
+	a.NoError(err)
+
+	// Stopped when cancelled while running.
+	cancelled := NewRun()
+	a.NoError(e.AddJob(Track(&sleepingJob{10 * time.Second, "3"}, cancelled)))
+	err = wait.Poll(1*time.Millisecond, time.Second, func() (done bool, err error) {
+		_, ok := e.runningJobs.Load((10 * time.Second).String() + "3")
+		return ok, nil
+	})
+	a.NoError(err)
+	cancelled.Cancel()
+	err = wait.Poll(1*time.Millisecond, time.Second, func() (done bool, err error) {
+		return !cancelled.Pending(), nil
+	})
+	a.NoError(err)
+
+	cancel()
+	<-ch
+}
+
 type sleepingJob struct {
 	duration time.Duration
 	id       string
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"sync"
+)
+
+// Run tracks the jobs added for an event, so that the source of the event
+// can tell whether they are still queued or running, and cancel them.
+type Run struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending int
+}
+
+// NewRun creates a Run without jobs.
+func NewRun() *Run {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Run{ctx: ctx, cancel: cancel}
+}
+
+// Pending tells whether jobs of r are queued, running, or waiting to be
+// retried.
+func (r *Run) Pending() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pending > 0
+}
+
+// Cancel drops the queued jobs of r when they are picked up, and cancels the
+// running ones.
+func (r *Run) Cancel() {
+	r.cancel()
+}
+
+func (r *Run) cancelled() bool {
+	return r.ctx.Err() != nil
+}
+
+func (r *Run) add() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending++
+}
+
+func (r *Run) done() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending--
+}
+
+// trackedJob is a Job whose progress is reported to a Run.
+type trackedJob struct {
+	Job
+	run *Run
+}
+
+// Track makes the Executor report the progress of j to run. j is returned as
+// is if run is nil.
+func Track(j Job, run *Run) Job {
+	if run == nil {
+		return j
+	}
+	return &trackedJob{Job: j, run: run}
+}
+
+// runOf returns the Run tracking j, or nil.
+func runOf(j Job) *Run {
+	if t, ok := j.(*trackedJob); ok {
+		return t.run
+	}
+	return nil
+}
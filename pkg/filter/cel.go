@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	apiservercel "k8s.io/apiserver/pkg/cel"
+	"k8s.io/apiserver/pkg/cel/library"
+)
+
+// contextType is the type of the context variable of CEL filters. Events and
+// data differ by source, so they are dynamic.
+var contextType = apiservercel.NewObjectType("kubetrigger.Context", map[string]*apiservercel.DeclField{
+	"sourceType": apiservercel.NewDeclField("sourceType", apiservercel.StringType, true, nil, nil),
+	"event":      apiservercel.NewDeclField("event", apiservercel.DynType, true, nil, nil),
+	"data":       apiservercel.NewDeclField("data", apiservercel.DynType, true, nil, nil),
+	"timestamp":  apiservercel.NewDeclField("timestamp", apiservercel.StringType, true, nil, nil),
+	"related":    apiservercel.NewDeclField("related", apiservercel.DynType, false, nil, nil),
+})
+
+var (
+	celEnv     *cel.Env
+	celEnvErr  error
+	celEnvOnce sync.Once
+)
+
+// newCELEnv creates the environment of CEL filters, with the same helper
+// functions as Kubernetes validation rules.
+func newCELEnv() (*cel.Env, error) {
+	celEnvOnce.Do(func() {
+		base, err := cel.NewEnv(
+			cel.HomogeneousAggregateLiterals(),
+			cel.EagerlyValidateDeclarations(true),
+			cel.DefaultUTCTimeZone(true),
+			ext.Strings(ext.StringsVersion(2)),
+			ext.Sets(),
+			library.URLs(),
+			library.Regex(),
+			library.Lists(),
+			library.Quantity(),
+			library.IP(),
+			library.CIDR(),
+			library.Format(),
+		)
+		if err != nil {
+			celEnvErr = err
+			return
+		}
+		opts, err := apiservercel.NewDeclTypeProvider(contextType).EnvOptions(base.CELTypeProvider())
+		if err != nil {
+			celEnvErr = err
+			return
+		}
+		celEnv, celEnvErr = base.Extend(append(opts, cel.Variable("context", contextType.CelType()))...)
+	})
+	return celEnv, celEnvErr
+}
+
+// CELProgram is a compiled CEL filter.
+type CELProgram struct {
+	prg cel.Program
+}
+
+// CompileCEL compiles and type-checks a CEL filter, which must evaluate to a
+// bool. Errors are CompileErrors.
+func CompileCEL(expr string) (*CELProgram, error) {
+	env, err := newCELEnv()
+	if err != nil {
+		return nil, &CompileError{Err: fmt.Errorf("cannot create CEL environment: %w", err)}
+	}
+	ast, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		return nil, &CompileError{Err: issues.Err()}
+	}
+	if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, &CompileError{Err: fmt.Errorf("CEL filter must evaluate to a bool, got %s", t)}
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, &CompileError{Err: err}
+	}
+	return &CELProgram{prg: prg}, nil
+}
+
+// Eval evaluates the CEL filter against contextData. Errors are EvalErrors.
+// CEL filters have no output.
+func (p *CELProgram) Eval(ctx context.Context, contextData map[string]interface{}) (Result, error) {
+	// Sources may put structs in the context, which CEL cannot use, so convert
+	// it to JSON values.
+	b, err := json.Marshal(contextData)
+	if err != nil {
+		return Result{}, &EvalError{Err: err}
+	}
+	var activation map[string]interface{}
+	if err := utiljson.Unmarshal(b, &activation); err != nil {
+		return Result{}, &EvalError{Err: err}
+	}
+	out, _, err := p.prg.ContextEval(ctx, map[string]interface{}{"context": activation})
+	if err != nil {
+		// Events missing fields do not match.
+		if isMissing(err) {
+			return Result{}, nil
+		}
+		return Result{}, &EvalError{Err: err}
+	}
+	kept, ok := out.Value().(bool)
+	if !ok {
+		return Result{}, &EvalError{Err: fmt.Errorf("CEL filter evaluated to %v, not a bool", out.Value())}
+	}
+	return Result{Kept: kept}, nil
+}
+
+func isMissing(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "no such key") || strings.HasPrefix(msg, "no such attribute")
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	testcases := map[string]struct {
+		filter   string
+		language string
+		kept     bool
+		err      string
+	}{
+		"cue by default": {
+			filter: `context.data.data.version == "v2"`,
+			kept:   true,
+		},
+		"cel by language": {
+			filter:   `context.sourceType == "resource-watcher" && context.data.metadata.labels.app == "web"`,
+			language: LanguageCEL,
+			kept:     true,
+		},
+		"cel by prefix": {
+			filter: `cel: context.data.metadata.name.startsWith("other-")`,
+			kept:   false,
+		},
+		"kubernetes helpers": {
+			filter: `cel: context.data.metadata.labels.exists(k, k.matches("^ti")) &&
+				quantity("1Gi").isGreaterThan(quantity("512Mi")) &&
+				url("https://example.com/a").getHost() == "example.com"`,
+			kept: true,
+		},
+		"empty cel filter": {
+			language: LanguageCEL,
+			kept:     true,
+		},
+		"cel missing fields": {
+			filter: `cel: context.data.spec.replicas > 1`,
+			kept:   false,
+		},
+		"cel type error": {
+			filter: `cel: context.sourceType + 1 == 2`,
+			err:    "no matching overload",
+		},
+		"cel undefined field": {
+			filter: `cel: context.foo == "bar"`,
+			err:    "undefined field",
+		},
+		"cel not a bool": {
+			filter: `cel: context.sourceType`,
+			err:    "must evaluate to a bool",
+		},
+		"unknown language": {
+			filter:   `true`,
+			language: "rego",
+			err:      "unknown filter language",
+		},
+	}
+	ctx := context.Background()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			f, err := New(ctx, tc.filter, tc.language)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				if tc.language == "" {
+					var compileErr *CompileError
+					assert.ErrorAs(t, err, &compileErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			res, err := f.Eval(ctx, benchmarkContext())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.kept, res.Kept)
+		})
+	}
+}
+
+func TestCELStructs(t *testing.T) {
+	type event struct {
+		Type     string `json:"type"`
+		Replicas int    `json:"replicas"`
+	}
+	f, err := New(context.Background(), `cel: context.event.type == "update" && context.event.replicas == 3`, "")
+	require.NoError(t, err)
+	res, err := f.Eval(context.Background(), map[string]interface{}{
+		"event": event{Type: "update", Replicas: 3},
+	})
+	assert.NoError(t, err)
+	assert.True(t, res.Kept)
+}
+
+func BenchmarkCEL(b *testing.B) {
+	ctx := context.Background()
+	f, err := New(ctx, `cel: context.data.metadata.labels.app == "web"`, "")
+	require.NoError(b, err)
+	contextData := benchmarkContext()
+	for i := 0; i < b.N; i++ {
+		res, err := f.Eval(ctx, contextData)
+		if err != nil || !res.Kept {
+			b.Fatalf("unexpected result %v: %v", res.Kept, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+// CompileError is returned when a filter cannot be compiled. Such a filter
+// fails for every event, so it should be caught when the config is loaded.
+type CompileError struct {
+	Err error
+}
+
+func (e *CompileError) Error() string {
+	return "cannot compile filter: " + e.Err.Error()
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// EvalError is returned when a filter cannot be evaluated against an event,
+// e.g. because of mismatched types or failed provider functions. Missing
+// fields are not errors, they do not match instead.
+type EvalError struct {
+	Err error
+}
+
+func (e *EvalError) Error() string {
+	return "cannot evaluate filter: " + e.Err.Error()
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
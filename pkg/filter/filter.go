@@ -26,35 +26,83 @@ import (
 	"cuelang.org/go/cue/format"
 	"cuelang.org/go/cue/parser"
 	"cuelang.org/go/tools/fix"
-	"github.com/kubevela/pkg/cue/cuex"
 )
 
-// ApplyFilter applies the given filter to an object.
+// ApplyFilter applies the given filter to an object. Compiled filters are
+// cached, see SetCacheSize.
 func ApplyFilter(ctx context.Context, contextData map[string]interface{}, filter string) (bool, error) {
-	template, err := BuildFilterTemplate(filter)
+	p, err := programFor(ctx, filter)
 	if err != nil {
 		return false, err
 	}
-	filterVal, err := cuex.CompileStringWithOptions(ctx, template, cuex.WithExtraData("context", contextData))
-	if err != nil {
-		return false, err
+	res, err := p.Eval(ctx, contextData)
+	return res.Kept, err
+}
+
+// Result is the result of a filter.
+type Result struct {
+	// Kept is whether the event passed the filter.
+	Kept bool
+	// Output is what the filter computed in its output field, given to the
+	// action as context.filter. It is only set if the event is kept.
+	Output map[string]interface{}
+}
+
+var (
+	outputPath        = cue.ParsePath("filter.output")
+	filterContextPath = cue.ParsePath("filter.context")
+)
+
+// output gets the output of an evaluated filter, nil if it has none.
+func output(filterVal cue.Value, contextData map[string]interface{}) (map[string]interface{}, error) {
+	v := filterVal.LookupPath(outputPath)
+	if !v.Exists() {
+		return nil, nil
 	}
+	// In struct filters, context is the constraint. Fill it with the context
+	// of the event, which it matched, for the output to use.
+	if filterVal.LookupPath(filterContextPath).Exists() {
+		v = filterVal.FillPath(filterContextPath, contextData).LookupPath(outputPath)
+	}
+	var out map[string]interface{}
+	if err := v.Decode(&out); err != nil {
+		return nil, &EvalError{Err: fmt.Errorf("invalid filter output: %w", err)}
+	}
+	return out, nil
+}
+
+// result gets the result of an evaluated filter. Bool results are kept as is.
+// Struct results are constraints on the context, kept if they unify with it.
+func result(filterVal cue.Value) (bool, error) {
 	if filterVal.Err() != nil {
-		return false, filterVal.Err()
+		return false, &EvalError{Err: filterVal.Err()}
 	}
 	result := filterVal.LookupPath(cue.ParsePath("filter"))
 	if filterVal.LookupPath(cue.ParsePath("filter.filter")).Exists() {
 		result = filterVal.LookupPath(cue.ParsePath("filter.filter"))
 	}
-	if result.Err() != nil {
-		return false, result.Err()
+	if err := result.Err(); err != nil {
+		// Values referencing missing fields are incomplete. Such events do
+		// not match.
+		if !result.IsConcrete() {
+			return false, nil
+		}
+		return false, &EvalError{Err: err}
 	}
-	resultBool, err := result.Bool()
-	// if the result is not a bool, return true to pass the filter
-	if err != nil {
-		return true, nil
+	switch result.Kind() {
+	case cue.BoolKind:
+		return result.Bool()
+	case cue.StructKind:
+		constraint := result.LookupPath(contextPath)
+		if !constraint.Exists() {
+			return true, nil
+		}
+		// The context must be an instance of the constraint, so events
+		// missing fields do not match either.
+		return constraint.Subsume(filterVal.LookupPath(contextPath), cue.Final()) == nil, nil
+	default:
+		return false, &EvalError{Err: fmt.Errorf("filter evaluated to %s, not a bool or struct", result.Kind())}
 	}
-	return resultBool, nil
 }
 
 // BuildFilterTemplate build filter template
@@ -64,7 +112,9 @@ func BuildFilterTemplate(filter string) (string, error) {
 		return "", err
 	}
 	n := fix.File(f)
-	if n.Imports == nil {
+	// A single expression is the filter, other filters are wrapped, so that
+	// all of their fields, e.g. output, are in the filter.
+	if n.Imports == nil && len(n.Decls) <= 1 {
 		return fmt.Sprintf("filter: %s", filter), nil
 	}
 	var importDecls, contentDecls []ast.Decl
@@ -100,3 +150,53 @@ filter: {
 	%s
 }
 `
+
+// Filter languages.
+const (
+	LanguageCUE = "cue"
+	LanguageCEL = "cel"
+)
+
+// celPrefix selects CEL for a filter regardless of its language.
+const celPrefix = "cel:"
+
+// Filter is a filter ready to be evaluated against the context of events.
+type Filter interface {
+	Eval(ctx context.Context, contextData map[string]interface{}) (Result, error)
+}
+
+// cueFilter is a CUE filter. It is cached with other CUE filters, and
+// compiled again if it is evicted.
+type cueFilter string
+
+func (f cueFilter) Eval(ctx context.Context, contextData map[string]interface{}) (Result, error) {
+	p, err := programFor(ctx, string(f))
+	if err != nil {
+		return Result{}, err
+	}
+	return p.Eval(ctx, contextData)
+}
+
+// New creates a Filter from filter in language, cue if empty. A filter
+// prefixed by cel: is always CEL. Filters are compiled here, so that errors are
+// CompileErrors returned once, instead of for every event.
+func New(ctx context.Context, filter, language string) (Filter, error) {
+	if strings.HasPrefix(filter, celPrefix) {
+		filter = strings.TrimPrefix(filter, celPrefix)
+		language = LanguageCEL
+	}
+	switch language {
+	case "", LanguageCUE:
+		if _, err := programFor(ctx, filter); err != nil {
+			return nil, err
+		}
+		return cueFilter(filter), nil
+	case LanguageCEL:
+		if strings.TrimSpace(filter) == "" {
+			return cueFilter(""), nil
+		}
+		return CompileCEL(filter)
+	default:
+		return nil, fmt.Errorf("unknown filter language %q", language)
+	}
+}
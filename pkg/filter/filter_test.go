@@ -17,10 +17,15 @@ limitations under the License.
 package filter
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/kubevela/pkg/cue/cuex"
 	"github.com/kubevela/pkg/util/stringtools"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/kubevela/kube-trigger/pkg/state"
 )
 
 func TestBuildFilterTemplate(t *testing.T) {
@@ -55,3 +60,233 @@ func TestBuildFilterTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyFilter(t *testing.T) {
+	testcases := map[string]struct {
+		filter     string
+		kept       bool
+		compileErr bool
+		evalErr    bool
+	}{
+		"empty filter": {
+			filter: "",
+			kept:   true,
+		},
+		"matching": {
+			filter: `context: data: metadata: labels: app: "web"`,
+			kept:   true,
+		},
+		"not matching": {
+			filter: `context.data.data.version == "v1"`,
+			kept:   false,
+		},
+		"with stdlib imports": {
+			filter: `
+			import "strings"
+			strings.HasPrefix(context.data.metadata.name, "my-")`,
+			kept: true,
+		},
+		"with provider functions": {
+			filter: `
+			import "vela/base64"
+			encoded: base64.#Encode & {$params: context.data.metadata.name}
+			filter: encoded.$returns == "bXktY20="`,
+			kept: true,
+		},
+		"with kube-trigger packages": {
+			filter: `
+			import (
+				"trigger/k8s"
+				"trigger/semver"
+				"trigger/time"
+			)
+			labels: k8s.#MatchLabels & {$params: {labels: context.data.metadata.labels, selector: "tier=prod"}}
+			version: semver.#InRange & {$params: {version: context.data.data.version, range: ">=2.0.0"}}
+			hours: time.#InBusinessHours & {$params: {time: context.timestamp, days: ["Sun"], start: "00:00", end: "01:00"}}
+			filter: labels.$returns && version.$returns && hours.$returns`,
+			kept: true,
+		},
+		"struct not matching": {
+			filter: `context: data: metadata: labels: app: "api"`,
+			kept:   false,
+		},
+		"struct with types and disjunctions": {
+			filter: `
+			context: event: type: "create" | "update"
+			context: data: metadata: name: string`,
+			kept: true,
+		},
+		"struct with missing fields": {
+			filter: `context: data: spec: replicas: 3`,
+			kept:   false,
+		},
+		"missing fields": {
+			filter: `context.data.spec.replicas > 1`,
+			kept:   false,
+		},
+		"invalid filter": {
+			filter:     `context: data: {`,
+			compileErr: true,
+		},
+		"mismatched types": {
+			filter:  `context.data.metadata.name + 1 == 2`,
+			evalErr: true,
+		},
+		"not a bool": {
+			filter:  `context.data.metadata.name`,
+			evalErr: true,
+		},
+	}
+	ctx := context.Background()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// Applied twice, the second time from the cache.
+			for i := 0; i < 2; i++ {
+				kept, err := ApplyFilter(ctx, benchmarkContext(), tc.filter)
+				var compileErr *CompileError
+				var evalErr *EvalError
+				assert.Equal(t, tc.compileErr, errors.As(err, &compileErr), "error: %v", err)
+				assert.Equal(t, tc.evalErr, errors.As(err, &evalErr), "error: %v", err)
+				assert.Equal(t, tc.kept, kept)
+			}
+		})
+	}
+}
+
+func TestOutput(t *testing.T) {
+	testcases := map[string]struct {
+		filter  string
+		kept    bool
+		output  map[string]interface{}
+		evalErr bool
+	}{
+		"no output": {
+			filter: `context.data.metadata.name == "my-cm"`,
+			kept:   true,
+		},
+		"with a bool filter": {
+			filter: `
+			filter: context.data.metadata.name == "my-cm"
+			output: app: context.data.metadata.labels.app`,
+			kept:   true,
+			output: map[string]interface{}{"app": "web"},
+		},
+		"with a struct filter": {
+			filter: `
+			context: data: metadata: labels: app: "web" | "api"
+			output: namespace: "\(context.data.metadata.namespace)-\(context.data.metadata.labels.app)"`,
+			kept:   true,
+			output: map[string]interface{}{"namespace": "default-web"},
+		},
+		"with imports": {
+			filter: `
+			import "strings"
+			filter: strings.HasPrefix(context.data.metadata.name, "my-")
+			output: name: strings.TrimPrefix(context.data.metadata.name, "my-")`,
+			kept:   true,
+			output: map[string]interface{}{"name": "cm"},
+		},
+		"filtered out": {
+			filter: `
+			filter: context.data.metadata.name == "other"
+			output: app: context.data.metadata.labels.app`,
+			kept: false,
+		},
+		"incomplete output": {
+			filter: `
+			filter: true
+			output: replicas: context.data.spec.replicas`,
+			evalErr: true,
+		},
+	}
+	ctx := context.Background()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			f, err := New(ctx, tc.filter, "")
+			assert.NoError(t, err)
+			res, err := f.Eval(ctx, benchmarkContext())
+			var evalErr *EvalError
+			assert.Equal(t, tc.evalErr, errors.As(err, &evalErr), "error: %v", err)
+			assert.Equal(t, tc.kept, res.Kept)
+			assert.Equal(t, tc.output, res.Output)
+		})
+	}
+}
+
+func TestStatefulBuiltins(t *testing.T) {
+	ctx := state.WithStore(context.Background(), state.NewMemory())
+	f := `
+	import "trigger"
+	changed: trigger.#Changed & {path: "data"}
+	dedupe: trigger.#Dedupe & {key: context.data.metadata.name, window: "1h"}
+	filter: changed.$returns && dedupe.$returns`
+	kept, err := ApplyFilter(ctx, benchmarkContext(), f)
+	assert.NoError(t, err)
+	assert.True(t, kept)
+	kept, err = ApplyFilter(ctx, benchmarkContext(), f)
+	assert.NoError(t, err)
+	assert.False(t, kept)
+
+	// Builtins fail without a store.
+	_, err = ApplyFilter(context.Background(), benchmarkContext(), f)
+	var evalErr *EvalError
+	assert.True(t, errors.As(err, &evalErr), "error: %v", err)
+}
+
+func benchmarkContext() map[string]interface{} {
+	return map[string]interface{}{
+		"sourceType": "resource-watcher",
+		"event":      map[string]interface{}{"type": "update"},
+		"data": map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "my-cm",
+				"namespace": "default",
+				"labels":    map[string]interface{}{"app": "web", "tier": "prod"},
+			},
+			"data": map[string]interface{}{"version": "v2"},
+		},
+		"timestamp": "2023-01-01T00:00:00Z",
+	}
+}
+
+func BenchmarkApplyFilter(b *testing.B) {
+	filters := map[string]string{
+		"simple": `context: data: metadata: labels: app: "web"`,
+		"imports": `
+			import "strings"
+			strings.HasPrefix(context.data.metadata.name, "my-")`,
+	}
+	ctx := context.Background()
+	for name, f := range filters {
+		// Builds and compiles the filter with the context for every event, as
+		// before filters were cached.
+		b.Run(name+"/uncached", func(b *testing.B) {
+			contextData := benchmarkContext()
+			for i := 0; i < b.N; i++ {
+				template, err := BuildFilterTemplate(f)
+				if err != nil {
+					b.Fatal(err)
+				}
+				v, err := cuex.CompileStringWithOptions(ctx, template, cuex.WithExtraData("context", contextData))
+				if err != nil {
+					b.Fatal(err)
+				}
+				kept, err := result(v)
+				if err != nil || !kept {
+					b.Fatalf("unexpected result %v: %v", kept, err)
+				}
+			}
+		})
+		b.Run(name+"/cached", func(b *testing.B) {
+			contextData := benchmarkContext()
+			for i := 0; i < b.N; i++ {
+				kept, err := ApplyFilter(ctx, contextData, f)
+				if err != nil || !kept {
+					b.Fatalf("unexpected result %v: %v", kept, err)
+				}
+			}
+		})
+	}
+}
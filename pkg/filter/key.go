@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"github.com/kubevela/pkg/cue/cuex"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library/registry"
+)
+
+var keyPath = cue.ParsePath("key")
+
+// Key is a CUE expression compiled once, and evaluated against the context of
+// each event to group events by.
+type Key struct {
+	// mu guards value, since CUE values are not safe for concurrent use.
+	mu    sync.Mutex
+	value cue.Value
+}
+
+// CompileKey compiles the CUE expression expr into a Key. An empty expr is
+// an empty key. expr is a plain expression, so it cannot import packages or
+// call provider functions.
+func CompileKey(ctx context.Context, expr string) (*Key, error) {
+	if expr == "" {
+		return &Key{}, nil
+	}
+	template := "key: " + expr + "\ncontext: _\n"
+	registry.Load()
+	value, err := cuex.CompileStringWithOptions(ctx, template, cuex.DisableResolveProviderFunctions{})
+	if err != nil {
+		return nil, err
+	}
+	if value.Err() != nil {
+		return nil, value.Err()
+	}
+	return &Key{value: value}, nil
+}
+
+// Evaluate evaluates k against the context of an event. Keys that are not
+// strings are encoded as JSON.
+func (k *Key) Evaluate(_ context.Context, contextData map[string]interface{}) (string, error) {
+	if !k.value.Exists() {
+		return "", nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key := k.value.FillPath(contextPath, contextData).LookupPath(keyPath)
+	if key.Err() != nil {
+		return "", key.Err()
+	}
+	if s, err := key.String(); err == nil {
+		return s, nil
+	}
+	b, err := key.MarshalJSON()
+	if err != nil {
+		return "", fmt.Errorf("key is not concrete: %w", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,72 @@
+package k8s
+
+// +usage=Whether labels match a label selector, either a string like "app=web,tier in (prod)" or a struct with matchLabels and matchExpressions.
+#MatchLabels: {
+	#do:       "matchLabels"
+	#provider: "trigger/k8s"
+
+	$params: {
+		labels: [string]: string
+		selector: string | {
+			matchLabels?: [string]: string
+			matchExpressions?: [...{
+				key:      string
+				operator: "In" | "NotIn" | "Exists" | "DoesNotExist"
+				values?: [...string]
+			}]
+		}
+	}
+	$returns?: bool
+}
+
+// +usage=Parses a quantity, e.g. 500m or 2Gi, into a number.
+#ParseQuantity: {
+	#do:       "parseQuantity"
+	#provider: "trigger/k8s"
+
+	$params:   string
+	$returns?: number
+}
+
+// +usage=The controller owner reference of an object. $returns is absent if it has none.
+#Owner: {
+	#do:       "owner"
+	#provider: "trigger/k8s"
+
+	$params: {...}
+	$returns?: {
+		apiVersion: string
+		kind:       string
+		name:       string
+		uid:        string
+		...
+	}
+}
+
+// +usage=The condition of an object, in status.conditions, with the type. $returns is absent if it has none.
+#Condition: {
+	#do:       "condition"
+	#provider: "trigger/k8s"
+
+	$params: {
+		object: {...}
+		type: string
+	}
+	$returns?: {
+		type:   string
+		status: string
+		...
+	}
+}
+
+// +usage=Whether the condition of an object with the type is True.
+#ConditionTrue: {
+	#do:       "conditionTrue"
+	#provider: "trigger/k8s"
+
+	$params: {
+		object: {...}
+		type: string
+	}
+	$returns?: bool
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package k8s is the CUE package "trigger/k8s", with helpers for Kubernetes
+// objects. They do not access the cluster.
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	_ "embed"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library"
+)
+
+// LabelSelection is the params of #MatchLabels.
+type LabelSelection struct {
+	Labels map[string]string `json:"labels"`
+	// Selector is a string, or a metav1.LabelSelector.
+	Selector json.RawMessage `json:"selector"`
+}
+
+// MatchLabelsParams is the params of #MatchLabels.
+type MatchLabelsParams providers.Params[LabelSelection]
+
+// BoolReturns is the returns of #MatchLabels and #ConditionTrue.
+type BoolReturns providers.Returns[bool]
+
+// MatchLabels reports whether labels match a selector.
+func MatchLabels(_ context.Context, params *MatchLabelsParams) (*BoolReturns, error) {
+	var selector labels.Selector
+	var s string
+	if err := json.Unmarshal(params.Params.Selector, &s); err == nil {
+		if selector, err = labels.Parse(s); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+		}
+	} else {
+		ls := &metav1.LabelSelector{}
+		if err := json.Unmarshal(params.Params.Selector, ls); err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+		if selector, err = metav1.LabelSelectorAsSelector(ls); err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+	return &BoolReturns{Returns: selector.Matches(labels.Set(params.Params.Labels))}, nil
+}
+
+// QuantityParams is the params of #ParseQuantity.
+type QuantityParams providers.Params[string]
+
+// QuantityReturns is the returns of #ParseQuantity.
+type QuantityReturns providers.Returns[float64]
+
+// ParseQuantity parses a quantity into a number.
+func ParseQuantity(_ context.Context, params *QuantityParams) (*QuantityReturns, error) {
+	q, err := resource.ParseQuantity(params.Params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity %q: %w", params.Params, err)
+	}
+	return &QuantityReturns{Returns: q.AsApproximateFloat64()}, nil
+}
+
+// ObjectParams is the params of #Owner.
+type ObjectParams providers.Params[map[string]interface{}]
+
+// OwnerReturns is the returns of #Owner.
+type OwnerReturns struct {
+	Returns *metav1.OwnerReference `json:"$returns,omitempty"`
+}
+
+// Owner gets the controller owner reference of an object.
+func Owner(_ context.Context, params *ObjectParams) (*OwnerReturns, error) {
+	obj := &unstructured.Unstructured{Object: params.Params}
+	return &OwnerReturns{Returns: metav1.GetControllerOfNoCopy(obj)}, nil
+}
+
+// ObjectCondition is the params of #Condition and #ConditionTrue.
+type ObjectCondition struct {
+	Object map[string]interface{} `json:"object"`
+	Type   string                 `json:"type"`
+}
+
+// ConditionParams is the params of #Condition and #ConditionTrue.
+type ConditionParams providers.Params[ObjectCondition]
+
+// ConditionReturns is the returns of #Condition.
+type ConditionReturns struct {
+	Returns map[string]interface{} `json:"$returns,omitempty"`
+}
+
+// Condition gets the condition of an object with a type.
+func Condition(_ context.Context, params *ConditionParams) (*ConditionReturns, error) {
+	return &ConditionReturns{Returns: condition(params.Params)}, nil
+}
+
+// ConditionTrue reports whether the condition of an object with a type is
+// True.
+func ConditionTrue(_ context.Context, params *ConditionParams) (*BoolReturns, error) {
+	c := condition(params.Params)
+	return &BoolReturns{Returns: c != nil && c["status"] == string(metav1.ConditionTrue)}, nil
+}
+
+func condition(p ObjectCondition) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(p.Object, "status", "conditions")
+	for _, c := range conditions {
+		if c, ok := c.(map[string]interface{}); ok && c["type"] == p.Type {
+			return c
+		}
+	}
+	return nil
+}
+
+// ProviderName is the name of the package and its provider.
+const ProviderName = "trigger/k8s"
+
+//go:embed k8s.cue
+var template string
+
+// Package is the CUE package "trigger/k8s".
+var Package = runtime.Must(library.NewPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"matchLabels":   cuexruntime.GenericProviderFn[MatchLabelsParams, BoolReturns](MatchLabels),
+	"parseQuantity": cuexruntime.GenericProviderFn[QuantityParams, QuantityReturns](ParseQuantity),
+	"owner":         cuexruntime.GenericProviderFn[ObjectParams, OwnerReturns](Owner),
+	"condition":     cuexruntime.GenericProviderFn[ConditionParams, ConditionReturns](Condition),
+	"conditionTrue": cuexruntime.GenericProviderFn[ConditionParams, BoolReturns](ConditionTrue),
+}))
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchLabels(t *testing.T) {
+	labels := map[string]string{"app": "web", "tier": "prod"}
+	testcases := map[string]struct {
+		selector string
+		matches  bool
+		err      bool
+	}{
+		"string":              {selector: `"app=web,tier in (prod, staging)"`, matches: true},
+		"string not matching": {selector: `"app!=web"`, matches: false},
+		"struct":              {selector: `{"matchLabels": {"app": "web"}, "matchExpressions": [{"key": "tier", "operator": "Exists"}]}`, matches: true},
+		"struct not matching": {selector: `{"matchExpressions": [{"key": "tier", "operator": "NotIn", "values": ["prod"]}]}`, matches: false},
+		"empty struct":        {selector: `{}`, matches: true},
+		"invalid string":      {selector: `"app in web"`, err: true},
+		"invalid operator":    {selector: `{"matchExpressions": [{"key": "tier", "operator": "Has"}]}`, err: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r, err := MatchLabels(context.Background(), &MatchLabelsParams{Params: LabelSelection{Labels: labels, Selector: []byte(tc.selector)}})
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.matches, r.Returns)
+		})
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	for q, v := range map[string]float64{"500m": 0.5, "2Gi": 2 * 1024 * 1024 * 1024, "3": 3} {
+		r, err := ParseQuantity(context.Background(), &QuantityParams{Params: q})
+		require.NoError(t, err)
+		assert.Equal(t, v, r.Returns, q)
+	}
+	_, err := ParseQuantity(context.Background(), &QuantityParams{Params: "lots"})
+	assert.Error(t, err)
+}
+
+func TestOwner(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"ownerReferences": []interface{}{
+				map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "name": "ref", "uid": "1"},
+				map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-1", "uid": "2", "controller": true},
+			},
+		},
+	}
+	r, err := Owner(context.Background(), &ObjectParams{Params: obj})
+	require.NoError(t, err)
+	require.NotNil(t, r.Returns)
+	assert.Equal(t, "web-1", r.Returns.Name)
+
+	r, err = Owner(context.Background(), &ObjectParams{Params: map[string]interface{}{}})
+	require.NoError(t, err)
+	assert.Nil(t, r.Returns)
+}
+
+func TestCondition(t *testing.T) {
+	obj := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "True"},
+				map[string]interface{}{"type": "Progressing", "status": "False", "reason": "Timeout"},
+			},
+		},
+	}
+	r, err := Condition(context.Background(), &ConditionParams{Params: ObjectCondition{Object: obj, Type: "Progressing"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Timeout", r.Returns["reason"])
+
+	for typ, status := range map[string]bool{"Available": true, "Progressing": false, "Ready": false} {
+		r, err := ConditionTrue(context.Background(), &ConditionParams{Params: ObjectCondition{Object: obj, Type: typ}})
+		require.NoError(t, err)
+		assert.Equal(t, status, r.Returns, typ)
+	}
+}
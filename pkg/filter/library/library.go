@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package library has the helpers of the CUE packages that kube-trigger
+// provides to filters, e.g. import "trigger".
+package library
+
+import (
+	"context"
+
+	"cuelang.org/go/cue/build"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/cue/util"
+)
+
+// Package is a CUE package with provider functions. Unlike cuex internal
+// packages, its import path is its name, without the vela/ prefix.
+type Package struct {
+	name     string
+	template string
+	imp      *build.Instance
+	fns      map[string]cuexruntime.ProviderFn
+}
+
+var _ cuexruntime.Package = &Package{}
+
+// NewPackage creates a Package named name, e.g. trigger/time. Provider
+// functions in template use name as their #provider.
+func NewPackage(name, template string, fns map[string]cuexruntime.ProviderFn) (*Package, error) {
+	imp, err := util.BuildImport(name, map[string]string{"-": template})
+	if err != nil {
+		return nil, err
+	}
+	return &Package{name: name, template: template, imp: imp, fns: fns}, nil
+}
+
+// GetProviderFn implements cuexruntime.Provider.
+func (p *Package) GetProviderFn(do string) cuexruntime.ProviderFn {
+	return p.fns[do]
+}
+
+// GetName implements cuexruntime.CUETemplater.
+func (p *Package) GetName() string {
+	return p.name
+}
+
+// GetPath implements cuexruntime.CUETemplater.
+func (p *Package) GetPath() string {
+	return p.name
+}
+
+// GetTemplates implements cuexruntime.CUETemplater.
+func (p *Package) GetTemplates() []string {
+	return []string{p.template}
+}
+
+// GetImports implements cuexruntime.CUETemplater.
+func (p *Package) GetImports() []*build.Instance {
+	return []*build.Instance{p.imp}
+}
+
+type eventContextKey struct{}
+
+// WithEventContext returns a copy of ctx with the context of the event being
+// filtered, for provider functions to use.
+func WithEventContext(ctx context.Context, eventContext map[string]interface{}) context.Context {
+	return context.WithValue(ctx, eventContextKey{}, eventContext)
+}
+
+// EventContext gets the context of the event being filtered, or nil.
+func EventContext(ctx context.Context) map[string]interface{} {
+	c, _ := ctx.Value(eventContextKey{}).(map[string]interface{})
+	return c
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package registry loads the CUE packages of kube-trigger, for filters and
+// actions to import.
+package registry
+
+import (
+	"sync"
+
+	"github.com/kubevela/pkg/cue/cuex"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library/k8s"
+	"github.com/kubevela/kube-trigger/pkg/filter/library/semver"
+	"github.com/kubevela/kube-trigger/pkg/filter/library/time"
+	"github.com/kubevela/kube-trigger/pkg/filter/library/trigger"
+)
+
+var loadOnce sync.Once
+
+// Load adds the packages to the default compiler. It is done lazily, since
+// the compiler needs a kubeconfig. It is safe to call many times.
+func Load() {
+	loadOnce.Do(func() {
+		cuex.DefaultCompiler.Get().LoadInternalPackages(
+			trigger.Package,
+			time.Package,
+			semver.Package,
+			k8s.Package,
+		)
+	})
+}
@@ -0,0 +1,25 @@
+package semver
+
+// +usage=Compares two semantic versions. It returns -1 if a is lower than b, 0 if they are equal, and 1 if a is higher. Versions may have a v prefix.
+#Compare: {
+	#do:       "compare"
+	#provider: "trigger/semver"
+
+	$params: {
+		a: string
+		b: string
+	}
+	$returns?: int
+}
+
+// +usage=Whether a semantic version is in a range, e.g. ">=1.2.0 <2.0.0 || >=3.0.0".
+#InRange: {
+	#do:       "inRange"
+	#provider: "trigger/semver"
+
+	$params: {
+		version: string
+		range:   string
+	}
+	$returns?: bool
+}
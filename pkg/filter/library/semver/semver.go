@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package semver is the CUE package "trigger/semver".
+package semver
+
+import (
+	"context"
+	"fmt"
+
+	_ "embed"
+
+	"github.com/blang/semver/v4"
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/runtime"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library"
+)
+
+// Versions is the params of #Compare.
+type Versions struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// CompareParams is the params of #Compare.
+type CompareParams providers.Params[Versions]
+
+// CompareReturns is the returns of #Compare.
+type CompareReturns providers.Returns[int]
+
+// Compare compares two versions.
+func Compare(_ context.Context, params *CompareParams) (*CompareReturns, error) {
+	a, err := parse(params.Params.A)
+	if err != nil {
+		return nil, err
+	}
+	b, err := parse(params.Params.B)
+	if err != nil {
+		return nil, err
+	}
+	return &CompareReturns{Returns: a.Compare(b)}, nil
+}
+
+// VersionRange is the params of #InRange.
+type VersionRange struct {
+	Version string `json:"version"`
+	Range   string `json:"range"`
+}
+
+// InRangeParams is the params of #InRange.
+type InRangeParams providers.Params[VersionRange]
+
+// InRangeReturns is the returns of #InRange.
+type InRangeReturns providers.Returns[bool]
+
+// InRange reports whether a version is in a range.
+func InRange(_ context.Context, params *InRangeParams) (*InRangeReturns, error) {
+	v, err := parse(params.Params.Version)
+	if err != nil {
+		return nil, err
+	}
+	r, err := semver.ParseRange(params.Params.Range)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %q: %w", params.Params.Range, err)
+	}
+	return &InRangeReturns{Returns: r(v)}, nil
+}
+
+func parse(s string) (semver.Version, error) {
+	v, err := semver.ParseTolerant(s)
+	if err != nil {
+		return v, fmt.Errorf("invalid version %q: %w", s, err)
+	}
+	return v, nil
+}
+
+// ProviderName is the name of the package and its provider.
+const ProviderName = "trigger/semver"
+
+//go:embed semver.cue
+var template string
+
+// Package is the CUE package "trigger/semver".
+var Package = runtime.Must(library.NewPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"compare": cuexruntime.GenericProviderFn[CompareParams, CompareReturns](Compare),
+	"inRange": cuexruntime.GenericProviderFn[InRangeParams, InRangeReturns](InRange),
+}))
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package semver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	testcases := map[string]struct {
+		a, b   string
+		result int
+		err    bool
+	}{
+		"lower":   {a: "1.2.3", b: "1.10.0", result: -1},
+		"equal":   {a: "v1.2.0", b: "1.2", result: 0},
+		"higher":  {a: "2.0.0", b: "2.0.0-rc.1", result: 1},
+		"invalid": {a: "latest", b: "1.0.0", err: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r, err := Compare(context.Background(), &CompareParams{Params: Versions{A: tc.a, B: tc.b}})
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.result, r.Returns)
+		})
+	}
+}
+
+func TestInRange(t *testing.T) {
+	testcases := map[string]struct {
+		version, rng string
+		in           bool
+		err          bool
+	}{
+		"in":            {version: "v1.5.0", rng: ">=1.2.0 <2.0.0", in: true},
+		"out":           {version: "2.1.0", rng: ">=1.2.0 <2.0.0", in: false},
+		"or":            {version: "3.1.0", rng: "<2.0.0 || >=3.0.0", in: true},
+		"invalid range": {version: "1.0.0", rng: "~>1", err: true},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r, err := InRange(context.Background(), &InRangeParams{Params: VersionRange{Version: tc.version, Range: tc.rng}})
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.in, r.Returns)
+		})
+	}
+}
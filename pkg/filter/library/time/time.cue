@@ -0,0 +1,21 @@
+package time
+
+// +usage=Whether a time is within business hours in a time zone. Hours ending before they start span midnight.
+#InBusinessHours: {
+	#do:       "inBusinessHours"
+	#provider: "trigger/time"
+
+	$params: {
+		// +usage=The time in RFC 3339, e.g. context.timestamp. Defaults to now.
+		time?: string
+		// +usage=The IANA time zone, e.g. Asia/Shanghai.
+		timezone: *"UTC" | string
+		// +usage=The business days.
+		days: *["Mon", "Tue", "Wed", "Thu", "Fri"] | [...("Mon" | "Tue" | "Wed" | "Thu" | "Fri" | "Sat" | "Sun")]
+		// +usage=When business hours start, e.g. 09:00.
+		start: *"09:00" | string
+		// +usage=When business hours end, e.g. 17:00.
+		end: *"17:00" | string
+	}
+	$returns?: bool
+}
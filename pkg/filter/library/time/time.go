@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package time is the CUE package "trigger/time".
+package time
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	_ "embed"
+	// Time zones are embedded, since images may not have them.
+	_ "time/tzdata"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/runtime"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// BusinessHours is the params of #InBusinessHours.
+type BusinessHours struct {
+	Time     string   `json:"time"`
+	Timezone string   `json:"timezone"`
+	Days     []string `json:"days"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+}
+
+// BusinessHoursParams is the params of #InBusinessHours.
+type BusinessHoursParams providers.Params[BusinessHours]
+
+// BoolReturns is the returns of #InBusinessHours.
+type BoolReturns providers.Returns[bool]
+
+// InBusinessHours reports whether a time is within business hours.
+func InBusinessHours(_ context.Context, params *BusinessHoursParams) (*BoolReturns, error) {
+	p := params.Params
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", p.Timezone, err)
+	}
+	t := now()
+	if p.Time != "" {
+		if t, err = time.Parse(time.RFC3339, p.Time); err != nil {
+			return nil, fmt.Errorf("invalid time %q: %w", p.Time, err)
+		}
+	}
+	start, err := minutes(p.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := minutes(p.End)
+	if err != nil {
+		return nil, err
+	}
+	t = t.In(loc)
+	// Hours spanning midnight belong to the day they start.
+	day := t
+	m := t.Hour()*60 + t.Minute()
+	var in bool
+	switch {
+	case start <= end:
+		in = m >= start && m < end
+	case m >= start:
+		in = true
+	case m < end:
+		in = true
+		day = t.AddDate(0, 0, -1)
+	}
+	if !in {
+		return &BoolReturns{Returns: false}, nil
+	}
+	for _, d := range p.Days {
+		if d == day.Weekday().String()[:3] {
+			return &BoolReturns{Returns: true}, nil
+		}
+	}
+	return &BoolReturns{Returns: false}, nil
+}
+
+// minutes parses a time of day like 09:30 into minutes since midnight.
+func minutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, must be like 09:00", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ProviderName is the name of the package and its provider.
+const ProviderName = "trigger/time"
+
+//go:embed time.cue
+var template string
+
+// Package is the CUE package "trigger/time".
+var Package = runtime.Must(library.NewPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"inBusinessHours": cuexruntime.GenericProviderFn[BusinessHoursParams, BoolReturns](InBusinessHours),
+}))
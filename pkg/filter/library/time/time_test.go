@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package time
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInBusinessHours(t *testing.T) {
+	weekdays := []string{"Mon", "Tue", "Wed", "Thu", "Fri"}
+	testcases := map[string]struct {
+		hours BusinessHours
+		in    bool
+		err   bool
+	}{
+		"in": {
+			// Monday 10:00 in Shanghai.
+			hours: BusinessHours{Time: "2023-01-02T02:00:00Z", Timezone: "Asia/Shanghai", Days: weekdays, Start: "09:00", End: "17:00"},
+			in:    true,
+		},
+		"after hours in the time zone": {
+			// Monday 02:00 in UTC, but 18:00 on Sunday in Los Angeles.
+			hours: BusinessHours{Time: "2023-01-02T02:00:00Z", Timezone: "America/Los_Angeles", Days: weekdays, Start: "09:00", End: "17:00"},
+			in:    false,
+		},
+		"weekend": {
+			hours: BusinessHours{Time: "2023-01-01T10:00:00Z", Timezone: "UTC", Days: weekdays, Start: "09:00", End: "17:00"},
+			in:    false,
+		},
+		"end is excluded": {
+			hours: BusinessHours{Time: "2023-01-02T17:00:00Z", Timezone: "UTC", Days: weekdays, Start: "09:00", End: "17:00"},
+			in:    false,
+		},
+		"overnight": {
+			// Saturday 01:00, in the hours starting on Friday.
+			hours: BusinessHours{Time: "2023-01-07T01:00:00Z", Timezone: "UTC", Days: weekdays, Start: "22:00", End: "06:00"},
+			in:    true,
+		},
+		"overnight from a day off": {
+			// Monday 01:00, in the hours starting on Sunday.
+			hours: BusinessHours{Time: "2023-01-02T01:00:00Z", Timezone: "UTC", Days: weekdays, Start: "22:00", End: "06:00"},
+			in:    false,
+		},
+		"invalid time zone": {
+			hours: BusinessHours{Timezone: "Mars/Olympus", Days: weekdays, Start: "09:00", End: "17:00"},
+			err:   true,
+		},
+		"invalid time of day": {
+			hours: BusinessHours{Timezone: "UTC", Days: weekdays, Start: "9am", End: "17:00"},
+			err:   true,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r, err := InBusinessHours(context.Background(), &BusinessHoursParams{Params: tc.hours})
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.in, r.Returns)
+		})
+	}
+}
+
+func TestInBusinessHoursNow(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2023, 1, 4, 12, 0, 0, 0, time.UTC) }
+	r, err := InBusinessHours(context.Background(), &BusinessHoursParams{Params: BusinessHours{
+		Timezone: "UTC", Days: []string{"Wed"}, Start: "09:00", End: "17:00",
+	}})
+	require.NoError(t, err)
+	assert.True(t, r.Returns)
+}
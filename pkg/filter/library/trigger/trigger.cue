@@ -0,0 +1,42 @@
+package trigger
+
+// +usage=Whether a field of context.data changed since the last event of the same object. It is true for the first event of an object.
+#Changed: {
+	#do:       "changed"
+	#provider: "trigger"
+
+	// +usage=The dot-separated path of the field in context.data, e.g. spec.template. The whole object if empty.
+	path: *"" | string
+	// +usage=The key of the object. Defaults to the apiVersion, kind, namespace and name of context.data.
+	key: *"" | string
+	// +usage=How long an object is remembered without events, e.g. 24h. Its next event is a change.
+	ttl: *"24h" | string
+	// +usage=Whether the field changed.
+	$returns?: bool
+}
+
+// +usage=Whether no event with the same key was kept within the window. State only changes for events kept by the whole filter.
+#Dedupe: {
+	#do:       "dedupe"
+	#provider: "trigger"
+
+	// +usage=The key of the event, e.g. context.data.metadata.name.
+	key: string
+	// +usage=How long to drop events with the same key, e.g. 10m.
+	window: string
+	// +usage=Whether the event is not a duplicate.
+	$returns?: bool
+}
+
+// +usage=Whether the last event with the same key that passed was more than a period ago. Events dropped during the period do not extend it.
+#Cooldown: {
+	#do:       "cooldown"
+	#provider: "trigger"
+
+	// +usage=The key of the event, e.g. context.data.metadata.name.
+	key: string
+	// +usage=How long to drop events with the same key after one passes, e.g. 1h.
+	period: string
+	// +usage=Whether the event passes.
+	$returns?: bool
+}
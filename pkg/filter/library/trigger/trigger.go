@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trigger is the CUE package "trigger", with stateful builtins for
+// filters. State is kept in the state.Store of the trigger.
+package trigger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "embed"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library"
+	"github.com/kubevela/kube-trigger/pkg/state"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// defaultChangedTTL is how long #Changed remembers objects without events.
+const defaultChangedTTL = 24 * time.Hour
+
+// ChangedParams is the params of #Changed.
+type ChangedParams struct {
+	Path string `json:"path"`
+	Key  string `json:"key"`
+	TTL  string `json:"ttl"`
+}
+
+// DedupeParams is the params of #Dedupe.
+type DedupeParams struct {
+	Key    string `json:"key"`
+	Window string `json:"window"`
+}
+
+// CooldownParams is the params of #Cooldown.
+type CooldownParams struct {
+	Key    string `json:"key"`
+	Period string `json:"period"`
+}
+
+// Returns is what the builtins return.
+type Returns struct {
+	Returns bool `json:"$returns"`
+}
+
+// Changed reports whether a field of the object in context.data changed since
+// the last event of the object.
+func Changed(ctx context.Context, params *ChangedParams) (*Returns, error) {
+	store, eventContext, err := from(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ttl := defaultChangedTTL
+	if params.TTL != "" {
+		if ttl, err = parseDuration("ttl", params.TTL); err != nil {
+			return nil, err
+		}
+	}
+	data, _ := eventContext["data"].(map[string]interface{})
+	if data == nil {
+		// Sources may put structs in data.
+		b, err := json.Marshal(eventContext["data"])
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &data); err != nil {
+			return nil, fmt.Errorf("context.data is not an object")
+		}
+	}
+	key := params.Key
+	if key == "" {
+		obj := unstructured.Unstructured{Object: data}
+		key = strings.Join([]string{obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName()}, "/")
+	}
+	var field interface{} = data
+	if params.Path != "" {
+		field, _, _ = unstructured.NestedFieldNoCopy(data, strings.Split(params.Path, ".")...)
+	}
+	b, err := json.Marshal(field)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+
+	// Entries expire after ttl, so that deleted objects are forgotten. They
+	// are renewed once half of ttl is left, not for every event.
+	changed := false
+	err = store.Update(ctx, "changed/"+params.Path+"/"+key, func(current *state.Entry) *state.Entry {
+		t := now()
+		if current != nil && current.Value == hash && current.Expires.Sub(t) > ttl/2 {
+			return current
+		}
+		changed = current == nil || current.Value != hash
+		return &state.Entry{Value: hash, Expires: t.Add(ttl)}
+	})
+	return &Returns{Returns: changed}, err
+}
+
+// Dedupe reports whether no event with the same key was seen within the
+// window.
+func Dedupe(ctx context.Context, params *DedupeParams) (*Returns, error) {
+	store, _, err := from(ctx)
+	if err != nil {
+		return nil, err
+	}
+	window, err := parseDuration("window", params.Window)
+	if err != nil {
+		return nil, err
+	}
+	unique := false
+	err = store.Update(ctx, "dedupe/"+params.Key, func(current *state.Entry) *state.Entry {
+		unique = current == nil
+		return &state.Entry{Expires: now().Add(window)}
+	})
+	return &Returns{Returns: unique}, err
+}
+
+// Cooldown reports whether the last event with the same key that passed was
+// more than the period ago.
+func Cooldown(ctx context.Context, params *CooldownParams) (*Returns, error) {
+	store, _, err := from(ctx)
+	if err != nil {
+		return nil, err
+	}
+	period, err := parseDuration("period", params.Period)
+	if err != nil {
+		return nil, err
+	}
+	passed := false
+	err = store.Update(ctx, "cooldown/"+params.Key, func(current *state.Entry) *state.Entry {
+		if current != nil {
+			return current
+		}
+		passed = true
+		return &state.Entry{Expires: now().Add(period)}
+	})
+	return &Returns{Returns: passed}, err
+}
+
+func from(ctx context.Context) (state.Store, map[string]interface{}, error) {
+	store := state.FromContext(ctx)
+	if store == nil {
+		return nil, nil, fmt.Errorf("no state store for stateful builtins")
+	}
+	return store, library.EventContext(ctx), nil
+}
+
+func parseDuration(name, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, s, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive", name)
+	}
+	return d, nil
+}
+
+// ProviderName is the name of the package and its provider.
+const ProviderName = "trigger"
+
+//go:embed trigger.cue
+var template string
+
+// Package is the CUE package "trigger".
+var Package = runtime.Must(library.NewPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"changed":  cuexruntime.GenericProviderFn[ChangedParams, Returns](Changed),
+	"dedupe":   cuexruntime.GenericProviderFn[DedupeParams, Returns](Dedupe),
+	"cooldown": cuexruntime.GenericProviderFn[CooldownParams, Returns](Cooldown),
+}))
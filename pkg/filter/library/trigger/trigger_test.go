@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library"
+	"github.com/kubevela/kube-trigger/pkg/state"
+)
+
+func eventContext(name string, replicas int) map[string]interface{} {
+	return map[string]interface{}{
+		"data": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       map[string]interface{}{"replicas": replicas},
+			"status":     map[string]interface{}{"readyReplicas": replicas},
+		},
+	}
+}
+
+func TestChanged(t *testing.T) {
+	ctx := state.WithStore(context.Background(), state.NewMemory())
+	changed := func(c map[string]interface{}, path string) bool {
+		r, err := Changed(library.WithEventContext(ctx, c), &ChangedParams{Path: path})
+		require.NoError(t, err)
+		return r.Returns
+	}
+	assert.True(t, changed(eventContext("web", 1), "spec"))
+	assert.False(t, changed(eventContext("web", 1), "spec"))
+	assert.True(t, changed(eventContext("api", 1), "spec"))
+	assert.True(t, changed(eventContext("web", 2), "spec"))
+	// Paths are tracked separately.
+	assert.True(t, changed(eventContext("web", 2), "status"))
+	assert.False(t, changed(eventContext("web", 2), "status"))
+
+	// Objects are forgotten after the ttl.
+	defer func() { now = time.Now }()
+	ttl := func(c map[string]interface{}) bool {
+		r, err := Changed(library.WithEventContext(ctx, c), &ChangedParams{Path: "spec", TTL: "1m"})
+		require.NoError(t, err)
+		return r.Returns
+	}
+	now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+	assert.True(t, ttl(eventContext("db", 1)))
+	now = time.Now
+	assert.True(t, ttl(eventContext("db", 1)))
+	assert.False(t, ttl(eventContext("db", 1)))
+}
+
+func TestDedupe(t *testing.T) {
+	defer func() { now = time.Now }()
+	ctx := state.WithStore(context.Background(), state.NewMemory())
+	dedupe := func() bool {
+		r, err := Dedupe(ctx, &DedupeParams{Key: "web", Window: "1m"})
+		require.NoError(t, err)
+		return r.Returns
+	}
+	assert.True(t, dedupe())
+	assert.False(t, dedupe())
+	// The store expires entries by the wall clock, so the window of an event
+	// seen 2m ago has ended.
+	now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+	assert.False(t, dedupe())
+	now = time.Now
+	assert.True(t, dedupe())
+
+	_, err := Dedupe(ctx, &DedupeParams{Key: "web", Window: "soon"})
+	assert.Error(t, err)
+}
+
+func TestCooldown(t *testing.T) {
+	defer func() { now = time.Now }()
+	ctx := state.WithStore(context.Background(), state.NewMemory())
+	cooldown := func() bool {
+		r, err := Cooldown(ctx, &CooldownParams{Key: "web", Period: "1h"})
+		require.NoError(t, err)
+		return r.Returns
+	}
+	now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	assert.True(t, cooldown())
+	// Dropped events do not extend the period, which ended an hour ago.
+	now = time.Now
+	assert.True(t, cooldown())
+	assert.False(t, cooldown())
+}
+
+// TestDedupeCooldown shows how they differ for events kept by the filter
+// although they are duplicates, e.g. passed by another condition: each one
+// restarts the window of #Dedupe, while the period of #Cooldown counts from
+// the event that passed it. The store is used directly here, as if all events
+// were kept.
+func TestDedupeCooldown(t *testing.T) {
+	ctx := state.WithStore(context.Background(), state.NewMemory())
+	both := func() (bool, bool) {
+		d, err := Dedupe(ctx, &DedupeParams{Key: "web", Window: "1s"})
+		require.NoError(t, err)
+		c, err := Cooldown(ctx, &CooldownParams{Key: "web", Period: "1s"})
+		require.NoError(t, err)
+		return d.Returns, c.Returns
+	}
+	dedupe, cooldown := both()
+	assert.True(t, dedupe)
+	assert.True(t, cooldown)
+	time.Sleep(600 * time.Millisecond)
+	dedupe, cooldown = both()
+	assert.False(t, dedupe)
+	assert.False(t, cooldown)
+	// 1.2s after the first event, 0.6s after the second one.
+	time.Sleep(600 * time.Millisecond)
+	dedupe, cooldown = both()
+	assert.False(t, dedupe)
+	assert.True(t, cooldown)
+}
+
+func TestNoStore(t *testing.T) {
+	_, err := Cooldown(context.Background(), &CooldownParams{Key: "web", Period: "1h"})
+	assert.Error(t, err)
+}
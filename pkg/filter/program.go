@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"github.com/kubevela/pkg/cue/cuex"
+	"k8s.io/utils/lru"
+
+	"github.com/kubevela/kube-trigger/pkg/filter/library"
+	"github.com/kubevela/kube-trigger/pkg/filter/library/registry"
+)
+
+const defaultCacheSize = 100
+
+// programs caches compiled filters by filter text.
+var programs = lru.New(defaultCacheSize)
+
+// SetCacheSize sets how many compiled filters are cached. Cached filters are
+// dropped. It must be called before filters are applied.
+func SetCacheSize(size int) {
+	programs = lru.New(size)
+}
+
+var contextPath = cue.ParsePath("context")
+
+// Program is a filter compiled once, and evaluated against the context of
+// each event.
+type Program struct {
+	// mu guards value, since CUE values are not safe for concurrent use.
+	mu    sync.Mutex
+	value cue.Value
+	// resolve is whether the filter calls provider functions, which are
+	// called for each event.
+	resolve bool
+}
+
+// Compile compiles a filter into a Program. Errors are CompileErrors.
+func Compile(ctx context.Context, filter string) (*Program, error) {
+	// An empty filter keeps everything.
+	if strings.TrimSpace(filter) == "" {
+		filter = "true"
+	}
+	template, err := BuildFilterTemplate(filter)
+	if err != nil {
+		return nil, &CompileError{Err: err}
+	}
+	// Declare context, so that references to it are valid before it is filled.
+	template += "\ncontext: _\n"
+	registry.Load()
+	value, err := cuex.CompileStringWithOptions(ctx, template, cuex.DisableResolveProviderFunctions{})
+	if err != nil {
+		return nil, &CompileError{Err: err}
+	}
+	if value.Err() != nil {
+		return nil, &CompileError{Err: value.Err()}
+	}
+	resolve, err := usesProviders(template)
+	if err != nil {
+		return nil, &CompileError{Err: err}
+	}
+	return &Program{value: value, resolve: resolve}, nil
+}
+
+// Eval evaluates the Program against contextData. Errors are EvalErrors.
+func (p *Program) Eval(ctx context.Context, contextData map[string]interface{}) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	filterVal := p.value.FillPath(contextPath, contextData)
+	if p.resolve {
+		var err error
+		ctx = library.WithEventContext(ctx, contextData)
+		filterVal, err = cuex.DefaultCompiler.Get().Resolve(ctx, filterVal)
+		if err != nil {
+			return Result{}, &EvalError{Err: err}
+		}
+	}
+	kept, err := result(filterVal)
+	if err != nil || !kept {
+		return Result{}, err
+	}
+	out, err := output(filterVal, contextData)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Kept: true, Output: out}, nil
+}
+
+// programFor gets the compiled filter from the cache, or compiles it.
+func programFor(ctx context.Context, filter string) (*Program, error) {
+	if p, ok := programs.Get(filter); ok {
+		return p.(*Program), nil
+	}
+	p, err := Compile(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	programs.Add(filter, p)
+	return p, nil
+}
+
+// usesProviders reports whether template imports packages with provider
+// functions.
+func usesProviders(template string) (bool, error) {
+	f, err := parser.ParseFile("-", template, parser.ImportsOnly)
+	if err != nil {
+		return false, err
+	}
+	if len(f.Imports) == 0 {
+		return false, nil
+	}
+	paths := make(map[string]bool)
+	for _, pkg := range cuex.DefaultCompiler.Get().GetPackages() {
+		paths[pkg.GetPath()] = true
+	}
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return false, err
+		}
+		if paths[path] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
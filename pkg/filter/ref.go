@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"github.com/kubevela/pkg/util/template/definition"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+	"github.com/kubevela/kube-trigger/pkg/types"
+)
+
+// AllOf passes events that pass all of its Filters. The outputs of the
+// Filters are merged.
+type AllOf []Filter
+
+// Eval implements Filter.
+func (f AllOf) Eval(ctx context.Context, contextData map[string]interface{}) (Result, error) {
+	res := Result{Kept: true}
+	for _, filter := range f {
+		r, err := filter.Eval(ctx, contextData)
+		if err != nil || !r.Kept {
+			return Result{}, err
+		}
+		for k, v := range r.Output {
+			if res.Output == nil {
+				res.Output = make(map[string]interface{})
+			}
+			res.Output[k] = v
+		}
+	}
+	return res, nil
+}
+
+// AnyOf passes events that pass any of its Filters, with the output of the
+// first one they pass. Errors are returned if events pass none of them.
+type AnyOf []Filter
+
+// Eval implements Filter.
+func (f AnyOf) Eval(ctx context.Context, contextData map[string]interface{}) (Result, error) {
+	var firstErr error
+	for _, filter := range f {
+		r, err := filter.Eval(ctx, contextData)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if r.Kept {
+			return r, nil
+		}
+	}
+	return Result{}, firstErr
+}
+
+// Not passes events that do not pass its Filter.
+type Not struct {
+	Filter Filter
+}
+
+// Eval implements Filter.
+func (f Not) Eval(ctx context.Context, contextData map[string]interface{}) (Result, error) {
+	r, err := f.Filter.Eval(ctx, contextData)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Kept: !r.Kept}, nil
+}
+
+// NewFromRef creates a Filter from ref. Named filters are CUE, loaded from
+// trigger-filter Definitions with cli. Like filters of triggers, they evaluate
+// to a bool, or are structs that the context must match, and can use their
+// properties as parameter.
+func NewFromRef(ctx context.Context, cli client.Client, ref v1alpha1.FilterRef) (Filter, error) {
+	set := 0
+	for _, ok := range []bool{ref.Name != "", ref.AllOf != nil, ref.AnyOf != nil, ref.Not != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of name, allOf, anyOf and not must be set in a filter reference")
+	}
+	if ref.Properties != nil && ref.Name == "" {
+		return nil, fmt.Errorf("properties are only allowed with name in a filter reference")
+	}
+	switch {
+	case ref.AllOf != nil:
+		filters, err := newFromRefs(ctx, cli, ref.AllOf)
+		return AllOf(filters), err
+	case ref.AnyOf != nil:
+		filters, err := newFromRefs(ctx, cli, ref.AnyOf)
+		return AnyOf(filters), err
+	case ref.Not != nil:
+		f, err := NewFromRef(ctx, cli, *ref.Not)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Filter: f}, nil
+	}
+	template, err := definition.NewTemplateLoader(ctx, cli).LoadTemplate(ctx, ref.Name, definition.WithType(types.DefinitionTypeTriggerFilter))
+	if err != nil {
+		return nil, fmt.Errorf("cannot load filter %s: %w", ref.Name, err)
+	}
+	parameter := []byte("{}")
+	if ref.Properties != nil && len(ref.Properties.Raw) > 0 {
+		parameter = ref.Properties.Raw
+	}
+	filter, err := withParameter(template.Compile(), parameter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %s: %w", ref.Name, &CompileError{Err: err})
+	}
+	f, err := New(ctx, filter, LanguageCUE)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %s: %w", ref.Name, err)
+	}
+	return f, nil
+}
+
+// withParameter adds the parameter to a named filter. A bare expression in
+// the filter becomes its filter field, so that it can have other fields.
+func withParameter(template string, parameter []byte) (string, error) {
+	f, err := parser.ParseFile("-", template)
+	if err != nil {
+		return "", err
+	}
+	value, err := parser.ParseExpr("parameter", parameter)
+	if err != nil {
+		return "", err
+	}
+	for i, decl := range f.Decls {
+		if embed, ok := decl.(*ast.EmbedDecl); ok {
+			f.Decls[i] = &ast.Field{Label: ast.NewIdent("filter"), Value: embed.Expr}
+		}
+	}
+	f.Decls = append(f.Decls, &ast.Field{Label: ast.NewIdent("parameter"), Value: value})
+	b, err := format.Node(f)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func newFromRefs(ctx context.Context, cli client.Client, refs []v1alpha1.FilterRef) ([]Filter, error) {
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("allOf and anyOf need at least one filter")
+	}
+	filters := make([]Filter, 0, len(refs))
+	for _, ref := range refs {
+		f, err := NewFromRef(ctx, cli, ref)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
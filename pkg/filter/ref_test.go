@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	oamv1alpha1 "github.com/kubevela/pkg/apis/oam/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+	"github.com/kubevela/kube-trigger/pkg/types"
+)
+
+func filterDefinition(name, template string) client.Object {
+	return &oamv1alpha1.Definition{
+		ObjectMeta: metav1.ObjectMeta{Name: types.DefinitionTypeTriggerFilter + "-" + name, Namespace: "vela-system"},
+		Spec: oamv1alpha1.DefinitionSpec{
+			Type:      types.DefinitionTypeTriggerFilter,
+			Templates: map[string]string{"main.cue": template},
+		},
+	}
+}
+
+func TestNewFromRef(t *testing.T) {
+	sc := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(sc))
+	require.NoError(t, oamv1alpha1.AddToScheme(sc))
+	cli := fake.NewClientBuilder().WithScheme(sc).WithObjects(
+		filterDefinition("in-namespace", `
+			import "list"
+			parameter: namespaces: [...string]
+			list.Contains(parameter.namespaces, context.data.metadata.namespace)`),
+		filterDefinition("labelled", `
+			parameter: {key: string, value: string}
+			context: data: metadata: labels: (parameter.key): parameter.value
+			output: (parameter.key): parameter.value`),
+		filterDefinition("is-configmap", `context.data.kind == "ConfigMap"`),
+		filterDefinition("invalid", `context.data.kind ==`),
+	).Build()
+
+	ref := func(name, properties string) v1alpha1.FilterRef {
+		r := v1alpha1.FilterRef{Name: name}
+		if properties != "" {
+			r.Properties = &runtime.RawExtension{Raw: []byte(properties)}
+		}
+		return r
+	}
+	testcases := map[string]struct {
+		ref        v1alpha1.FilterRef
+		kept       bool
+		output     map[string]interface{}
+		err        string
+		compileErr bool
+	}{
+		"named": {
+			ref:  ref("is-configmap", ""),
+			kept: true,
+		},
+		"with properties": {
+			ref:  ref("in-namespace", `{"namespaces": ["default", "prod"]}`),
+			kept: true,
+		},
+		"with output": {
+			ref:    ref("labelled", `{"key": "tier", "value": "prod"}`),
+			kept:   true,
+			output: map[string]interface{}{"tier": "prod"},
+		},
+		"allOf": {
+			ref: v1alpha1.FilterRef{AllOf: []v1alpha1.FilterRef{
+				ref("is-configmap", ""),
+				ref("labelled", `{"key": "app", "value": "web"}`),
+				ref("labelled", `{"key": "tier", "value": "prod"}`),
+			}},
+			kept:   true,
+			output: map[string]interface{}{"app": "web", "tier": "prod"},
+		},
+		"allOf not matching": {
+			ref: v1alpha1.FilterRef{AllOf: []v1alpha1.FilterRef{
+				ref("is-configmap", ""),
+				ref("in-namespace", `{"namespaces": ["prod"]}`),
+			}},
+			kept: false,
+		},
+		"anyOf": {
+			ref: v1alpha1.FilterRef{AnyOf: []v1alpha1.FilterRef{
+				ref("in-namespace", `{"namespaces": ["prod"]}`),
+				ref("labelled", `{"key": "app", "value": "web"}`),
+			}},
+			kept:   true,
+			output: map[string]interface{}{"app": "web"},
+		},
+		"not": {
+			ref:  v1alpha1.FilterRef{Not: &v1alpha1.FilterRef{AnyOf: []v1alpha1.FilterRef{ref("in-namespace", `{"namespaces": ["prod"]}`)}}},
+			kept: true,
+		},
+		"not found": {
+			ref: ref("not-found", ""),
+			err: "cannot load filter not-found",
+		},
+		"invalid template": {
+			ref:        ref("invalid", ""),
+			err:        "invalid filter invalid",
+			compileErr: true,
+		},
+		"more than one set": {
+			ref: v1alpha1.FilterRef{Name: "is-configmap", Not: &v1alpha1.FilterRef{Name: "is-configmap"}},
+			err: "exactly one of",
+		},
+		"empty allOf": {
+			ref: v1alpha1.FilterRef{AllOf: []v1alpha1.FilterRef{}},
+			err: "at least one filter",
+		},
+		"properties without name": {
+			ref: v1alpha1.FilterRef{Not: &v1alpha1.FilterRef{Name: "is-configmap"}, Properties: &runtime.RawExtension{Raw: []byte("{}")}},
+			err: "properties are only allowed with name",
+		},
+	}
+	ctx := context.Background()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			f, err := NewFromRef(ctx, cli, tc.ref)
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				var compileErr *CompileError
+				assert.Equal(t, tc.compileErr, errors.As(err, &compileErr))
+				return
+			}
+			require.NoError(t, err)
+			res, err := f.Eval(ctx, benchmarkContext())
+			require.NoError(t, err)
+			assert.Equal(t, tc.kept, res.Kept)
+			assert.Equal(t, tc.output, res.Output)
+		})
+	}
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+)
+
+// reference is a field of context that a filter or an action refers to.
+type reference struct {
+	pos  token.Pos
+	path []cue.Selector
+}
+
+func (r reference) String() string {
+	var sb strings.Builder
+	sb.WriteString("context")
+	for _, sel := range r.path {
+		if sel.Type() == cue.IndexLabel {
+			sb.WriteString("[" + sel.String() + "]")
+			continue
+		}
+		sb.WriteString("." + sel.String())
+	}
+	return sb.String()
+}
+
+// CheckReferences checks that the fields of context that src, a CUE filter or
+// action template, refers to are in the schemas of context.event and
+// context.data. Both are CUE, and not checked if empty. Only the paths of the
+// fields are checked; src is not unified with the schemas, so the types of
+// values are not.
+func CheckReferences(src, eventSchema, dataSchema string) error {
+	if eventSchema == "" && dataSchema == "" {
+		return nil
+	}
+	schema, err := contextSchema(eventSchema, dataSchema)
+	if err != nil {
+		return fmt.Errorf("invalid schema of events: %w", err)
+	}
+	f, err := parser.ParseFile("-", src)
+	if err != nil {
+		return &CompileError{Err: err}
+	}
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, ref := range references(f) {
+		n := unknownPrefix(schema, ref.path)
+		if n == 0 {
+			continue
+		}
+		ref.path = ref.path[:n]
+		if seen[ref.String()] {
+			continue
+		}
+		seen[ref.String()] = true
+		unknown = append(unknown, fmt.Sprintf("%s (line %d, column %d)", ref, ref.pos.Line(), ref.pos.Column()))
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("not in the schema of events: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// CheckFilterReferences is CheckReferences for a filter in language. CEL
+// filters are not checked.
+func CheckFilterReferences(filter, language, eventSchema, dataSchema string) error {
+	if strings.HasPrefix(filter, celPrefix) || language == LanguageCEL {
+		return nil
+	}
+	return CheckReferences(filter, eventSchema, dataSchema)
+}
+
+// contextSchema compiles the schema of context, with closed event and data.
+func contextSchema(eventSchema, dataSchema string) (cue.Value, error) {
+	var imports []ast.Decl
+	seen := make(map[string]bool)
+	def := func(name, schema string) (string, error) {
+		if schema == "" {
+			return fmt.Sprintf("%s: _", name), nil
+		}
+		f, err := parser.ParseFile(name, schema)
+		if err != nil {
+			return "", err
+		}
+		var decls []ast.Decl
+		for _, decl := range f.Decls {
+			if imp, ok := decl.(*ast.ImportDecl); ok {
+				for _, spec := range imp.Specs {
+					if !seen[spec.Path.Value] {
+						seen[spec.Path.Value] = true
+						imports = append(imports, &ast.ImportDecl{Specs: []*ast.ImportSpec{spec}})
+					}
+				}
+				continue
+			}
+			decls = append(decls, decl)
+		}
+		b, err := format.Node(&ast.File{Decls: decls})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s: {\n%s\n}", name, b), nil
+	}
+	event, err := def("#Event", eventSchema)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	data, err := def("#Data", dataSchema)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	b, err := format.Node(&ast.File{Decls: imports})
+	if err != nil {
+		return cue.Value{}, err
+	}
+	src := fmt.Sprintf(`%s
+#Context: {
+	sourceType: string
+	event:      #Event
+	data:       #Data
+	timestamp:  string
+	related?: {...}
+	filter?: {...}
+	storm?: {
+		summarized: int
+		sampled:    int
+		since:      string
+	}
+}
+%s
+%s
+`, b, event, data)
+	v := cuecontext.New().CompileString(src)
+	if v.Err() != nil {
+		return cue.Value{}, v.Err()
+	}
+	return v.LookupPath(cue.MakePath(cue.Def("#Context"))), nil
+}
+
+// unknownPrefix returns the length of the shortest prefix of path that is not
+// in schema, or 0 if there is none. Fields below open structs, and below
+// fields whose value cannot be looked up, are not checked.
+func unknownPrefix(schema cue.Value, path []cue.Selector) int {
+	v := schema
+	for i, sel := range path {
+		if !v.Allows(sel) {
+			return i + 1
+		}
+		next := v.LookupPath(cue.MakePath(sel))
+		if !next.Exists() {
+			switch sel.Type() {
+			case cue.StringLabel:
+				next = v.LookupPath(cue.MakePath(sel.Optional()))
+			case cue.IndexLabel:
+				// Elements of lists of any length.
+				next = v.LookupPath(cue.MakePath(cue.AnyIndex))
+			}
+		}
+		if !next.Exists() {
+			return 0
+		}
+		v = next
+	}
+	return 0
+}
+
+// references finds the fields of context that f refers to, in selectors like
+// context.data.spec, and in fields declared under a top-level context field,
+// like those of struct filters.
+func references(f *ast.File) []reference {
+	var refs []reference
+	ast.Walk(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.SelectorExpr, *ast.IndexExpr:
+			if path, _, ok := selectors(n.(ast.Expr)); ok && len(path) > 0 {
+				refs = append(refs, reference{pos: n.Pos(), path: path})
+			}
+		}
+		return true
+	}, nil)
+	for _, decl := range f.Decls {
+		if field, ok := decl.(*ast.Field); ok && labelName(field.Label) == "context" {
+			refs = append(refs, declared(nil, field.Value)...)
+		}
+	}
+	return refs
+}
+
+// selectors gets the path of an expression rooted at context. complete is
+// false if the path stops at an index that is not a literal.
+func selectors(expr ast.Expr) (path []cue.Selector, complete bool, ok bool) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return nil, true, e.Name == "context"
+	case *ast.SelectorExpr:
+		path, complete, ok = selectors(e.X)
+		if !ok || !complete {
+			return path, false, ok
+		}
+		name := labelName(e.Sel)
+		if name == "" {
+			return path, false, true
+		}
+		return append(path, cue.Str(name)), true, true
+	case *ast.IndexExpr:
+		path, complete, ok = selectors(e.X)
+		if !ok || !complete {
+			return path, false, ok
+		}
+		lit, isLit := e.Index.(*ast.BasicLit)
+		if !isLit {
+			return path, false, true
+		}
+		switch lit.Kind {
+		case token.INT:
+			i, err := strconv.Atoi(lit.Value)
+			if err != nil {
+				return path, false, true
+			}
+			return append(path, cue.Index(i)), true, true
+		case token.STRING:
+			s, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return path, false, true
+			}
+			return append(path, cue.Str(s)), true, true
+		}
+		return path, false, true
+	}
+	return nil, false, false
+}
+
+// declared finds the fields declared in the value of a context field.
+func declared(prefix []cue.Selector, expr ast.Expr) []reference {
+	s, ok := expr.(*ast.StructLit)
+	if !ok {
+		return nil
+	}
+	var refs []reference
+	for _, elt := range s.Elts {
+		field, ok := elt.(*ast.Field)
+		if !ok {
+			continue
+		}
+		name := labelName(field.Label)
+		if name == "" {
+			continue
+		}
+		path := append(append([]cue.Selector{}, prefix...), cue.Str(name))
+		refs = append(refs, reference{pos: field.Pos(), path: path})
+		refs = append(refs, declared(path, field.Value)...)
+	}
+	return refs
+}
+
+// labelName gets the name of an identifier or string label, or "" for other
+// labels, like patterns.
+func labelName(l ast.Label) string {
+	switch l := l.(type) {
+	case *ast.Ident:
+		return l.Name
+	case *ast.BasicLit:
+		if l.Kind == token.STRING {
+			if s, err := strconv.Unquote(l.Value); err == nil {
+				return s
+			}
+		}
+	}
+	return ""
+}
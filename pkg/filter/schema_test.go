@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReferences(t *testing.T) {
+	event := `type: "create" | "update" | "delete"`
+	data := `
+	import "strings"
+	apiVersion?: string
+	kind?:       string
+	metadata?: {...}
+	spec?: {
+		replicas?: int
+		containers?: [...{image!: string & strings.MinRunes(1)}]
+		selector?: matchLabels?: [string]: string
+	}`
+	testcases := map[string]struct {
+		src     string
+		event   string
+		data    string
+		unknown string
+	}{
+		"known fields": {
+			src: `
+			context.event.type == "update" &&
+			context.data.spec.replicas > 1 &&
+			context.data.spec.containers[0].image != "" &&
+			context.data.spec.selector.matchLabels.app == "web"`,
+		},
+		"open structs": {
+			src: `context.data.metadata.labels["app.oam.dev/name"] != _|_ && context.related.namespace.metadata.name == "prod"`,
+		},
+		"unknown field": {
+			src:     `context.data.spec.replicaz > 1`,
+			unknown: "context.data.spec.replicaz (line 1, column 1)",
+		},
+		"unknown field of event": {
+			src:     `context.event.typ == "update"`,
+			unknown: "context.event.typ (line 1, column 1)",
+		},
+		"unknown field in a list": {
+			src:     "\ncontext.data.spec.containers[0].img == \"\"",
+			unknown: "context.data.spec.containers[0].img (line 2, column 1)",
+		},
+		"field of a scalar": {
+			src:     `context.data.spec.replicas.value > 1`,
+			unknown: "context.data.spec.replicas.value",
+		},
+		"dynamic index": {
+			src: `context.data.spec.containers[context.data.spec.replicas].foo == ""`,
+		},
+		"struct filter": {
+			src:     `context: data: spec: replicaz: 3`,
+			unknown: "context.data.spec.replicaz (line 1, column 22)",
+		},
+		"with imports": {
+			src: `
+			import "strings"
+			strings.HasPrefix(context.data.spec.containers[0].imag, "nginx")`,
+			unknown: "context.data.spec.containers[0].imag",
+		},
+		"unknown data field, without a data schema": {
+			src:  `context.data.spec.replicaz > 1`,
+			data: "-",
+		},
+		"no schema": {
+			src:   `context.event.typ == "update"`,
+			event: "-",
+			data:  "-",
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			e, d := event, data
+			if tc.event == "-" {
+				e = ""
+			}
+			if tc.data == "-" {
+				d = ""
+			}
+			err := CheckReferences(tc.src, e, d)
+			if tc.unknown == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.unknown)
+			}
+		})
+	}
+}
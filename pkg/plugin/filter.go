@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+	"github.com/kubevela/kube-trigger/pkg/filter"
+)
+
+// Filter is a Plugin run as a filter. The Plugin is got for every event, so
+// that changes of its module are seen, like those of actions.
+type Filter struct {
+	cli  client.Client
+	spec v1alpha1.Plugin
+}
+
+var _ filter.Filter = Filter{}
+
+// NewFilter loads the module of p as a filter.
+func NewFilter(ctx context.Context, cli client.Client, p v1alpha1.Plugin) (Filter, error) {
+	ret, err := New(ctx, cli, p, FunctionFilter)
+	if err != nil {
+		return Filter{}, err
+	}
+	ret.Release()
+	return Filter{cli: cli, spec: p}, nil
+}
+
+// Eval implements filter.Filter.
+func (f Filter) Eval(ctx context.Context, contextData map[string]interface{}) (filter.Result, error) {
+	p, err := New(ctx, f.cli, f.spec, FunctionFilter)
+	if err != nil {
+		return filter.Result{}, err
+	}
+	defer p.Release()
+	return p.filter(ctx, contextData)
+}
+
+// filter runs the plugin as a filter, with the context of an event.
+func (p *Plugin) filter(ctx context.Context, contextData map[string]interface{}) (filter.Result, error) {
+	out, err := p.call(ctx, contextData)
+	if err != nil {
+		return filter.Result{}, err
+	}
+	if err := failed(out); err != nil {
+		return filter.Result{}, err
+	}
+	var kept bool
+	if json.Unmarshal(out, &kept) == nil {
+		return filter.Result{Kept: kept}, nil
+	}
+	res := struct {
+		Kept   bool                   `json:"kept"`
+		Output map[string]interface{} `json:"output"`
+	}{}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return filter.Result{}, fmt.Errorf("invalid result of plugin filter %q: %w", out, err)
+	}
+	return filter.Result{Kept: res.Kept, Output: res.Output}, nil
+}
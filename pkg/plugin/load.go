@@ -0,0 +1,275 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+// maxModuleSize is the max size of modules and manifests pulled from
+// registries.
+const maxModuleSize = 64 << 20
+
+// Media types of the layers of modules, used by different tools that push
+// them.
+var wasmMediaTypes = map[string]bool{
+	"application/wasm":                                  true,
+	"application/vnd.wasm.content.layer.v1+wasm":        true,
+	"application/vnd.module.wasm.content.layer.v1+wasm": true,
+}
+
+// manifestMediaTypes are the manifests accepted from registries.
+const manifestMediaTypes = "application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+
+// httpClient pulls images.
+var httpClient = http.DefaultClient
+
+// load gets the digest of the module of p, and the module unless its digest
+// is known. The digest of a module in a ConfigMap is its UID and
+// resourceVersion, and of an image the digest of its manifest.
+func load(ctx context.Context, cli client.Client, p v1alpha1.Plugin, known string) ([]byte, string, error) {
+	if p.Image != "" {
+		bin, digest, err := pull(ctx, p.Image, known)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot pull plugin %s: %w", p.Image, err)
+		}
+		return bin, digest, nil
+	}
+	key := p.Key
+	if key == "" {
+		key = defaultKey
+	}
+	cm := &corev1.ConfigMap{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: p.ConfigMap.Namespace, Name: p.ConfigMap.Name}, cm); err != nil {
+		return nil, "", fmt.Errorf("cannot get plugin ConfigMap %s/%s: %w", p.ConfigMap.Namespace, p.ConfigMap.Name, err)
+	}
+	digest := fmt.Sprintf("configmap:%s/%s", cm.UID, cm.ResourceVersion)
+	if digest == known {
+		return nil, digest, nil
+	}
+	bin, ok := cm.BinaryData[key]
+	if !ok {
+		return nil, "", fmt.Errorf("plugin ConfigMap %s/%s has no binaryData %s", p.ConfigMap.Namespace, p.ConfigMap.Name, key)
+	}
+	return bin, digest, nil
+}
+
+// immutable tells whether the module of p never changes, i.e. it is an image
+// referenced by digest.
+func immutable(p v1alpha1.Plugin) bool {
+	return strings.Contains(p.Image, "@sha256:")
+}
+
+// reference is a parsed image reference.
+type reference struct {
+	registry   string
+	repository string
+	// ref is a tag or a digest.
+	ref string
+}
+
+func parseReference(image string) (reference, error) {
+	r := reference{registry: "registry-1.docker.io", ref: "latest"}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, r.ref = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, r.ref = name[:i], name[i+1:]
+	}
+	// The first part of the name is a registry if it looks like a host.
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		r.registry, name = name[:i], name[i+1:]
+	} else if !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || r.ref == "" {
+		return r, fmt.Errorf("invalid image %q", image)
+	}
+	r.repository = name
+	return r, nil
+}
+
+// url is the URL of path in the registry of r. Registries on localhost are
+// pulled with plain HTTP.
+func (r reference) url(path string) string {
+	scheme := "https"
+	host := r.registry
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, r.registry, r.repository, path)
+}
+
+// pull pulls the module layer of image anonymously, with the OCI
+// distribution API, unless the digest of its manifest is known.
+func pull(ctx context.Context, image, known string) ([]byte, string, error) {
+	r, err := parseReference(image)
+	if err != nil {
+		return nil, "", err
+	}
+	puller := &puller{}
+	b, err := puller.get(ctx, r.url("manifests/"+r.ref), manifestMediaTypes)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(b)
+	manifestDigest := "sha256:" + hex.EncodeToString(sum[:])
+	if manifestDigest == known {
+		return nil, manifestDigest, nil
+	}
+	manifest := struct {
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+			Digest    string `json:"digest"`
+		} `json:"layers"`
+	}{}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest: %w", err)
+	}
+	digest := ""
+	for _, l := range manifest.Layers {
+		if wasmMediaTypes[l.MediaType] || len(manifest.Layers) == 1 {
+			digest = l.Digest
+			break
+		}
+	}
+	if digest == "" {
+		return nil, "", fmt.Errorf("no WebAssembly layer in the manifest")
+	}
+	bin, err := puller.get(ctx, r.url("blobs/"+digest), "")
+	if err != nil {
+		return nil, "", err
+	}
+	sum = sha256.Sum256(bin)
+	if digest != "sha256:"+hex.EncodeToString(sum[:]) {
+		return nil, "", fmt.Errorf("digest of the layer does not match %s", digest)
+	}
+	return bin, manifestDigest, nil
+}
+
+// puller gets things from a registry, with an anonymous token if the
+// registry needs one.
+type puller struct {
+	token string
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+func (p *puller) get(ctx context.Context, url, accept string) ([]byte, error) {
+	resp, err := p.do(ctx, url, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && p.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+		if err := p.authenticate(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = p.do(ctx, url, accept); err != nil {
+			return nil, err
+		}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot get %s: %s", url, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxModuleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxModuleSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxModuleSize)
+	}
+	return b, nil
+}
+
+func (p *puller) do(ctx context.Context, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	return httpClient.Do(req)
+}
+
+// authenticate gets an anonymous token for a Bearer challenge.
+func (p *puller) authenticate(ctx context.Context, challenge string) error {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return fmt.Errorf("registry needs unsupported authentication %q", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return err
+	}
+	q := req.URL.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot get a token from %s: %s", params["realm"], resp.Status)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	p.token = token.Token
+	if p.token == "" {
+		p.token = token.AccessToken
+	}
+	if p.token == "" {
+		return fmt.Errorf("no token from %s", params["realm"])
+	}
+	return nil
+}
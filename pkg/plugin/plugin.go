@@ -0,0 +1,373 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugin runs WebAssembly modules as filters and actions, in a
+// sandbox.
+//
+// A module is a WASI reactor, or has no imports at all. It exports its memory
+// as memory, and these functions:
+//
+//	allocate(size i32) i32
+//	filter(ptr i32, len i32) i64 // for filters
+//	action(ptr i32, len i32) i64 // for actions
+//
+// allocate returns a buffer of size bytes in the memory, where the input is
+// written. The input is the JSON {"context": ..., "config": ...}, with the
+// context of the event and the config of the plugin. filter and action are
+// called with the buffer, and return where their result is in the memory, as
+// ptr<<32 | len.
+//
+// The result of filter is true, false, or {"kept": bool, "output": {...}}.
+// The output is given to the action as context.filter, like the output of
+// CUE filters. The result of action is empty, or {"error": "..."} if it
+// failed. Both can return {"error": "..."} to fail.
+//
+// Every call has a new instance of the module, without access to files,
+// the network, environment variables or the clock. The memory and time of a
+// call are limited.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+// Functions exported by modules.
+const (
+	FunctionAllocate = "allocate"
+	FunctionFilter   = "filter"
+	FunctionAction   = "action"
+)
+
+const (
+	defaultKey       = "plugin.wasm"
+	defaultMaxMemory = "16Mi"
+	defaultTimeout   = time.Second
+	pageSize         = 65536
+)
+
+var logger = logrus.WithField("plugin", "wasm")
+
+// Plugin is a compiled module.
+type Plugin struct {
+	runtime  wazero.Runtime
+	module   wazero.CompiledModule
+	config   json.RawMessage
+	timeout  time.Duration
+	function string
+
+	// refs counts the holders of the Plugin, the cache included. The runtime
+	// is closed when the last one releases it.
+	mu   sync.Mutex
+	refs int
+}
+
+func (p *Plugin) acquire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refs++
+}
+
+// Release releases a Plugin returned by New. Its runtime is closed once its
+// module has changed, and it is released by all its holders.
+func (p *Plugin) Release() {
+	p.mu.Lock()
+	p.refs--
+	last := p.refs == 0
+	p.mu.Unlock()
+	if last {
+		_ = p.runtime.Close(context.Background())
+	}
+}
+
+// limits are the parsed limits of a v1alpha1.Plugin.
+type limits struct {
+	pages   uint32
+	timeout time.Duration
+}
+
+// Validate validates a Plugin, without loading its module.
+func Validate(p v1alpha1.Plugin) error {
+	_, err := parse(p)
+	return err
+}
+
+func parse(p v1alpha1.Plugin) (*limits, error) {
+	if (p.ConfigMap == nil) == (p.Image == "") {
+		return nil, fmt.Errorf("plugin needs exactly one of configMap and image")
+	}
+	if p.ConfigMap != nil && (p.ConfigMap.Name == "" || p.ConfigMap.Namespace == "") {
+		return nil, fmt.Errorf("plugin needs the name and namespace of a ConfigMap")
+	}
+	maxMemory := p.MaxMemory
+	if maxMemory == "" {
+		maxMemory = defaultMaxMemory
+	}
+	q, err := resource.ParseQuantity(maxMemory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin maxMemory %q: %w", p.MaxMemory, err)
+	}
+	pages := q.Value() / pageSize
+	if pages < 1 || pages > 65536 {
+		return nil, fmt.Errorf("plugin maxMemory must be between 64Ki and 4Gi")
+	}
+	l := &limits{pages: uint32(pages), timeout: defaultTimeout}
+	if p.Timeout != "" {
+		if l.timeout, err = time.ParseDuration(p.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid plugin timeout %q: %w", p.Timeout, err)
+		}
+		if l.timeout <= 0 {
+			return nil, fmt.Errorf("plugin timeout must be positive")
+		}
+	}
+	return l, nil
+}
+
+// recheckInterval is how often the modules of cached Plugins are checked for
+// changes.
+var recheckInterval = time.Minute
+
+// cachedPlugin is the Plugin compiled from the module with digest, for a
+// spec and function.
+type cachedPlugin struct {
+	plugin  *Plugin
+	digest  string
+	checked time.Time
+	// loading is closed when the module being loaded is, nil if none is.
+	loading chan struct{}
+}
+
+// plugins caches Plugins by their spec and function, so that modules are not
+// loaded again for every action.
+var (
+	pluginsMu sync.Mutex
+	plugins   = map[string]*cachedPlugin{}
+)
+
+// New loads the module of p, and compiles it to call function, which is
+// FunctionFilter or FunctionAction. Modules are cached, and loaded again
+// when their digest changes. Modules are loaded once at a time, and the
+// cached Plugin is used while they are. The Plugin must be released when it
+// is no longer used.
+func New(ctx context.Context, cli client.Client, p v1alpha1.Plugin, function string) (*Plugin, error) {
+	l, err := parse(p)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	key := function + "/" + string(spec)
+	pluginsMu.Lock()
+	var cached *cachedPlugin
+	for {
+		cached = plugins[key]
+		if cached == nil {
+			cached = &cachedPlugin{}
+			plugins[key] = cached
+		}
+		if cached.plugin != nil && (cached.loading != nil || immutable(p) || time.Since(cached.checked) < recheckInterval) {
+			cached.plugin.acquire()
+			pluginsMu.Unlock()
+			return cached.plugin, nil
+		}
+		if cached.loading == nil {
+			break
+		}
+		loading := cached.loading
+		pluginsMu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		pluginsMu.Lock()
+	}
+	cached.loading = make(chan struct{})
+	known := cached.digest
+	pluginsMu.Unlock()
+
+	var ret *Plugin
+	bin, digest, err := load(ctx, cli, p, known)
+	if err == nil && digest != known {
+		ret, err = compile(ctx, bin, *l, function)
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	close(cached.loading)
+	cached.loading = nil
+	if err != nil {
+		if cached.plugin == nil {
+			delete(plugins, key)
+			return nil, err
+		}
+		logger.Errorf("cannot check plugin for changes, using the cached one: %s", err)
+		cached.checked = time.Now()
+		cached.plugin.acquire()
+		return cached.plugin, nil
+	}
+	cached.checked = time.Now()
+	if ret != nil {
+		if p.Config != nil {
+			ret.config = p.Config.Raw
+		}
+		// Held by the cache until the module changes.
+		ret.acquire()
+		if cached.plugin != nil {
+			cached.plugin.Release()
+		}
+		cached.plugin, cached.digest = ret, digest
+	}
+	cached.plugin.acquire()
+	return cached.plugin, nil
+}
+
+// compile compiles bin in a runtime of its own, limited by l, and checks that
+// it exports function.
+func compile(ctx context.Context, bin []byte, l limits, function string) (*Plugin, error) {
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(l.pages).
+		WithCloseOnContextDone(true))
+	// WASI without any files, environment or clock, for modules written in
+	// languages that need it.
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	m, err := r.CompileModule(ctx, bin)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("invalid plugin module: %w", err)
+	}
+	exports := m.ExportedFunctions()
+	for _, name := range []string{FunctionAllocate, function} {
+		if _, ok := exports[name]; !ok {
+			_ = r.Close(ctx)
+			return nil, fmt.Errorf("plugin module does not export %s", name)
+		}
+	}
+	if _, ok := m.ExportedMemories()["memory"]; !ok {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("plugin module does not export memory")
+	}
+	return &Plugin{runtime: r, module: m, timeout: l.timeout, function: function}, nil
+}
+
+// call calls the function of the plugin with the context of an event, and
+// returns its result.
+func (p *Plugin) call(ctx context.Context, contextData map[string]interface{}) ([]byte, error) {
+	input, err := json.Marshal(map[string]interface{}{
+		"context": contextData,
+		"config":  p.config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	out, err := p.run(ctx, input)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("plugin timed out after %s", p.timeout)
+	}
+	return out, err
+}
+
+func (p *Plugin) run(ctx context.Context, input []byte) ([]byte, error) {
+	// Names of instances are empty, so that many can run at the same time.
+	m, err := p.runtime.InstantiateModule(ctx, p.module, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithStdout(logWriter{}).
+		WithStderr(logWriter{}))
+	if err != nil {
+		return nil, fmt.Errorf("cannot instantiate plugin module: %w", err)
+	}
+	defer func() { _ = m.Close(ctx) }()
+
+	res, err := m.ExportedFunction(FunctionAllocate).Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("plugin failed to allocate memory: %w", err)
+	}
+	ptr := uint32(res[0])
+	if !m.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("plugin allocated memory out of range")
+	}
+	res, err = m.ExportedFunction(p.function).Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("plugin failed: %w", err)
+	}
+	return read(m.Memory(), res[0])
+}
+
+// read reads the result at ptr<<32 | len in mem. It is copied, because mem is
+// closed with its module.
+func read(mem api.Memory, packed uint64) ([]byte, error) {
+	ptr, size := uint32(packed>>32), uint32(packed)
+	if size == 0 {
+		return nil, nil
+	}
+	b, ok := mem.Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("plugin result out of range")
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// failure is a result failing a call.
+type failure struct {
+	Error string `json:"error"`
+}
+
+// failed gets the error in out, if any.
+func failed(out []byte) error {
+	f := failure{}
+	if json.Unmarshal(out, &f) == nil && f.Error != "" {
+		return fmt.Errorf("plugin failed: %s", f.Error)
+	}
+	return nil
+}
+
+// logWriter logs what modules write to stdout and stderr.
+type logWriter struct{}
+
+func (logWriter) Write(b []byte) (int, error) {
+	logger.Info(strings.TrimRight(string(b), "\n"))
+	return len(b), nil
+}
+
+// Run runs the plugin as an action, with the context of an event.
+func (p *Plugin) Run(ctx context.Context, contextData map[string]interface{}) error {
+	out, err := p.call(ctx, contextData)
+	if err != nil {
+		return err
+	}
+	return failed(out)
+}
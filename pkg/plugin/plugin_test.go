@@ -0,0 +1,364 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+// Bodies of the exported function of test modules.
+var (
+	// returnData returns the data of the module, at 0.
+	returnData = func(data string) []byte {
+		return append(append([]byte{0x42}, sleb128(int64(len(data)))...), 0x0b)
+	}
+	// echo returns its input.
+	echo = []byte{0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b}
+	// spin loops forever.
+	spin = []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b}
+)
+
+// module builds a module with pages of memory, exporting allocate, and body
+// as function. allocate allocates from 1024 on, after data.
+func module(pages int, function string, body []byte, data string) []byte {
+	section := func(id byte, content ...[]byte) []byte {
+		c := vector(content...)
+		return append(append([]byte{id}, uleb128(uint64(len(c)))...), c...)
+	}
+	name := func(s string) []byte {
+		return append(uleb128(uint64(len(s))), s...)
+	}
+	code := func(instrs []byte) []byte {
+		b := append([]byte{0x00}, instrs...)
+		return append(uleb128(uint64(len(b))), b...)
+	}
+	allocate := []byte{0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b}
+	m := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	m = append(m, section(1, []byte{0x60, 1, 0x7f, 1, 0x7f}, []byte{0x60, 2, 0x7f, 0x7f, 1, 0x7e})...)
+	m = append(m, section(3, []byte{0}, []byte{1})...)
+	m = append(m, section(5, append([]byte{0x00}, uleb128(uint64(pages))...))...)
+	m = append(m, section(6, []byte{0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b})...)
+	m = append(m, section(7,
+		append(name("memory"), 0x02, 0x00),
+		append(name(FunctionAllocate), 0x00, 0x00),
+		append(name(function), 0x00, 0x01))...)
+	m = append(m, section(10, code(allocate), code(body))...)
+	m = append(m, section(11, append([]byte{0x00, 0x41, 0x00, 0x0b}, name(data)...))...)
+	return m
+}
+
+func vector(items ...[]byte) []byte {
+	b := uleb128(uint64(len(items)))
+	for _, i := range items {
+		b = append(b, i...)
+	}
+	return b
+}
+
+func uleb128(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func sleb128(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func compileModule(t *testing.T, bin []byte, p v1alpha1.Plugin, function string) (*Plugin, error) {
+	p.Image = "test"
+	l, err := parse(p)
+	require.NoError(t, err)
+	ret, err := compile(context.Background(), bin, *l, function)
+	if ret != nil && p.Config != nil {
+		ret.config = p.Config.Raw
+	}
+	return ret, err
+}
+
+func TestFilter(t *testing.T) {
+	cases := map[string]struct {
+		result string
+		kept   bool
+		output map[string]interface{}
+		err    string
+	}{
+		"kept":        {result: "true", kept: true},
+		"dropped":     {result: "false"},
+		"with output": {result: `{"kept":true,"output":{"app":"web"}}`, kept: true, output: map[string]interface{}{"app": "web"}},
+		"failed":      {result: `{"error":"boom"}`, err: "plugin failed: boom"},
+		"invalid":     {result: `"yes"`, err: "invalid result"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := compileModule(t, module(1, FunctionFilter, returnData(c.result), c.result), v1alpha1.Plugin{}, FunctionFilter)
+			require.NoError(t, err)
+			res, err := p.filter(context.Background(), map[string]interface{}{"sourceType": "test"})
+			if c.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.kept, res.Kept)
+			assert.Equal(t, c.output, res.Output)
+		})
+	}
+}
+
+func TestInput(t *testing.T) {
+	p, err := compileModule(t, module(1, FunctionFilter, echo, ""), v1alpha1.Plugin{
+		Config: &runtime.RawExtension{Raw: []byte(`{"threshold":3}`)},
+	}, FunctionFilter)
+	require.NoError(t, err)
+	out, err := p.call(context.Background(), map[string]interface{}{"data": map[string]interface{}{"name": "web"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"context":{"data":{"name":"web"}},"config":{"threshold":3}}`, string(out))
+}
+
+func TestRun(t *testing.T) {
+	p, err := compileModule(t, module(1, FunctionAction, returnData(""), ""), v1alpha1.Plugin{}, FunctionAction)
+	require.NoError(t, err)
+	assert.NoError(t, p.Run(context.Background(), nil))
+
+	result := `{"error":"cannot notify"}`
+	p, err = compileModule(t, module(1, FunctionAction, returnData(result), result), v1alpha1.Plugin{}, FunctionAction)
+	require.NoError(t, err)
+	assert.EqualError(t, p.Run(context.Background(), nil), "plugin failed: cannot notify")
+}
+
+func TestLimits(t *testing.T) {
+	p, err := compileModule(t, module(1, FunctionFilter, spin, ""), v1alpha1.Plugin{Timeout: "50ms"}, FunctionFilter)
+	require.NoError(t, err)
+	_, err = p.call(context.Background(), nil)
+	assert.EqualError(t, err, "plugin timed out after 50ms")
+
+	p, err = compileModule(t, module(32, FunctionFilter, echo, ""), v1alpha1.Plugin{MaxMemory: "1Mi"}, FunctionFilter)
+	if err == nil {
+		_, err = p.call(context.Background(), nil)
+	}
+	assert.Error(t, err)
+
+	_, err = compileModule(t, module(1, FunctionFilter, echo, ""), v1alpha1.Plugin{}, FunctionAction)
+	assert.EqualError(t, err, "plugin module does not export action")
+}
+
+func TestValidate(t *testing.T) {
+	cm := &v1alpha1.ConfigMapReference{Namespace: "default", Name: "plugin"}
+	assert.NoError(t, Validate(v1alpha1.Plugin{ConfigMap: cm}))
+	assert.NoError(t, Validate(v1alpha1.Plugin{Image: "ghcr.io/org/filter:v1", MaxMemory: "64Mi", Timeout: "100ms"}))
+	assert.Error(t, Validate(v1alpha1.Plugin{}))
+	assert.Error(t, Validate(v1alpha1.Plugin{ConfigMap: cm, Image: "ghcr.io/org/filter:v1"}))
+	assert.Error(t, Validate(v1alpha1.Plugin{ConfigMap: &v1alpha1.ConfigMapReference{Name: "plugin"}}))
+	assert.Error(t, Validate(v1alpha1.Plugin{ConfigMap: cm, MaxMemory: "1Ki"}))
+	assert.Error(t, Validate(v1alpha1.Plugin{ConfigMap: cm, Timeout: "soon"}))
+}
+
+func TestNewFromConfigMap(t *testing.T) {
+	bin := module(1, FunctionFilter, returnData("true"), "true")
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "plugin"},
+		BinaryData: map[string][]byte{"filter.wasm": bin},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm).Build()
+	spec := v1alpha1.Plugin{ConfigMap: &v1alpha1.ConfigMapReference{Namespace: "default", Name: "plugin"}, Key: "filter.wasm"}
+
+	f, err := NewFilter(context.Background(), cli, spec)
+	require.NoError(t, err)
+	res, err := f.Eval(context.Background(), nil)
+	require.NoError(t, err)
+	assert.True(t, res.Kept)
+
+	// Loaded once.
+	held, err := New(context.Background(), nil, spec, FunctionFilter)
+	require.NoError(t, err)
+	again, err := New(context.Background(), nil, spec, FunctionFilter)
+	require.NoError(t, err)
+	assert.Same(t, held, again)
+	again.Release()
+
+	// Loaded again when the ConfigMap changes, under the live filter.
+	recheckInterval = 0
+	defer func() { recheckInterval = time.Minute }()
+	cm.BinaryData["filter.wasm"] = module(1, FunctionFilter, returnData("false"), "false")
+	require.NoError(t, cli.Update(context.Background(), cm))
+	res, err = f.Eval(context.Background(), nil)
+	require.NoError(t, err)
+	assert.False(t, res.Kept)
+
+	// The old module runs until it is released.
+	res, err = held.filter(context.Background(), nil)
+	require.NoError(t, err)
+	assert.True(t, res.Kept)
+	held.Release()
+	_, err = held.filter(context.Background(), nil)
+	assert.Error(t, err)
+
+	spec.Key = "missing.wasm"
+	_, err = New(context.Background(), cli, spec, FunctionFilter)
+	assert.ErrorContains(t, err, "has no binaryData missing.wasm")
+}
+
+func TestPull(t *testing.T) {
+	bin := module(1, FunctionFilter, returnData("true"), "true")
+	sum := sha256.Sum256(bin)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "repository:org/filter:pull", r.URL.Query().Get("scope"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "anonymous"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:org/filter:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/org/filter/manifests/v1":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"layers": []map[string]string{
+					{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "sha256:0"},
+					{"mediaType": "application/vnd.wasm.content.layer.v1+wasm", "digest": digest},
+				},
+			})
+		case "/v2/org/filter/blobs/" + digest:
+			_, _ = w.Write(bin)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	got, manifestDigest, err := pull(context.Background(), host+"/org/filter:v1", "")
+	require.NoError(t, err)
+	assert.Equal(t, bin, got)
+
+	// The module is not pulled if the manifest is known.
+	got, again, err := pull(context.Background(), host+"/org/filter:v1", manifestDigest)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.Equal(t, manifestDigest, again)
+
+	_, _, err = pull(context.Background(), host+"/org/filter:v2", "")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestParseReference(t *testing.T) {
+	cases := map[string]reference{
+		"ghcr.io/org/filter:v1":      {registry: "ghcr.io", repository: "org/filter", ref: "v1"},
+		"localhost:5000/filter":      {registry: "localhost:5000", repository: "filter", ref: "latest"},
+		"org/filter@sha256:abc":      {registry: "registry-1.docker.io", repository: "org/filter", ref: "sha256:abc"},
+		"filter":                     {registry: "registry-1.docker.io", repository: "library/filter", ref: "latest"},
+		"localhost/org/filter:1.0.0": {registry: "localhost", repository: "org/filter", ref: "1.0.0"},
+	}
+	for image, want := range cases {
+		got, err := parseReference(image)
+		assert.NoError(t, err, image)
+		assert.Equal(t, want, got, image)
+	}
+	_, err := parseReference("ghcr.io/org/filter:")
+	assert.Error(t, err)
+}
+
+func TestURL(t *testing.T) {
+	cases := map[string]string{
+		"localhost:5000":        "http://localhost:5000/v2/filter/manifests/v1",
+		"127.0.0.1":             "http://127.0.0.1/v2/filter/manifests/v1",
+		"[::1]:5000":            "http://[::1]:5000/v2/filter/manifests/v1",
+		"localhost.example.com": "https://localhost.example.com/v2/filter/manifests/v1",
+		"127.0.0.1.nip.io:5000": "https://127.0.0.1.nip.io:5000/v2/filter/manifests/v1",
+	}
+	for registry, want := range cases {
+		r := reference{registry: registry, repository: "filter", ref: "v1"}
+		assert.Equal(t, want, r.url("manifests/v1"), registry)
+	}
+}
+
+func TestNewLoadsOnce(t *testing.T) {
+	bin := module(1, FunctionFilter, returnData("true"), "true")
+	sum := sha256.Sum256(bin)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	var manifests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/org/once/manifests/v1":
+			manifests.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"layers": []map[string]string{{"mediaType": "application/vnd.wasm.content.layer.v1+wasm", "digest": digest}},
+			})
+		case "/v2/org/once/blobs/" + digest:
+			_, _ = w.Write(bin)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	spec := v1alpha1.Plugin{Image: strings.TrimPrefix(srv.URL, "http://") + "/org/once:v1"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := New(context.Background(), nil, spec, FunctionFilter)
+			if assert.NoError(t, err) {
+				p.Release()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), manifests.Load())
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/httpserver"
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+	"github.com/kubevela/kube-trigger/pkg/util/client"
+)
+
+func init() {
+	logger = logrus.WithField("source", admissionWebhookType)
+}
+
+var (
+	logger               *logrus.Entry
+	admissionWebhookType = "admission-webhook"
+)
+
+// Event is the brief event passed to filters and actions.
+type Event struct {
+	Operation   string                      `json:"operation"`
+	Kind        metav1.GroupVersionKind     `json:"kind"`
+	Resource    metav1.GroupVersionResource `json:"resource"`
+	SubResource string                      `json:"subResource,omitempty"`
+	Namespace   string                      `json:"namespace,omitempty"`
+	Name        string                      `json:"name,omitempty"`
+	UserInfo    authenticationv1.UserInfo   `json:"userInfo"`
+}
+
+// Data is the detailed admission request passed to filters and actions.
+type Data struct {
+	Operation string                    `json:"operation"`
+	UserInfo  authenticationv1.UserInfo `json:"userInfo"`
+	Object    map[string]interface{}    `json:"object,omitempty"`
+	OldObject map[string]interface{}    `json:"oldObject,omitempty"`
+}
+
+// AdmissionWebhook serves admission webhook requests, and fires events before
+// changes are persisted.
+type AdmissionWebhook struct {
+	config Config
+	eh     eventhandler.EventHandler
+}
+
+var _ types.Source = &AdmissionWebhook{}
+
+// New creates a new AdmissionWebhook.
+func (w *AdmissionWebhook) New() types.Source {
+	return &AdmissionWebhook{}
+}
+
+// Init initializes the AdmissionWebhook.
+func (w *AdmissionWebhook) Init(properties *runtime.RawExtension, eh eventhandler.EventHandler) error {
+	b, err := properties.MarshalJSON()
+	if err != nil {
+		return pkgerrors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	err = json.Unmarshal(b, &w.config)
+	if err != nil {
+		return pkgerrors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	w.config.Config = w.config.Config.WithDefaults(defaultPort, defaultPath)
+	if err := w.config.Validate(); err != nil {
+		return pkgerrors.Wrapf(err, "invalid properties for %s", w.Type())
+	}
+	w.eh = eh
+	return nil
+}
+
+// Run starts serving admission requests.
+func (w *AdmissionWebhook) Run(ctx context.Context) error {
+	if w.config.WebhookConfiguration != nil {
+		cli, err := client.GetClient()
+		if err != nil {
+			return err
+		}
+		if err := w.config.applyWebhookConfiguration(ctx, cli); err != nil {
+			return pkgerrors.Wrapf(err, "cannot apply webhook configuration %s", w.config.WebhookConfiguration.Name)
+		}
+		logger.Infof("applied %s webhook configuration %s", w.config.WebhookConfiguration.Type, w.config.WebhookConfiguration.Name)
+	}
+	s, err := httpserver.Register(w.config.Config, w)
+	if err != nil {
+		return err
+	}
+	s.Start(ctx)
+	logger.Infof("serving admission requests on :%d%s", w.config.Port, w.config.Path)
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (w *AdmissionWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	review := &admissionv1.AdmissionReview{}
+	if err := httpserver.DecodeJSON(rw, r, review); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(rw, "no request in AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	review.Response = w.review(review.Request)
+	review.Request = nil
+	httpserver.WriteJSON(rw, http.StatusOK, review)
+}
+
+// review fires an event for req and decides whether req is allowed.
+func (w *AdmissionWebhook) review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	resp := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+	if !w.config.matches(req) {
+		return resp
+	}
+	// Actions may have side effects, so they are not run for dry-run requests.
+	if req.DryRun != nil && *req.DryRun {
+		return resp
+	}
+
+	e := Event{
+		Operation:   string(req.Operation),
+		Kind:        req.Kind,
+		Resource:    req.Resource,
+		SubResource: req.SubResource,
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		UserInfo:    req.UserInfo,
+	}
+	data := Data{
+		Operation: string(req.Operation),
+		UserInfo:  req.UserInfo,
+	}
+	var err error
+	if data.Object, err = decodeObject(req.Object); err != nil {
+		logger.Errorf("cannot decode object of request %s: %s", req.UID, err)
+	}
+	if data.OldObject, err = decodeObject(req.OldObject); err != nil {
+		logger.Errorf("cannot decode oldObject of request %s: %s", req.UID, err)
+	}
+
+	logger.Infof("%s %s %s/%s requested by %s, calling event handler", req.Operation, req.Kind.Kind, req.Namespace, req.Name, req.UserInfo.Username)
+	err = w.eh(w.Type(), e, data)
+	if err != nil && !errors.Is(err, eventhandler.ErrFilteredOut) {
+		logger.Infof("calling event handler failed: %s", err)
+	}
+	// Whether the request is denied only depends on the filter, not on
+	// whether the action could be dispatched.
+	kept := err == nil || errors.Is(err, eventhandler.ErrActionFailed)
+	if kept && w.config.Deny != nil {
+		resp.Allowed = false
+		resp.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: w.config.Deny.Message,
+		}
+	}
+	return resp
+}
+
+func decodeObject(raw runtime.RawExtension) (map[string]interface{}, error) {
+	if len(raw.Raw) == 0 {
+		return nil, nil
+	}
+	obj := make(map[string]interface{})
+	err := json.Unmarshal(raw.Raw, &obj)
+	return obj, err
+}
+
+// Type returns the type of the AdmissionWebhook.
+func (w *AdmissionWebhook) Type() string {
+	return admissionWebhookType
+}
+
+// Singleton .
+func (w *AdmissionWebhook) Singleton() bool {
+	return false
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionwebhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/httpserver"
+)
+
+func newReview(op admissionv1.Operation, resource string, dryRun bool) *admissionv1.AdmissionReview {
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "uid",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			Resource:  metav1.GroupVersionResource{Version: "v1", Resource: resource},
+			Namespace: "default",
+			Name:      "cm",
+			Operation: op,
+			UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+			Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"cm"},"data":{"a":"2"}}`)},
+			OldObject: runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"cm"},"data":{"a":"1"}}`)},
+			DryRun:    ptr.To(dryRun),
+		},
+	}
+}
+
+func post(t *testing.T, h http.Handler, review *admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
+	b, err := json.Marshal(review)
+	assert.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, defaultPath, bytes.NewReader(b)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	got := &admissionv1.AdmissionReview{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), got))
+	assert.Equal(t, "AdmissionReview", got.Kind)
+	return got.Response
+}
+
+func TestAdmissionWebhook_ServeHTTP(t *testing.T) {
+	a := assert.New(t)
+	var events []Event
+	var data []Data
+	handlerErr := error(nil)
+	w := (&AdmissionWebhook{}).New().(*AdmissionWebhook)
+	err := w.Init(&runtime.RawExtension{Raw: []byte(`{"certFile":"tls.crt","keyFile":"tls.key","rules":[{"operations":["UPDATE"],"resources":["configmaps"]}],"deny":{"message":"no way"}}`)},
+		func(_ string, event interface{}, d interface{}) error {
+			events = append(events, event.(Event))
+			data = append(data, d.(Data))
+			return handlerErr
+		})
+	a.NoError(err)
+	a.Equal(defaultPort, w.config.Port)
+
+	// Passed the filter, denied.
+	resp := post(t, w, newReview(admissionv1.Update, "configmaps", false))
+	a.False(resp.Allowed)
+	a.Equal("no way", resp.Result.Message)
+	a.Equal("uid", string(resp.UID))
+	a.Len(events, 1)
+	a.Equal("alice", events[0].UserInfo.Username)
+	a.Equal("2", data[0].Object["data"].(map[string]interface{})["a"])
+	a.Equal("1", data[0].OldObject["data"].(map[string]interface{})["a"])
+
+	// Passed the filter, but the action failed, still denied.
+	handlerErr = fmt.Errorf("%w: queue is full", eventhandler.ErrActionFailed)
+	resp = post(t, w, newReview(admissionv1.Update, "configmaps", false))
+	a.False(resp.Allowed)
+
+	// Filter failed, allowed.
+	handlerErr = errors.New("filter failed")
+	resp = post(t, w, newReview(admissionv1.Update, "configmaps", false))
+	a.True(resp.Allowed)
+
+	// Filtered out, allowed.
+	handlerErr = eventhandler.ErrFilteredOut
+	resp = post(t, w, newReview(admissionv1.Update, "configmaps", false))
+	a.True(resp.Allowed)
+	a.Len(events, 4)
+
+	// Not selected by rules, or dry-run, no events.
+	resp = post(t, w, newReview(admissionv1.Create, "configmaps", false))
+	a.True(resp.Allowed)
+	resp = post(t, w, newReview(admissionv1.Update, "secrets", false))
+	a.True(resp.Allowed)
+	resp = post(t, w, newReview(admissionv1.Update, "configmaps", true))
+	a.True(resp.Allowed)
+	a.Len(events, 4)
+
+	// Bad requests.
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, defaultPath, bytes.NewReader([]byte(`{}`))))
+	a.Equal(http.StatusBadRequest, rec.Code)
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, defaultPath, nil))
+	a.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func TestApplyWebhookConfiguration(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	c := &Config{
+		Rules: []Rule{{Operations: []string{"DELETE"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+		WebhookConfiguration: &WebhookConfiguration{
+			Name:    "deploy-guard",
+			Service: &ServiceReference{Namespace: "vela-system", Name: "kube-trigger"},
+		},
+	}
+	c.Config = c.Config.WithDefaults(defaultPort, defaultPath)
+	c.CertFile, c.KeyFile = "tls.crt", "tls.key"
+	a.NoError(c.Validate())
+	a.NoError(c.applyWebhookConfiguration(ctx, cli))
+
+	got := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	a.NoError(cli.Get(ctx, client.ObjectKey{Name: "deploy-guard"}, got))
+	a.Len(got.Webhooks, 1)
+	a.Equal("deploy-guard.kube-trigger.oam.dev", got.Webhooks[0].Name)
+	a.Equal(defaultPath, *got.Webhooks[0].ClientConfig.Service.Path)
+	a.Equal([]string{"*"}, got.Webhooks[0].Rules[0].APIVersions)
+
+	// Updated in place.
+	c.Rules[0].Operations = []string{"DELETE", "UPDATE"}
+	a.NoError(c.applyWebhookConfiguration(ctx, cli))
+	a.NoError(cli.Get(ctx, client.ObjectKey{Name: "deploy-guard"}, got))
+	a.Len(got.Webhooks[0].Rules[0].Operations, 2)
+
+	// Mutating
+	c.WebhookConfiguration.Type = WebhookTypeMutating
+	a.NoError(c.applyWebhookConfiguration(ctx, cli))
+	a.NoError(cli.Get(ctx, client.ObjectKey{Name: "deploy-guard"}, &admissionregistrationv1.MutatingWebhookConfiguration{}))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	rules := []Rule{{Resources: []string{"pods"}}}
+	tls := httpserver.Config{CertFile: "tls.crt", KeyFile: "tls.key"}
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "no_webhook_configuration", config: Config{Config: tls}},
+		{name: "no_tls", config: Config{}, wantErr: true},
+		{name: "url", config: Config{Config: tls, Rules: rules, WebhookConfiguration: &WebhookConfiguration{Name: "a", URL: "https://example.com"}}},
+		{name: "no_name", config: Config{Config: tls, Rules: rules, WebhookConfiguration: &WebhookConfiguration{URL: "https://example.com"}}, wantErr: true},
+		{name: "no_rules", config: Config{Config: tls, WebhookConfiguration: &WebhookConfiguration{Name: "a", URL: "https://example.com"}}, wantErr: true},
+		{name: "no_client", config: Config{Config: tls, Rules: rules, WebhookConfiguration: &WebhookConfiguration{Name: "a"}}, wantErr: true},
+		{name: "bad_type", config: Config{Config: tls, Rules: rules, WebhookConfiguration: &WebhookConfiguration{Name: "a", URL: "u", Type: "other"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionwebhook
+
+import (
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/httpserver"
+)
+
+const (
+	defaultPort = 9443
+	defaultPath = "/admission"
+
+	// WebhookTypeValidating generates a ValidatingWebhookConfiguration.
+	WebhookTypeValidating = "validating"
+	// WebhookTypeMutating generates a MutatingWebhookConfiguration. The
+	// webhook never patches objects.
+	WebhookTypeMutating = "mutating"
+)
+
+// Config is the config for AdmissionWebhook.
+type Config struct {
+	httpserver.Config `json:",inline"`
+	// Rules select the requests to fire events for. All requests are selected
+	// if empty. They are also used in the generated webhook configuration.
+	Rules []Rule `json:"rules,omitempty"`
+	// NamespaceSelector is used in the generated webhook configuration.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Deny, if set, denies requests whose events pass the filter.
+	Deny *Deny `json:"deny,omitempty"`
+	// WebhookConfiguration, if set, is generated and applied on start.
+	WebhookConfiguration *WebhookConfiguration `json:"webhookConfiguration,omitempty"`
+}
+
+// Rule is like an admissionregistration/v1 RuleWithOperations. "*" matches
+// everything.
+type Rule struct {
+	Operations  []string `json:"operations,omitempty"`
+	APIGroups   []string `json:"apiGroups,omitempty"`
+	APIVersions []string `json:"apiVersions,omitempty"`
+	// Resources are resources or resource/subresource, e.g. pods/exec.
+	Resources []string `json:"resources,omitempty"`
+}
+
+// Deny describes how requests are denied.
+type Deny struct {
+	// Message is returned to the user when a request is denied.
+	Message string `json:"message"`
+}
+
+// WebhookConfiguration describes the generated webhook configuration.
+type WebhookConfiguration struct {
+	// Name of the webhook configuration.
+	Name string `json:"name"`
+	// Type is validating (default) or mutating.
+	Type string `json:"type,omitempty"`
+	// Service is the Service in front of kube-trigger. Either Service or URL
+	// must be set.
+	Service *ServiceReference `json:"service,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	// CABundle is the PEM-encoded CA that signed the serving certificate.
+	CABundle string `json:"caBundle,omitempty"`
+	// FailurePolicy is Ignore (default) or Fail.
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+	// TimeoutSeconds defaults to 10.
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ServiceReference refers to a Service.
+type ServiceReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Port      *int32 `json:"port,omitempty"`
+}
+
+// Validate validates the config. The API server only calls webhooks over
+// HTTPS, so certFile and keyFile are required.
+func (c *Config) Validate() error {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("certFile and keyFile are required")
+	}
+	if wc := c.WebhookConfiguration; wc != nil {
+		if wc.Name == "" {
+			return fmt.Errorf("webhookConfiguration.name is required")
+		}
+		if wc.Type != "" && wc.Type != WebhookTypeValidating && wc.Type != WebhookTypeMutating {
+			return fmt.Errorf("unknown webhookConfiguration.type %q", wc.Type)
+		}
+		if (wc.Service == nil) == (wc.URL == "") {
+			return fmt.Errorf("either webhookConfiguration.service or webhookConfiguration.url must be set")
+		}
+		if len(c.Rules) == 0 {
+			return fmt.Errorf("rules are required to generate webhook configuration")
+		}
+	}
+	return nil
+}
+
+func matchAny(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if value == "*" || value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matches(req *admissionv1.AdmissionRequest) bool {
+	resource := req.Resource.Resource
+	if req.SubResource != "" {
+		resource += "/" + req.SubResource
+	}
+	return matchAny(r.Operations, string(req.Operation)) &&
+		matchAny(r.APIGroups, req.Resource.Group) &&
+		matchAny(r.APIVersions, req.Resource.Version) &&
+		matchAny(r.Resources, resource)
+}
+
+func (c *Config) matches(req *admissionv1.AdmissionRequest) bool {
+	if len(c.Rules) == 0 {
+		return true
+	}
+	for i := range c.Rules {
+		if c.Rules[i].matches(req) {
+			return true
+		}
+	}
+	return false
+}
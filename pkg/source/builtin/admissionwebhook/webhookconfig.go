@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionwebhook
+
+import (
+	"context"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const webhookNameSuffix = ".kube-trigger.oam.dev"
+
+func orAll(values []string) []string {
+	if len(values) == 0 {
+		return []string{"*"}
+	}
+	return values
+}
+
+func (c *Config) admissionRules() []admissionregistrationv1.RuleWithOperations {
+	var rules []admissionregistrationv1.RuleWithOperations
+	for _, r := range c.Rules {
+		var ops []admissionregistrationv1.OperationType
+		for _, op := range orAll(r.Operations) {
+			ops = append(ops, admissionregistrationv1.OperationType(op))
+		}
+		rules = append(rules, admissionregistrationv1.RuleWithOperations{
+			Operations: ops,
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   orAll(r.APIGroups),
+				APIVersions: orAll(r.APIVersions),
+				Resources:   orAll(r.Resources),
+			},
+		})
+	}
+	return rules
+}
+
+func (c *Config) clientConfig() admissionregistrationv1.WebhookClientConfig {
+	wc := c.WebhookConfiguration
+	cc := admissionregistrationv1.WebhookClientConfig{
+		CABundle: []byte(wc.CABundle),
+	}
+	if wc.URL != "" {
+		cc.URL = ptr.To(wc.URL)
+		return cc
+	}
+	cc.Service = &admissionregistrationv1.ServiceReference{
+		Namespace: wc.Service.Namespace,
+		Name:      wc.Service.Name,
+		Path:      ptr.To(c.Path),
+		Port:      wc.Service.Port,
+	}
+	return cc
+}
+
+// buildWebhookConfiguration builds the Validating or Mutating
+// WebhookConfiguration described by c.
+func (c *Config) buildWebhookConfiguration() client.Object {
+	wc := c.WebhookConfiguration
+	failurePolicy := admissionregistrationv1.Ignore
+	if wc.FailurePolicy != "" {
+		failurePolicy = admissionregistrationv1.FailurePolicyType(wc.FailurePolicy)
+	}
+	timeout := wc.TimeoutSeconds
+	if timeout == nil {
+		timeout = ptr.To[int32](10)
+	}
+	// Events are not fired for dry-run requests, so there are no side effects.
+	sideEffects := admissionregistrationv1.SideEffectClassNoneOnDryRun
+	name := wc.Name + webhookNameSuffix
+	meta := metav1.ObjectMeta{Name: wc.Name}
+
+	if wc.Type == WebhookTypeMutating {
+		return &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: meta,
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name:                    name,
+				ClientConfig:            c.clientConfig(),
+				Rules:                   c.admissionRules(),
+				FailurePolicy:           &failurePolicy,
+				NamespaceSelector:       c.NamespaceSelector,
+				SideEffects:             &sideEffects,
+				TimeoutSeconds:          timeout,
+				AdmissionReviewVersions: []string{"v1"},
+			}},
+		}
+	}
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: meta,
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name:                    name,
+			ClientConfig:            c.clientConfig(),
+			Rules:                   c.admissionRules(),
+			FailurePolicy:           &failurePolicy,
+			NamespaceSelector:       c.NamespaceSelector,
+			SideEffects:             &sideEffects,
+			TimeoutSeconds:          timeout,
+			AdmissionReviewVersions: []string{"v1"},
+		}},
+	}
+}
+
+// applyWebhookConfiguration creates or updates the generated webhook
+// configuration.
+func (c *Config) applyWebhookConfiguration(ctx context.Context, cli client.Client) error {
+	desired := c.buildWebhookConfiguration()
+	existing, _ := desired.DeepCopyObject().(client.Object)
+	err := cli.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if apierrors.IsNotFound(err) {
+		return cli.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	return cli.Update(ctx, desired)
+}
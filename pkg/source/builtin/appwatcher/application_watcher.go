@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appwatcher
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/controller"
+	rwtypes "github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+func init() {
+	logger = logrus.WithField("source", applicationWatcherType)
+}
+
+var (
+	logger                 *logrus.Entry
+	applicationWatcherType = "application-watcher"
+)
+
+const (
+	defaultCluster        = "local"
+	applicationAPIVersion = "core.oam.dev/v1beta1"
+	applicationKind       = "Application"
+)
+
+// EventType is the type of Application events.
+type EventType string
+
+// EventTypes
+const (
+	// EventTypeWorkflowStarted is fired when a new run of the workflow starts.
+	EventTypeWorkflowStarted EventType = "workflowStarted"
+	// EventTypeWorkflowStepFailed is fired for each failed step or sub-step.
+	EventTypeWorkflowStepFailed EventType = "workflowStepFailed"
+	// EventTypeWorkflowSucceeded is fired when the workflow succeeds.
+	EventTypeWorkflowSucceeded EventType = "workflowSucceeded"
+	// EventTypeWorkflowSuspended is fired when the workflow is suspended.
+	EventTypeWorkflowSuspended EventType = "workflowSuspended"
+	// EventTypeHealthChanged is fired when the Application becomes healthy or
+	// unhealthy, i.e. all its services are healthy or not.
+	EventTypeHealthChanged EventType = "healthChanged"
+	// EventTypeRevisionChanged is fired when the latest revision changes.
+	EventTypeRevisionChanged EventType = "revisionChanged"
+)
+
+// Event is the brief event passed to filters and actions. The Application is
+// passed as data.
+type Event struct {
+	Type      EventType `json:"type"`
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	// Step is the name of the failed step. Sub-steps are named parent/child.
+	Step string `json:"step,omitempty"`
+	// Phase is the phase of the step for step events, the health for
+	// healthChanged, and the phase of the workflow otherwise.
+	Phase    string `json:"phase,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	// Healthy is set for healthChanged.
+	Healthy *bool `json:"healthy,omitempty"`
+}
+
+// ApplicationWatcher watches KubeVela Applications and fires semantic events
+// computed from changes of their status.
+type ApplicationWatcher struct {
+	config Config
+	eh     eventhandler.EventHandler
+}
+
+var _ types.Source = &ApplicationWatcher{}
+
+// New creates a new ApplicationWatcher.
+func (w *ApplicationWatcher) New() types.Source {
+	return &ApplicationWatcher{}
+}
+
+// Init initializes the ApplicationWatcher.
+func (w *ApplicationWatcher) Init(properties *runtime.RawExtension, eh eventhandler.EventHandler) error {
+	b, err := properties.MarshalJSON()
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	err = json.Unmarshal(b, &w.config)
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	if err := w.config.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid properties for %s", w.Type())
+	}
+	w.eh = eh
+	return nil
+}
+
+// Run starts the ApplicationWatcher.
+func (w *ApplicationWatcher) Run(ctx context.Context) error {
+	clusterGetter, err := k8sresourcewatcher.NewMultiClustersGetter(k8sresourcewatcher.MultiClusterConfigType)
+	if err != nil {
+		return err
+	}
+	clusters := w.config.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{defaultCluster}
+	}
+	for _, cluster := range clusters {
+		cli, mapper, err := clusterGetter.GetDynamicClientAndMapper(ctx, cluster)
+		if err != nil {
+			return err
+		}
+		multiCtx := multicluster.WithCluster(ctx, cluster)
+		informer, err := controller.NewInformer(multiCtx, cli, mapper, rwtypes.Config{
+			APIVersion:     applicationAPIVersion,
+			Kind:           applicationKind,
+			Namespace:      w.config.Namespace,
+			MatchingLabels: w.config.MatchingLabels,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot watch Applications in cluster %s", cluster)
+		}
+		// Existing Applications are only the baseline, events are computed
+		// from updates.
+		//nolint:errcheck // no need to check err here
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, new interface{}) {
+				oldApp, ok1 := old.(*unstructured.Unstructured)
+				newApp, ok2 := new.(*unstructured.Unstructured)
+				if ok1 && ok2 {
+					w.update(cluster, oldApp, newApp)
+				}
+			},
+		})
+		go informer.Run(multiCtx.Done())
+		logger.Infof("watching Applications in cluster %s", cluster)
+	}
+	return nil
+}
+
+// update fires events for the status changes from old to new.
+func (w *ApplicationWatcher) update(cluster string, old, new *unstructured.Unstructured) {
+	oldStatus, err := parseStatus(old)
+	if err != nil {
+		logger.Errorf("cannot parse status of Application %s/%s: %s", old.GetNamespace(), old.GetName(), err)
+		return
+	}
+	newStatus, err := parseStatus(new)
+	if err != nil {
+		logger.Errorf("cannot parse status of Application %s/%s: %s", new.GetNamespace(), new.GetName(), err)
+		return
+	}
+	for _, e := range diff(oldStatus, newStatus) {
+		if !w.config.listens(e.Type) {
+			continue
+		}
+		e.Cluster = cluster
+		e.Namespace = new.GetNamespace()
+		e.Name = new.GetName()
+		logger.Infof("%s event of Application %s/%s/%s happened, calling event handler", e.Type, cluster, e.Namespace, e.Name)
+		if err := w.eh(w.Type(), e, new.Object); err != nil {
+			logger.Infof("calling event handler failed: %s", err)
+		}
+	}
+}
+
+// Type returns the type of the ApplicationWatcher.
+func (w *ApplicationWatcher) Type() string {
+	return applicationWatcherType
+}
+
+// Singleton .
+func (w *ApplicationWatcher) Singleton() bool {
+	return false
+}
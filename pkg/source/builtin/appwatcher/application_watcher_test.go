@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appwatcher
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func app(t *testing.T, status string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(applicationAPIVersion)
+	u.SetKind(applicationKind)
+	u.SetNamespace("default")
+	u.SetName("my-app")
+	if status != "" {
+		s := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(status), &s))
+		u.Object["status"] = s
+	}
+	return u
+}
+
+const (
+	executing = `{
+  "latestRevision": {"name": "my-app-v2"},
+  "workflow": {"appRevision": "my-app-v2", "status": "executing", "startTime": "2023-01-01T00:00:00Z",
+    "steps": [{"id": "a", "name": "deploy", "phase": "running"}]},
+  "services": [{"name": "web", "healthy": true}]
+}`
+	failed = `{
+  "latestRevision": {"name": "my-app-v2"},
+  "workflow": {"appRevision": "my-app-v2", "status": "executing", "startTime": "2023-01-01T00:00:00Z",
+    "steps": [{"id": "a", "name": "deploy", "phase": "failed", "message": "timeout",
+      "subSteps": [{"id": "b", "name": "wait", "phase": "failed", "reason": "Timeout"}]}]},
+  "services": [{"name": "web", "healthy": false, "message": "0/1 ready"}]
+}`
+	suspended = `{
+  "latestRevision": {"name": "my-app-v2"},
+  "workflow": {"appRevision": "my-app-v2", "status": "suspending", "suspend": true, "startTime": "2023-01-01T00:00:00Z",
+    "steps": [{"id": "a", "name": "deploy", "phase": "failed", "message": "timeout"}]},
+  "services": [{"name": "web", "healthy": false, "message": "0/1 ready"}]
+}`
+	succeeded = `{
+  "latestRevision": {"name": "my-app-v2"},
+  "workflow": {"appRevision": "my-app-v2", "status": "succeeded", "startTime": "2023-01-01T00:00:00Z",
+    "steps": [{"id": "a", "name": "deploy", "phase": "succeeded"}]},
+  "services": [{"name": "web", "healthy": true}]
+}`
+	restarted = `{
+  "latestRevision": {"name": "my-app-v3"},
+  "workflow": {"appRevision": "my-app-v3", "status": "succeeded", "startTime": "2023-01-02T00:00:00Z",
+    "steps": [{"id": "a", "name": "deploy", "phase": "succeeded"}]},
+  "services": [{"name": "web", "healthy": true}]
+}`
+)
+
+func TestApplicationWatcher_update(t *testing.T) {
+	var events []Event
+	w := &ApplicationWatcher{}
+	err := w.Init(&runtime.RawExtension{Raw: []byte(`{}`)}, func(_ string, e interface{}, _ interface{}) error {
+		events = append(events, e.(Event))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	types := func() []EventType {
+		var ret []EventType
+		for _, e := range events {
+			ret = append(ret, e.Type)
+		}
+		events = nil
+		return ret
+	}
+
+	tests := []struct {
+		name     string
+		old, new string
+		want     []EventType
+	}{
+		{name: "started", old: "", new: executing,
+			want: []EventType{EventTypeRevisionChanged, EventTypeHealthChanged, EventTypeWorkflowStarted}},
+		{name: "step_failed", old: executing, new: failed,
+			want: []EventType{EventTypeHealthChanged, EventTypeWorkflowStepFailed, EventTypeWorkflowStepFailed}},
+		{name: "suspended", old: failed, new: suspended,
+			want: []EventType{EventTypeWorkflowSuspended}},
+		{name: "succeeded", old: suspended, new: succeeded,
+			want: []EventType{EventTypeHealthChanged, EventTypeWorkflowSucceeded}},
+		{name: "no_change", old: succeeded, new: succeeded},
+		{name: "restarted", old: succeeded, new: restarted,
+			want: []EventType{EventTypeRevisionChanged, EventTypeWorkflowStarted, EventTypeWorkflowSucceeded}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w.update("local", app(t, tt.old), app(t, tt.new))
+			assert.Equal(t, tt.want, types())
+		})
+	}
+
+	w.update("local", app(t, executing), app(t, failed))
+	assert.Equal(t, Event{Type: EventTypeWorkflowStepFailed, Cluster: "local", Namespace: "default", Name: "my-app",
+		Step: "deploy", Phase: "failed", Message: "timeout", Revision: "my-app-v2"}, events[1])
+	assert.Equal(t, "deploy/wait", events[2].Step)
+	assert.Equal(t, "Timeout", events[2].Message)
+	assert.False(t, *events[0].Healthy)
+	assert.Equal(t, "web: 0/1 ready", events[0].Message)
+}
+
+func TestApplicationWatcher_events(t *testing.T) {
+	var events []Event
+	w := &ApplicationWatcher{}
+	err := w.Init(&runtime.RawExtension{Raw: []byte(`{"events":["workflowSucceeded"]}`)}, func(_ string, e interface{}, _ interface{}) error {
+		events = append(events, e.(Event))
+		return nil
+	})
+	assert.NoError(t, err)
+	w.update("local", app(t, suspended), app(t, succeeded))
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventTypeWorkflowSucceeded, events[0].Type)
+
+	err = w.Init(&runtime.RawExtension{Raw: []byte(`{"events":["deleted"]}`)}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appwatcher
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+var _ types.SchemaSource = &ApplicationWatcher{}
+
+// applicationSchema is the schema of Applications. Their spec and status are
+// not checked.
+const applicationSchema = `
+apiVersion: string
+kind:       string
+metadata: {...}
+spec: {...}
+status?: {...}
+`
+
+// Schema implements types.SchemaSource. The data of events is the
+// Application.
+func (w *ApplicationWatcher) Schema(_ context.Context, _ client.Client, _ *runtime.RawExtension) (*types.Schema, error) {
+	return &types.Schema{Event: types.SchemaOf(Event{}), Data: applicationSchema}, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appwatcher
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Workflow phases of KubeVela Applications.
+const (
+	workflowPhaseSucceeded  = "succeeded"
+	workflowPhaseSuspending = "suspending"
+	stepPhaseFailed         = "failed"
+)
+
+// appStatus is the part of the status of an Application that events are
+// computed from.
+type appStatus struct {
+	LatestRevision *struct {
+		Name string `json:"name"`
+	} `json:"latestRevision,omitempty"`
+	Workflow *workflowStatus `json:"workflow,omitempty"`
+	Services []struct {
+		Name    string `json:"name"`
+		Healthy bool   `json:"healthy"`
+		Message string `json:"message,omitempty"`
+	} `json:"services,omitempty"`
+}
+
+type workflowStatus struct {
+	AppRevision string       `json:"appRevision,omitempty"`
+	Phase       string       `json:"status,omitempty"`
+	Message     string       `json:"message,omitempty"`
+	Suspend     bool         `json:"suspend,omitempty"`
+	StartTime   string       `json:"startTime,omitempty"`
+	Steps       []stepStatus `json:"steps,omitempty"`
+}
+
+type stepStatus struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Phase    string       `json:"phase,omitempty"`
+	Message  string       `json:"message,omitempty"`
+	Reason   string       `json:"reason,omitempty"`
+	SubSteps []stepStatus `json:"subSteps,omitempty"`
+}
+
+func parseStatus(app *unstructured.Unstructured) (*appStatus, error) {
+	s := &appStatus{}
+	status, ok := app.Object["status"]
+	if !ok {
+		return s, nil
+	}
+	b, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, s)
+	return s, err
+}
+
+func (s *appStatus) revision() string {
+	if s.LatestRevision != nil {
+		return s.LatestRevision.Name
+	}
+	return ""
+}
+
+// health returns whether all services are healthy, and the messages of
+// unhealthy ones. ok is false if there are no services yet.
+func (s *appStatus) health() (healthy bool, message string, ok bool) {
+	if len(s.Services) == 0 {
+		return false, "", false
+	}
+	var msgs []string
+	for _, svc := range s.Services {
+		if !svc.Healthy {
+			msgs = append(msgs, svc.Name+": "+svc.Message)
+		}
+	}
+	sort.Strings(msgs)
+	return len(msgs) == 0, strings.Join(msgs, "; "), true
+}
+
+// run identifies a run of the workflow. A restarted workflow has a new start
+// time, and usually a new revision.
+func (w *workflowStatus) run() string {
+	return w.AppRevision + "/" + w.StartTime
+}
+
+func (w *workflowStatus) suspended() bool {
+	return w.Phase == workflowPhaseSuspending || w.Suspend
+}
+
+// failedSteps returns the failed steps and sub-steps keyed by ID. Sub-steps
+// are named parent/child.
+func (w *workflowStatus) failedSteps() map[string]stepStatus {
+	failed := make(map[string]stepStatus)
+	var walk func(prefix string, steps []stepStatus)
+	walk = func(prefix string, steps []stepStatus) {
+		for _, step := range steps {
+			step.Name = prefix + step.Name
+			if step.Phase == stepPhaseFailed {
+				failed[step.ID+"/"+step.Name] = step
+			}
+			walk(step.Name+"/", step.SubSteps)
+		}
+	}
+	walk("", w.Steps)
+	return failed
+}
+
+// diff computes the events between the old and new status of an Application.
+func diff(old, new *appStatus) []Event {
+	var events []Event
+	revision := new.revision()
+
+	if old.revision() != revision && revision != "" {
+		events = append(events, Event{Type: EventTypeRevisionChanged, Revision: revision})
+	}
+
+	if healthy, msg, ok := new.health(); ok {
+		oldHealthy, _, oldOK := old.health()
+		if !oldOK || oldHealthy != healthy {
+			phase := "unhealthy"
+			if healthy {
+				phase = "healthy"
+			}
+			events = append(events, Event{Type: EventTypeHealthChanged, Phase: phase, Message: msg, Revision: revision, Healthy: &healthy})
+		}
+	}
+
+	if wf := new.Workflow; wf != nil && wf.StartTime != "" {
+		oldWf := old.Workflow
+		if oldWf == nil || oldWf.run() != wf.run() {
+			// A new run, nothing happened in it before.
+			oldWf = &workflowStatus{}
+			events = append(events, Event{Type: EventTypeWorkflowStarted, Phase: wf.Phase, Message: wf.Message, Revision: wf.AppRevision})
+		}
+		oldFailed := oldWf.failedSteps()
+		var failed []Event
+		for key, step := range wf.failedSteps() {
+			if _, ok := oldFailed[key]; ok {
+				continue
+			}
+			msg := step.Message
+			if msg == "" {
+				msg = step.Reason
+			}
+			failed = append(failed, Event{Type: EventTypeWorkflowStepFailed, Step: step.Name, Phase: step.Phase, Message: msg, Revision: wf.AppRevision})
+		}
+		sort.Slice(failed, func(i, j int) bool { return failed[i].Step < failed[j].Step })
+		events = append(events, failed...)
+		if wf.suspended() && !oldWf.suspended() {
+			events = append(events, Event{Type: EventTypeWorkflowSuspended, Phase: wf.Phase, Message: wf.Message, Revision: wf.AppRevision})
+		}
+		if wf.Phase == workflowPhaseSucceeded && oldWf.Phase != workflowPhaseSucceeded {
+			events = append(events, Event{Type: EventTypeWorkflowSucceeded, Phase: wf.Phase, Message: wf.Message, Revision: wf.AppRevision})
+		}
+	}
+	return events
+}
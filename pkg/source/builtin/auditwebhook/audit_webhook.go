@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditwebhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/httpserver"
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+func init() {
+	logger = logrus.WithField("source", auditWebhookType)
+}
+
+var (
+	logger           *logrus.Entry
+	auditWebhookType = "audit-webhook"
+)
+
+// Event is the brief event passed to filters and actions.
+type Event struct {
+	AuditID          string                     `json:"auditID"`
+	Stage            string                     `json:"stage"`
+	Verb             string                     `json:"verb"`
+	User             authenticationv1.UserInfo  `json:"user"`
+	ImpersonatedUser *authenticationv1.UserInfo `json:"impersonatedUser,omitempty"`
+	ObjectRef        *auditv1.ObjectReference   `json:"objectRef,omitempty"`
+	ResponseCode     int32                      `json:"responseCode,omitempty"`
+}
+
+// Data is the full audit entry passed to filters and actions.
+type Data struct {
+	Level                    string                     `json:"level"`
+	AuditID                  string                     `json:"auditID"`
+	Stage                    string                     `json:"stage"`
+	RequestURI               string                     `json:"requestURI"`
+	Verb                     string                     `json:"verb"`
+	User                     authenticationv1.UserInfo  `json:"user"`
+	ImpersonatedUser         *authenticationv1.UserInfo `json:"impersonatedUser,omitempty"`
+	SourceIPs                []string                   `json:"sourceIPs,omitempty"`
+	UserAgent                string                     `json:"userAgent,omitempty"`
+	ObjectRef                *auditv1.ObjectReference   `json:"objectRef,omitempty"`
+	ResponseStatus           *metav1.Status             `json:"responseStatus,omitempty"`
+	RequestObject            map[string]interface{}     `json:"requestObject,omitempty"`
+	ResponseObject           map[string]interface{}     `json:"responseObject,omitempty"`
+	RequestReceivedTimestamp metav1.MicroTime           `json:"requestReceivedTimestamp"`
+	StageTimestamp           metav1.MicroTime           `json:"stageTimestamp"`
+	Annotations              map[string]string          `json:"annotations,omitempty"`
+}
+
+// AuditWebhook receives audit events from the audit webhook backend of the
+// API server, and fires one event per audit entry.
+type AuditWebhook struct {
+	config Config
+	token  []byte
+	eh     eventhandler.EventHandler
+}
+
+var _ types.Source = &AuditWebhook{}
+
+// New creates a new AuditWebhook.
+func (w *AuditWebhook) New() types.Source {
+	return &AuditWebhook{}
+}
+
+// Init initializes the AuditWebhook.
+func (w *AuditWebhook) Init(properties *runtime.RawExtension, eh eventhandler.EventHandler) error {
+	b, err := properties.MarshalJSON()
+	if err != nil {
+		return pkgerrors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	err = json.Unmarshal(b, &w.config)
+	if err != nil {
+		return pkgerrors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	w.config.Config = w.config.Config.WithDefaults(defaultPort, defaultPath)
+	if err := w.config.Validate(); err != nil {
+		return pkgerrors.Wrapf(err, "invalid properties for %s", w.Type())
+	}
+	if w.config.TokenFile != "" {
+		b, err := os.ReadFile(w.config.TokenFile)
+		if err != nil {
+			return pkgerrors.Wrapf(err, "cannot read tokenFile for %s", w.Type())
+		}
+		w.token = []byte(strings.TrimSpace(string(b)))
+		if len(w.token) == 0 {
+			return pkgerrors.Errorf("tokenFile %s for %s is empty", w.config.TokenFile, w.Type())
+		}
+	}
+	w.eh = eh
+	return nil
+}
+
+// Run starts serving audit events.
+func (w *AuditWebhook) Run(ctx context.Context) error {
+	s, err := httpserver.Register(w.config.Config, w)
+	if err != nil {
+		return err
+	}
+	s.Start(ctx)
+	logger.Infof("serving audit events on :%d%s", w.config.Port, w.config.Path)
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (w *AuditWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil {
+		http.Error(rw, "audit events are only accepted over HTTPS", http.StatusBadRequest)
+		return
+	}
+	if !w.authenticated(r) {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	list := &auditv1.EventList{}
+	if err := httpserver.DecodeJSON(rw, r, list); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Failures of event handlers are not reported back, or the API server
+	// would resend the whole batch and fire duplicated events.
+	for i := range list.Items {
+		w.handle(&list.Items[i])
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// authenticated tells whether r has a verified client certificate, or the
+// configured bearer token.
+func (w *AuditWebhook) authenticated(r *http.Request) bool {
+	if w.config.ClientCAFile != "" && httpserver.VerifiedClient(r) {
+		return true
+	}
+	if len(w.token) == 0 {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), w.token) == 1
+}
+
+func (w *AuditWebhook) handle(ae *auditv1.Event) {
+	if !w.config.matches(ae) {
+		return
+	}
+	e := Event{
+		AuditID:          string(ae.AuditID),
+		Stage:            string(ae.Stage),
+		Verb:             ae.Verb,
+		User:             ae.User,
+		ImpersonatedUser: ae.ImpersonatedUser,
+		ObjectRef:        ae.ObjectRef,
+	}
+	if ae.ResponseStatus != nil {
+		e.ResponseCode = ae.ResponseStatus.Code
+	}
+	data := Data{
+		Level:                    string(ae.Level),
+		AuditID:                  string(ae.AuditID),
+		Stage:                    string(ae.Stage),
+		RequestURI:               ae.RequestURI,
+		Verb:                     ae.Verb,
+		User:                     ae.User,
+		ImpersonatedUser:         ae.ImpersonatedUser,
+		SourceIPs:                ae.SourceIPs,
+		UserAgent:                ae.UserAgent,
+		ObjectRef:                ae.ObjectRef,
+		ResponseStatus:           ae.ResponseStatus,
+		RequestReceivedTimestamp: ae.RequestReceivedTimestamp,
+		StageTimestamp:           ae.StageTimestamp,
+		Annotations:              ae.Annotations,
+	}
+	var err error
+	if data.RequestObject, err = decodeObject(ae.RequestObject); err != nil {
+		logger.Errorf("cannot decode requestObject of audit event %s: %s", ae.AuditID, err)
+	}
+	if data.ResponseObject, err = decodeObject(ae.ResponseObject); err != nil {
+		logger.Errorf("cannot decode responseObject of audit event %s: %s", ae.AuditID, err)
+	}
+
+	logger.Infof("%s %s by %s, calling event handler", ae.Verb, ae.RequestURI, ae.User.Username)
+	err = w.eh(w.Type(), e, data)
+	if err != nil && !errors.Is(err, eventhandler.ErrFilteredOut) {
+		logger.Infof("calling event handler failed: %s", err)
+	}
+}
+
+func decodeObject(u *runtime.Unknown) (map[string]interface{}, error) {
+	if u == nil || len(u.Raw) == 0 {
+		return nil, nil
+	}
+	obj := make(map[string]interface{})
+	err := json.Unmarshal(u.Raw, &obj)
+	return obj, err
+}
+
+// Type returns the type of the AuditWebhook.
+func (w *AuditWebhook) Type() string {
+	return auditWebhookType
+}
+
+// Singleton .
+func (w *AuditWebhook) Singleton() bool {
+	return false
+}
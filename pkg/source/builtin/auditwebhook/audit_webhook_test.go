@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditwebhook
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/httpserver"
+)
+
+const eventList = `{
+  "kind": "EventList",
+  "apiVersion": "audit.k8s.io/v1",
+  "items": [
+    {
+      "level": "RequestResponse",
+      "auditID": "1",
+      "stage": "ResponseComplete",
+      "requestURI": "/apis/apps/v1/namespaces/default/deployments/web",
+      "verb": "delete",
+      "user": {"username": "alice", "groups": ["devs", "system:authenticated"]},
+      "sourceIPs": ["10.0.0.1"],
+      "objectRef": {"resource": "deployments", "namespace": "default", "name": "web", "apiGroup": "apps", "apiVersion": "v1"},
+      "responseStatus": {"metadata": {}, "status": "Success", "code": 200},
+      "responseObject": {"kind": "Status", "apiVersion": "v1", "status": "Success"}
+    },
+    {
+      "level": "Metadata",
+      "auditID": "1",
+      "stage": "RequestReceived",
+      "requestURI": "/apis/apps/v1/namespaces/default/deployments/web",
+      "verb": "delete",
+      "user": {"username": "alice"},
+      "objectRef": {"resource": "deployments", "namespace": "default", "name": "web", "apiGroup": "apps", "apiVersion": "v1"}
+    },
+    {
+      "level": "Metadata",
+      "auditID": "2",
+      "stage": "ResponseComplete",
+      "requestURI": "/api/v1/namespaces/default/pods/web-0/exec",
+      "verb": "create",
+      "user": {"username": "system:serviceaccount:ci:runner"},
+      "objectRef": {"resource": "pods", "subresource": "exec", "namespace": "default", "name": "web-0", "apiVersion": "v1"},
+      "responseStatus": {"metadata": {}, "code": 403}
+    }
+  ]
+}`
+
+func TestAuditWebhook_ServeHTTP(t *testing.T) {
+	a := assert.New(t)
+	var events []Event
+	var data []Data
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	a.NoError(os.WriteFile(tokenFile, []byte("secret\n"), 0o600))
+	w := (&AuditWebhook{}).New().(*AuditWebhook)
+	err := w.Init(&runtime.RawExtension{Raw: []byte(`{"path":"/audit","certFile":"tls.crt","keyFile":"tls.key","tokenFile":"` + tokenFile + `"}`)},
+		func(_ string, event interface{}, d interface{}) error {
+			events = append(events, event.(Event))
+			data = append(data, d.(Data))
+			return nil
+		})
+	a.NoError(err)
+
+	request := func(body, token string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, defaultPath, bytes.NewReader([]byte(body)))
+		r.TLS = &tls.ConnectionState{}
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r
+	}
+
+	// Plain HTTP and unauthenticated requests are rejected.
+	rec := httptest.NewRecorder()
+	plain := request(eventList, "secret")
+	plain.TLS = nil
+	w.ServeHTTP(rec, plain)
+	a.Equal(http.StatusBadRequest, rec.Code)
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, request(eventList, ""))
+	a.Equal(http.StatusUnauthorized, rec.Code)
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, request(eventList, "guess"))
+	a.Equal(http.StatusUnauthorized, rec.Code)
+	a.Empty(events)
+
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, request(eventList, "secret"))
+	a.Equal(http.StatusOK, rec.Code)
+	// RequestReceived stage is not selected by default.
+	a.Len(events, 2)
+	a.Equal("delete", events[0].Verb)
+	a.Equal([]string{"devs", "system:authenticated"}, events[0].User.Groups)
+	a.Equal("web", events[0].ObjectRef.Name)
+	a.Equal(int32(200), events[0].ResponseCode)
+	a.Equal("Success", data[0].ResponseObject["status"])
+	a.Equal([]string{"10.0.0.1"}, data[0].SourceIPs)
+	a.Equal(int32(403), events[1].ResponseCode)
+
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, request(`not json`, "secret"))
+	a.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func TestConfig_matches(t *testing.T) {
+	deleteDeploy := &auditv1.Event{
+		Stage:            auditv1.StageResponseComplete,
+		Verb:             "delete",
+		User:             authenticationv1.UserInfo{Username: "admin"},
+		ImpersonatedUser: &authenticationv1.UserInfo{Username: "system:serviceaccount:ci:runner"},
+		ObjectRef:        &auditv1.ObjectReference{APIGroup: "apps", Resource: "deployments", Namespace: "prod"},
+		ResponseStatus:   &metav1.Status{Code: 200},
+	}
+	exec := &auditv1.Event{
+		Stage:     auditv1.StageResponseComplete,
+		Verb:      "create",
+		User:      authenticationv1.UserInfo{Username: "alice"},
+		ObjectRef: &auditv1.ObjectReference{Resource: "pods", Subresource: "exec", Namespace: "prod"},
+	}
+	nonResource := &auditv1.Event{Stage: auditv1.StageResponseComplete, Verb: "get"}
+
+	tests := []struct {
+		name   string
+		config Config
+		event  *auditv1.Event
+		want   bool
+	}{
+		{name: "empty", config: Config{}, event: deleteDeploy, want: true},
+		{name: "verb", config: Config{Verbs: []string{"delete"}}, event: exec, want: false},
+		{name: "impersonated_user_pattern", config: Config{Users: []string{"system:serviceaccount:*"}}, event: deleteDeploy, want: true},
+		{name: "user", config: Config{Users: []string{"bob"}}, event: exec, want: false},
+		{name: "group_resource", config: Config{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}, event: deleteDeploy, want: true},
+		{name: "subresource", config: Config{Resources: []string{"pods/exec"}}, event: exec, want: true},
+		{name: "resource_without_subresource", config: Config{Resources: []string{"pods"}}, event: exec, want: false},
+		{name: "namespace", config: Config{Namespaces: []string{"dev"}}, event: deleteDeploy, want: false},
+		{name: "non_resource_request", config: Config{Resources: []string{"pods"}}, event: nonResource, want: false},
+		{name: "response_code", config: Config{ResponseCodes: []int32{200}}, event: deleteDeploy, want: true},
+		{name: "no_response_status", config: Config{ResponseCodes: []int32{200}}, event: exec, want: false},
+		{name: "stage", config: Config{Stages: []string{"RequestReceived"}}, event: exec, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.config.matches(tt.event))
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	https := httpserver.Config{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt"}
+	assert.NoError(t, (&Config{Config: https, Stages: []string{"ResponseComplete"}, Users: []string{"system:*"}}).Validate())
+	assert.Error(t, (&Config{Config: https, Stages: []string{"Done"}}).Validate())
+	assert.Error(t, (&Config{Config: https, Users: []string{"["}}).Validate())
+	assert.Error(t, (&Config{TokenFile: "token"}).Validate(), "no TLS")
+	assert.Error(t, (&Config{Config: httpserver.Config{CertFile: "tls.crt", KeyFile: "tls.key"}}).Validate(), "no authentication")
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditwebhook
+
+import (
+	"fmt"
+	"path"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/httpserver"
+)
+
+const (
+	defaultPort = 9443
+	defaultPath = "/audit"
+)
+
+// Config is the config for AuditWebhook. Empty filters match everything.
+type Config struct {
+	httpserver.Config `json:",inline"`
+	// TokenFile is a file with the bearer token the API server sends, i.e.
+	// the token of the user in its audit webhook kubeconfig. Either TokenFile
+	// or ClientCAFile is required to authenticate the API server.
+	TokenFile string `json:"tokenFile,omitempty"`
+	// Stages of audit events to fire events for. Defaults to
+	// ResponseComplete, so that each request fires at most one event even if
+	// the audit policy records several stages.
+	Stages []string `json:"stages,omitempty"`
+	// Verbs are Kubernetes verbs, e.g. delete, patch.
+	Verbs []string `json:"verbs,omitempty"`
+	// Users are usernames, and may contain shell patterns, e.g.
+	// system:serviceaccount:*. Impersonated users are matched too.
+	Users []string `json:"users,omitempty"`
+	// APIGroups of the resources. The core group is "".
+	APIGroups []string `json:"apiGroups,omitempty"`
+	// Resources are resources or resource/subresource, e.g. pods/exec.
+	Resources []string `json:"resources,omitempty"`
+	// Namespaces of the resources.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ResponseCodes are HTTP response codes, e.g. 200, 403.
+	ResponseCodes []int32 `json:"responseCodes,omitempty"`
+}
+
+// Validate validates the config. Audit events are only received over HTTPS,
+// from clients that are authenticated by a client certificate or a token.
+func (c *Config) Validate() error {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("certFile and keyFile are required")
+	}
+	if c.ClientCAFile == "" && c.TokenFile == "" {
+		return fmt.Errorf("either clientCAFile or tokenFile is required")
+	}
+	for _, s := range c.Stages {
+		switch auditv1.Stage(s) {
+		case auditv1.StageRequestReceived, auditv1.StageResponseStarted,
+			auditv1.StageResponseComplete, auditv1.StagePanic:
+		default:
+			return fmt.Errorf("unknown stage %q", s)
+		}
+	}
+	for _, u := range c.Users {
+		if _, err := path.Match(u, ""); err != nil {
+			return fmt.Errorf("invalid user pattern %q: %w", u, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) stages() []string {
+	if len(c.Stages) == 0 {
+		return []string{string(auditv1.StageResponseComplete)}
+	}
+	return c.Stages
+}
+
+func contains[T comparable](values []T, v T) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) matchUser(e *auditv1.Event) bool {
+	if len(c.Users) == 0 {
+		return true
+	}
+	names := []string{e.User.Username}
+	if e.ImpersonatedUser != nil {
+		names = append(names, e.ImpersonatedUser.Username)
+	}
+	for _, pattern := range c.Users {
+		for _, name := range names {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches tells whether e passes all filters.
+func (c *Config) matches(e *auditv1.Event) bool {
+	if !contains(c.stages(), string(e.Stage)) || !contains(c.Verbs, e.Verb) || !c.matchUser(e) {
+		return false
+	}
+	if len(c.APIGroups) > 0 || len(c.Resources) > 0 || len(c.Namespaces) > 0 {
+		ref := e.ObjectRef
+		if ref == nil {
+			return false
+		}
+		resource := ref.Resource
+		if ref.Subresource != "" {
+			resource += "/" + ref.Subresource
+		}
+		if !contains(c.APIGroups, ref.APIGroup) || !contains(c.Resources, resource) || !contains(c.Namespaces, ref.Namespace) {
+			return false
+		}
+	}
+	if len(c.ResponseCodes) > 0 {
+		if e.ResponseStatus == nil || !contains(c.ResponseCodes, e.ResponseStatus.Code) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certexpiry
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	kindSecret      = "Secret"
+	kindCertificate = "Certificate"
+
+	secretTypeTLS = "kubernetes.io/tls"
+	secretKeyTLS  = "tls.crt"
+)
+
+// CertInfo is the parsed certificate passed to filters and actions as data.
+type CertInfo struct {
+	Subject        string   `json:"subject"`
+	CommonName     string   `json:"commonName,omitempty"`
+	DNSNames       []string `json:"dnsNames,omitempty"`
+	IPAddresses    []string `json:"ipAddresses,omitempty"`
+	EmailAddresses []string `json:"emailAddresses,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	// Issuer is the distinguished name of the issuer of certificates in
+	// Secrets.
+	Issuer string `json:"issuer,omitempty"`
+	// IssuerRef is the issuer of cert-manager Certificates.
+	IssuerRef    *IssuerRef  `json:"issuerRef,omitempty"`
+	SerialNumber string      `json:"serialNumber,omitempty"`
+	NotBefore    metav1.Time `json:"notBefore"`
+	NotAfter     metav1.Time `json:"notAfter"`
+}
+
+// IssuerRef refers to the cert-manager issuer of a Certificate.
+type IssuerRef struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+// certRef is a certificate found in a watched object.
+type certRef struct {
+	kind      string
+	namespace string
+	name      string
+	// key is the Secret key holding the certificate. Empty for Certificates.
+	key  string
+	info CertInfo
+}
+
+func (r certRef) id(cluster string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", cluster, r.kind, r.namespace, r.name, r.key)
+}
+
+// parsePEM parses the first (leaf) certificate in a PEM bundle. Blocks that
+// cannot be parsed are skipped.
+func parsePEM(data []byte) (*x509.Certificate, error) {
+	var errs []error
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			if len(errs) > 0 {
+				return nil, fmt.Errorf("no valid certificate found: %w", errors.Join(errs...))
+			}
+			return nil, fmt.Errorf("no PEM-encoded certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return c, nil
+	}
+}
+
+func certInfoFromX509(c *x509.Certificate) CertInfo {
+	info := CertInfo{
+		Subject:        c.Subject.String(),
+		CommonName:     c.Subject.CommonName,
+		DNSNames:       c.DNSNames,
+		EmailAddresses: c.EmailAddresses,
+		Issuer:         c.Issuer.String(),
+		SerialNumber:   c.SerialNumber.String(),
+		NotBefore:      metav1.NewTime(c.NotBefore),
+		NotAfter:       metav1.NewTime(c.NotAfter),
+	}
+	for _, ip := range c.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	for _, u := range c.URIs {
+		info.URIs = append(info.URIs, u.String())
+	}
+	return info
+}
+
+// certsFromSecret returns certificates in tls.crt of a TLS Secret, and in any
+// of pemKeys of any Secret. Keys without a valid certificate are skipped, and
+// reported in the error with the certificates of the other keys.
+func certsFromSecret(obj *unstructured.Unstructured, pemKeys []string) ([]certRef, error) {
+	data, _, err := unstructured.NestedStringMap(obj.Object, "data")
+	if err != nil {
+		return nil, err
+	}
+	secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+
+	keys := pemKeys
+	if secretType == secretTypeTLS {
+		keys = append([]string{secretKeyTLS}, pemKeys...)
+	}
+
+	var refs []certRef
+	var errs []error
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		encoded, ok := data[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot decode %s: %w", key, err))
+			continue
+		}
+		c, err := parsePEM(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot parse %s: %w", key, err))
+			continue
+		}
+		refs = append(refs, certRef{
+			kind:      kindSecret,
+			namespace: obj.GetNamespace(),
+			name:      obj.GetName(),
+			key:       key,
+			info:      certInfoFromX509(c),
+		})
+	}
+	return refs, errors.Join(errs...)
+}
+
+// certsFromCertificate returns the certificate described by the status of a
+// cert-manager Certificate. Certificates that are not issued yet are ignored.
+func certsFromCertificate(obj *unstructured.Unstructured) ([]certRef, error) {
+	notAfterStr, ok, _ := unstructured.NestedString(obj.Object, "status", "notAfter")
+	if !ok || notAfterStr == "" {
+		return nil, nil
+	}
+	notAfter, err := time.Parse(time.RFC3339, notAfterStr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse status.notAfter: %w", err)
+	}
+	info := CertInfo{NotAfter: metav1.NewTime(notAfter)}
+	if s, ok, _ := unstructured.NestedString(obj.Object, "status", "notBefore"); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			info.NotBefore = metav1.NewTime(t)
+		}
+	}
+	info.CommonName, _, _ = unstructured.NestedString(obj.Object, "spec", "commonName")
+	if info.CommonName != "" {
+		info.Subject = "CN=" + info.CommonName
+	}
+	info.DNSNames, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "dnsNames")
+	info.IPAddresses, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "ipAddresses")
+	info.EmailAddresses, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "emailAddresses")
+	info.URIs, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "uris")
+	ref := &IssuerRef{}
+	ref.Group, _, _ = unstructured.NestedString(obj.Object, "spec", "issuerRef", "group")
+	ref.Kind, _, _ = unstructured.NestedString(obj.Object, "spec", "issuerRef", "kind")
+	ref.Name, _, _ = unstructured.NestedString(obj.Object, "spec", "issuerRef", "name")
+	if ref.Kind == "" {
+		ref.Kind = "Issuer"
+	}
+	info.IssuerRef = ref
+
+	return []certRef{{
+		kind:      kindCertificate,
+		namespace: obj.GetNamespace(),
+		name:      obj.GetName(),
+		info:      info,
+	}}, nil
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certexpiry
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/controller"
+	rwtypes "github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+func init() {
+	logger = logrus.WithField("source", certExpiryWatcherType)
+}
+
+var (
+	logger                *logrus.Entry
+	certExpiryWatcherType = "cert-expiry-watcher"
+)
+
+const defaultCluster = "local"
+
+// EventType is the type of certificate events.
+type EventType string
+
+// EventTypes
+const (
+	// EventTypeExpiring is fired once a certificate is within daysBefore of notAfter.
+	EventTypeExpiring EventType = "expiring"
+	// EventTypeExpired is fired once a certificate has passed notAfter.
+	EventTypeExpired EventType = "expired"
+)
+
+// Event is the brief event passed to filters and actions.
+type Event struct {
+	Type      EventType `json:"type"`
+	Cluster   string    `json:"cluster"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	// Key is the Secret key holding the certificate. Empty for Certificates.
+	Key      string `json:"key,omitempty"`
+	DaysLeft int    `json:"daysLeft"`
+}
+
+// CertExpiryWatcher watches TLS Secrets and cert-manager Certificates, and
+// fires events before the certificates in them expire.
+type CertExpiryWatcher struct {
+	config   Config
+	interval time.Duration
+	eh       eventhandler.EventHandler
+
+	mu sync.Mutex
+	// fired records the last event fired for each certificate, so that each
+	// event is fired once per issued certificate. A renewed certificate has a
+	// different notAfter, which re-arms it.
+	fired map[string]string
+}
+
+var _ types.Source = &CertExpiryWatcher{}
+
+// New creates a new CertExpiryWatcher.
+func (w *CertExpiryWatcher) New() types.Source {
+	return &CertExpiryWatcher{
+		fired: make(map[string]string),
+	}
+}
+
+// Init initializes the CertExpiryWatcher.
+func (w *CertExpiryWatcher) Init(properties *runtime.RawExtension, eh eventhandler.EventHandler) error {
+	b, err := properties.MarshalJSON()
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	err = json.Unmarshal(b, &w.config)
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	if err := w.config.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid properties for %s", w.Type())
+	}
+	// Already validated.
+	w.interval, _ = w.config.checkInterval()
+	w.eh = eh
+	return nil
+}
+
+// Run starts the CertExpiryWatcher.
+func (w *CertExpiryWatcher) Run(ctx context.Context) error {
+	clusterGetter, err := k8sresourcewatcher.NewMultiClustersGetter(k8sresourcewatcher.MultiClusterConfigType)
+	if err != nil {
+		return err
+	}
+	clusters := w.config.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{defaultCluster}
+	}
+	// Informers of all clusters are created before any is started, so that
+	// none is left running if one cluster fails.
+	type informers struct {
+		ctx     context.Context
+		cluster string
+		secret  cache.SharedIndexInformer
+		cert    cache.SharedIndexInformer
+	}
+	var all []informers
+	for _, cluster := range clusters {
+		cli, mapper, err := clusterGetter.GetDynamicClientAndMapper(ctx, cluster)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get clients of cluster %s", cluster)
+		}
+		multiCtx := multicluster.WithCluster(ctx, cluster)
+		secretInformer, err := controller.NewInformer(multiCtx, cli, mapper, rwtypes.Config{
+			APIVersion:     "v1",
+			Kind:           kindSecret,
+			Namespace:      w.config.Namespace,
+			MatchingLabels: w.config.MatchingLabels,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot watch Secrets in cluster %s", cluster)
+		}
+		var certInformer cache.SharedIndexInformer
+		if w.config.Certificates {
+			certInformer, err = controller.NewInformer(multiCtx, cli, mapper, rwtypes.Config{
+				APIVersion:     "cert-manager.io/v1",
+				Kind:           kindCertificate,
+				Namespace:      w.config.Namespace,
+				MatchingLabels: w.config.MatchingLabels,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "cannot watch cert-manager Certificates in cluster %s", cluster)
+			}
+		}
+		all = append(all, informers{ctx: multiCtx, cluster: cluster, secret: secretInformer, cert: certInformer})
+	}
+	for _, i := range all {
+		go w.watch(i.ctx, i.cluster, i.secret, i.cert)
+	}
+	return nil
+}
+
+func (w *CertExpiryWatcher) watch(ctx context.Context, cluster string, secretInformer, certInformer cache.SharedIndexInformer) {
+	l := logger.WithField("cluster", cluster)
+	synced := []cache.InformerSynced{secretInformer.HasSynced}
+	go secretInformer.Run(ctx.Done())
+	if certInformer != nil {
+		synced = append(synced, certInformer.HasSynced)
+		go certInformer.Run(ctx.Done())
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		l.Errorf("timed out waiting for caches to sync")
+		return
+	}
+	l.Infof("cert-expiry-watcher synced, checking every %s", w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		var certs []*unstructured.Unstructured
+		if certInformer != nil {
+			certs = toUnstructured(certInformer.GetStore().List())
+		}
+		w.check(cluster, time.Now(), toUnstructured(secretInformer.GetStore().List()), certs)
+		select {
+		case <-ctx.Done():
+			l.Infof("context cancelled, stopping cert-expiry-watcher")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func toUnstructured(objs []interface{}) []*unstructured.Unstructured {
+	ret := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, o := range objs {
+		if u, ok := o.(*unstructured.Unstructured); ok {
+			ret = append(ret, u)
+		}
+	}
+	return ret
+}
+
+// check fires events for certificates in secrets and certs that are about to
+// expire at now.
+func (w *CertExpiryWatcher) check(cluster string, now time.Time, secrets, certs []*unstructured.Unstructured) {
+	var refs []certRef
+	for _, s := range secrets {
+		r, err := certsFromSecret(s, w.config.PEMKeys)
+		if err != nil {
+			logger.Debugf("skipping invalid certificates of Secret %s/%s: %s", s.GetNamespace(), s.GetName(), err)
+		}
+		refs = append(refs, r...)
+	}
+	for _, c := range certs {
+		r, err := certsFromCertificate(c)
+		if err != nil {
+			logger.Debugf("skipping Certificate %s/%s: %s", c.GetNamespace(), c.GetName(), err)
+			continue
+		}
+		refs = append(refs, r...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	window := time.Duration(w.config.daysBefore()) * 24 * time.Hour
+	seen := make(map[string]bool)
+	for _, r := range refs {
+		id := r.id(cluster)
+		seen[id] = true
+		left := r.info.NotAfter.Sub(now)
+		if left > window {
+			// Not yet in the window, or renewed. Forget what we fired before.
+			delete(w.fired, id)
+			continue
+		}
+		typ := EventTypeExpiring
+		if left <= 0 {
+			typ = EventTypeExpired
+		}
+		record := r.info.NotAfter.UTC().Format(time.RFC3339) + "/" + string(typ)
+		if w.fired[id] == record {
+			continue
+		}
+		w.fired[id] = record
+		e := Event{
+			Type:      typ,
+			Cluster:   cluster,
+			Kind:      r.kind,
+			Namespace: r.namespace,
+			Name:      r.name,
+			Key:       r.key,
+			DaysLeft:  int(math.Floor(left.Hours() / 24)),
+		}
+		logger.Infof("certificate %s is %s, %d days left", id, typ, e.DaysLeft)
+		if err := w.eh(w.Type(), e, r.info); err != nil {
+			logger.Infof("calling event handler failed: %s", err)
+		}
+	}
+	// Forget certificates that are gone.
+	for id := range w.fired {
+		if strings.HasPrefix(id, cluster+"/") && !seen[id] {
+			delete(w.fired, id)
+		}
+	}
+}
+
+// Type returns the type of the CertExpiryWatcher.
+func (w *CertExpiryWatcher) Type() string {
+	return certExpiryWatcherType
+}
+
+// Singleton .
+func (w *CertExpiryWatcher) Singleton() bool {
+	return false
+}
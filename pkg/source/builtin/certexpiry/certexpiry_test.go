@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certexpiry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newCertPEM(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTLSSecret(t *testing.T, notAfter time.Time) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "tls", "namespace": "default"},
+		"type":       secretTypeTLS,
+		"data": map[string]interface{}{
+			"tls.crt": base64.StdEncoding.EncodeToString(newCertPEM(t, notAfter)),
+		},
+	}}
+}
+
+type recorded struct {
+	event Event
+	data  CertInfo
+}
+
+func newTestWatcher(daysBefore int) (*CertExpiryWatcher, *[]recorded) {
+	var got []recorded
+	w := (&CertExpiryWatcher{}).New().(*CertExpiryWatcher)
+	w.config.DaysBefore = &daysBefore
+	w.eh = func(_ string, event interface{}, data interface{}) error {
+		got = append(got, recorded{event: event.(Event), data: data.(CertInfo)})
+		return nil
+	}
+	return w, &got
+}
+
+func TestCertExpiryWatcher_Check(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+	w, got := newTestWatcher(30)
+
+	// Far from expiry, nothing fired.
+	w.check("local", now, []*unstructured.Unstructured{newTLSSecret(t, now.Add(60*24*time.Hour))}, nil)
+	a.Len(*got, 0)
+
+	// Within the window, fired once.
+	secret := newTLSSecret(t, now.Add(10*24*time.Hour+time.Hour))
+	w.check("local", now, []*unstructured.Unstructured{secret}, nil)
+	w.check("local", now.Add(time.Hour), []*unstructured.Unstructured{secret}, nil)
+	a.Len(*got, 1)
+	a.Equal(EventTypeExpiring, (*got)[0].event.Type)
+	a.Equal(10, (*got)[0].event.DaysLeft)
+	a.Equal("tls.crt", (*got)[0].event.Key)
+	a.Equal("example.com", (*got)[0].data.CommonName)
+	a.Equal([]string{"example.com", "www.example.com"}, (*got)[0].data.DNSNames)
+
+	// Expired, fired once more.
+	w.check("local", now.Add(11*24*time.Hour), []*unstructured.Unstructured{secret}, nil)
+	a.Len(*got, 2)
+	a.Equal(EventTypeExpired, (*got)[1].event.Type)
+
+	// Renewed, re-armed.
+	renewed := newTLSSecret(t, now.Add(90*24*time.Hour))
+	w.check("local", now, []*unstructured.Unstructured{renewed}, nil)
+	a.Len(*got, 2)
+	w.check("local", now.Add(70*24*time.Hour), []*unstructured.Unstructured{renewed}, nil)
+	a.Len(*got, 3)
+	a.Equal(EventTypeExpiring, (*got)[2].event.Type)
+}
+
+func TestCertExpiryWatcher_CheckCertificate(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+	w, got := newTestWatcher(30)
+	cert := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"commonName": "example.com",
+			"dnsNames":   []interface{}{"example.com"},
+			"issuerRef":  map[string]interface{}{"kind": "ClusterIssuer", "name": "letsencrypt"},
+		},
+		"status": map[string]interface{}{
+			"notAfter": now.Add(5 * 24 * time.Hour).UTC().Format(time.RFC3339),
+		},
+	}}
+	pending := cert.DeepCopy()
+	unstructured.RemoveNestedField(pending.Object, "status")
+
+	w.check("local", now, nil, []*unstructured.Unstructured{cert, pending})
+	a.Len(*got, 1)
+	a.Equal(kindCertificate, (*got)[0].event.Kind)
+	a.Equal(&IssuerRef{Kind: "ClusterIssuer", Name: "letsencrypt"}, (*got)[0].data.IssuerRef)
+}
+
+func TestCertsFromSecret(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+	bad := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not a certificate")})
+	chain := append(bad, newCertPEM(t, now.Add(24*time.Hour))...)
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "certs", "namespace": "default"},
+		"data": map[string]interface{}{
+			"chain.crt":   base64.StdEncoding.EncodeToString(chain),
+			"invalid.crt": base64.StdEncoding.EncodeToString(bad),
+			"ca.crt":      base64.StdEncoding.EncodeToString(newCertPEM(t, now.Add(48*time.Hour))),
+		},
+	}}
+
+	// The bad block of the chain is skipped, and so is the invalid key,
+	// without skipping the others.
+	refs, err := certsFromSecret(secret, []string{"chain.crt", "invalid.crt", "ca.crt"})
+	a.Error(err)
+	a.Len(refs, 2)
+	a.Equal("chain.crt", refs[0].key)
+	a.Equal("ca.crt", refs[1].key)
+}
+
+func TestCertExpiryWatcher_Init(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{name: "defaults", raw: `{}`},
+		{name: "full", raw: `{"namespace":"default","daysBefore":7,"pemKeys":["ca.crt"],"certificates":true,"checkInterval":"1h"}`},
+		{name: "invalid_interval", raw: `{"checkInterval":"often"}`, wantErr: true},
+		{name: "negative_days", raw: `{"daysBefore":-1}`, wantErr: true},
+		{name: "invalid_properties", raw: `this-is-not-valid`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := (&CertExpiryWatcher{}).New()
+			err := w.Init(&runtime.RawExtension{Raw: []byte(tt.raw)}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Init() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certexpiry
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultDaysBefore    = 30
+	defaultCheckInterval = 10 * time.Minute
+)
+
+// Config is the config for CertExpiryWatcher.
+type Config struct {
+	// Namespace limits the watched Secrets and Certificates to a namespace.
+	// All namespaces are watched if it is empty.
+	Namespace string `json:"namespace,omitempty"`
+	// MatchingLabels selects the watched Secrets and Certificates by labels.
+	MatchingLabels map[string]string `json:"matchingLabels,omitempty"`
+	// Clusters to watch. Defaults to the local cluster.
+	Clusters []string `json:"clusters,omitempty"`
+	// DaysBefore is how many days before notAfter an event is fired.
+	DaysBefore *int `json:"daysBefore,omitempty"`
+	// PEMKeys are extra keys in any Secret that hold PEM-encoded certificates,
+	// e.g. ca.crt. tls.crt of kubernetes.io/tls Secrets is always checked.
+	PEMKeys []string `json:"pemKeys,omitempty"`
+	// Certificates enables checking cert-manager Certificates as well.
+	Certificates bool `json:"certificates,omitempty"`
+	// CheckInterval is how often the cached objects are checked, e.g. 1h.
+	CheckInterval string `json:"checkInterval,omitempty"`
+}
+
+func (c *Config) daysBefore() int {
+	if c.DaysBefore == nil {
+		return defaultDaysBefore
+	}
+	return *c.DaysBefore
+}
+
+func (c *Config) checkInterval() (time.Duration, error) {
+	if c.CheckInterval == "" {
+		return defaultCheckInterval, nil
+	}
+	d, err := time.ParseDuration(c.CheckInterval)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("checkInterval must be greater than 0")
+	}
+	return d, nil
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	if c.daysBefore() < 0 {
+		return fmt.Errorf("daysBefore must be greater or equal to 0")
+	}
+	if _, err := c.checkInterval(); err != nil {
+		return fmt.Errorf("invalid checkInterval %q: %w", c.CheckInterval, err)
+	}
+	return nil
+}
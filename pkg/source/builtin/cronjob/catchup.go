@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/pkg/executor"
+)
+
+const (
+	defaultStateNamespace = "vela-system"
+	defaultStateName      = "kube-trigger-cronjob-state"
+	defaultCatchUpLimit   = 1
+	// maxMissedRuns bounds how many missed runs are looked at, in case the last
+	// fire time is very old and the schedule is very frequent.
+	maxMissedRuns = 1000
+)
+
+// ConcurrencyPolicy describes what happens when a schedule fires while the
+// previous run of it is still in progress. A run is in progress from when the
+// schedule fires, through jitter and catch-up, until the job of its action
+// leaves the executor.
+type ConcurrencyPolicy string
+
+// ConcurrencyPolicies
+const (
+	// ConcurrencyPolicyAllow allows runs to overlap.
+	ConcurrencyPolicyAllow ConcurrencyPolicy = "Allow"
+	// ConcurrencyPolicyForbid skips the new run.
+	ConcurrencyPolicyForbid ConcurrencyPolicy = "Forbid"
+	// ConcurrencyPolicyReplace cancels the previous run, and drops its job.
+	ConcurrencyPolicyReplace ConcurrencyPolicy = "Replace"
+)
+
+// StateConfig is where the last fire times are persisted.
+type StateConfig struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// stateStore persists the last fire time of each schedule.
+type stateStore interface {
+	LastFired(ctx context.Context, key string) (time.Time, error)
+	SetLastFired(ctx context.Context, key string, t time.Time) error
+}
+
+// configMapStore keeps the last fire times in the data of a ConfigMap.
+type configMapStore struct {
+	cli       client.Client
+	namespace string
+	name      string
+}
+
+func newConfigMapStore(cli client.Client, c *StateConfig) *configMapStore {
+	s := &configMapStore{
+		cli:       cli,
+		namespace: defaultStateNamespace,
+		name:      defaultStateName,
+	}
+	if c != nil && c.Namespace != "" {
+		s.namespace = c.Namespace
+	}
+	if c != nil && c.Name != "" {
+		s.name = c.Name
+	}
+	return s
+}
+
+// LastFired returns the zero time if key has never fired.
+func (s *configMapStore) LastFired(ctx context.Context, key string) (time.Time, error) {
+	cm := &corev1.ConfigMap{}
+	err := s.cli.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.name}, cm)
+	if apierrors.IsNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	v, ok := cm.Data[key]
+	if !ok {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// SetLastFired records t as the last fire time of key.
+func (s *configMapStore) SetLastFired(ctx context.Context, key string, t time.Time) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{}
+		err := s.cli.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.name}, cm)
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: s.namespace, Name: s.name},
+				Data:       map[string]string{key: t.UTC().Format(time.RFC3339)},
+			}
+			return s.cli.Create(ctx, cm)
+		}
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = t.UTC().Format(time.RFC3339)
+		return s.cli.Update(ctx, cm)
+	})
+}
+
+// missedRuns returns at most limit of the latest activations of sched after
+// last and no later than now, that are not older than deadline.
+func missedRuns(sched cron.Schedule, last, now time.Time, deadline time.Duration, limit int) []time.Time {
+	var missed []time.Time
+	for t, i := sched.Next(last), 0; !t.IsZero() && !t.After(now) && i < maxMissedRuns; t, i = sched.Next(t), i+1 {
+		if now.Sub(t) > deadline {
+			continue
+		}
+		missed = append(missed, t)
+	}
+	if len(missed) > limit {
+		missed = missed[len(missed)-limit:]
+	}
+	return missed
+}
+
+// runGuard tracks the last run of a schedule to apply the ConcurrencyPolicy.
+type runGuard struct {
+	mu sync.Mutex
+	// firing is the number of the run waiting for jitter or catch-up, zero
+	// if none.
+	firing int
+	cancel context.CancelFunc
+	// run tracks the job of the last run handed to the event handler.
+	run *executor.Run
+	// runs numbers the runs.
+	runs int
+}
+
+// start begins a run. ok is false if the run should be skipped. fired must be
+// called with the Run tracking the job of the run when it is handed to the
+// event handler, or with nil if it is not.
+func (g *runGuard) start(ctx context.Context, policy ConcurrencyPolicy) (runCtx context.Context, fired func(*executor.Run), ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch policy {
+	case ConcurrencyPolicyForbid:
+		if g.firing != 0 || (g.run != nil && g.run.Pending()) {
+			return nil, nil, false
+		}
+	case ConcurrencyPolicyReplace:
+		if g.firing != 0 {
+			g.cancel()
+		}
+		if g.run != nil {
+			g.run.Cancel()
+		}
+	default:
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	g.runs++
+	n := g.runs
+	g.firing, g.cancel = n, cancel
+	return runCtx, func(run *executor.Run) {
+		cancel()
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.firing == n {
+			g.firing, g.cancel = 0, nil
+		}
+		if run != nil {
+			g.run = run
+		}
+	}, true
+}
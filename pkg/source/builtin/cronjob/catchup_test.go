@@ -0,0 +1,220 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/executor"
+)
+
+func TestMissedRuns(t *testing.T) {
+	sched, err := parseSchedule(Config{Schedule: "0 * * * *", TimeZone: "UTC"})
+	assert.NoError(t, err)
+	last := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 1, 1, 5, 30, 0, 0, time.UTC)
+
+	// 01:00 - 05:00 are missed, only the latest ones are kept.
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 1, 1, 4, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 1, 5, 0, 0, 0, time.UTC),
+	}, missedRuns(sched.Schedule, last, now, 24*time.Hour, 2))
+
+	// Runs older than the deadline are dropped.
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 1, 1, 5, 0, 0, 0, time.UTC),
+	}, missedRuns(sched.Schedule, last, now, time.Hour, 10))
+
+	// Nothing missed.
+	assert.Empty(t, missedRuns(sched.Schedule, now, now, time.Hour, 10))
+}
+
+func TestConfigMapStore(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	s := newConfigMapStore(cli, &StateConfig{Namespace: "default"})
+
+	last, err := s.LastFired(ctx, "a")
+	a.NoError(err)
+	a.True(last.IsZero())
+
+	t1 := time.Date(2023, 1, 1, 1, 0, 0, 0, time.UTC)
+	t2 := time.Date(2023, 1, 1, 2, 0, 0, 0, time.UTC)
+	a.NoError(s.SetLastFired(ctx, "a", t1))
+	a.NoError(s.SetLastFired(ctx, "b", t2))
+	last, err = s.LastFired(ctx, "a")
+	a.NoError(err)
+	a.True(t1.Equal(last))
+
+	cm := &corev1.ConfigMap{}
+	a.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: defaultStateName}, cm))
+	a.Len(cm.Data, 2)
+}
+
+func TestCronJob_CatchUp(t *testing.T) {
+	a := assert.New(t)
+	var events []Event
+	c := (&CronJob{}).New().(*CronJob)
+	err := c.Init(&runtime.RawExtension{Raw: []byte(`{"schedule":"0 * * * *","timeZone":"UTC","startingDeadlineSeconds":86400,"catchUpLimit":2}`)},
+		func(_ string, event interface{}, _ interface{}) error {
+			events = append(events, event.(Event))
+			return nil
+		})
+	a.NoError(err)
+	ctx := context.Background()
+	c.store = newConfigMapStore(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), nil)
+	j := c.jobs[0]
+
+	// First start records a baseline without firing.
+	start := time.Date(2023, 1, 1, 0, 30, 0, 0, time.UTC)
+	c.catchUp(j, start)
+	a.Empty(events)
+	last, err := c.store.LastFired(ctx, j.key)
+	a.NoError(err)
+	a.True(start.Equal(last))
+
+	// Down from 00:30 to 03:30, 02:00 and 03:00 are caught up.
+	c.catchUp(j, time.Date(2023, 1, 1, 3, 30, 0, 0, time.UTC))
+	a.Len(events, 2)
+	a.True(events[0].CatchUp)
+	a.Equal(time.Date(2023, 1, 1, 2, 0, 0, 0, time.UTC), events[0].TimeScheduled.UTC())
+	a.Equal(time.Date(2023, 1, 1, 3, 0, 0, 0, time.UTC), events[1].TimeScheduled.UTC())
+	last, err = c.store.LastFired(ctx, j.key)
+	a.NoError(err)
+	a.Equal(time.Date(2023, 1, 1, 3, 0, 0, 0, time.UTC), last)
+
+	// Nothing more to catch up.
+	c.catchUp(j, time.Date(2023, 1, 1, 3, 40, 0, 0, time.UTC))
+	a.Len(events, 2)
+}
+
+func TestCronJob_StateKey(t *testing.T) {
+	a := assert.New(t)
+	key := func(config string) string {
+		c := (&CronJob{}).New().(*CronJob)
+		a.NoError(c.Init(&runtime.RawExtension{Raw: []byte(config)}, nil))
+		return c.jobs[0].key
+	}
+	k := key(`{"schedule":"0 * * * *"}`)
+	a.Equal(k, key(`{"schedule":"0 * * * *","jitter":"30s","excludeWindows":[{"start":"2023-12-24","end":"2023-12-26"}]}`))
+	a.NotEqual(k, key(`{"schedule":"0 0 * * *"}`))
+	a.NotEqual(k, key(`{"name":"nightly","schedule":"0 * * * *"}`))
+}
+
+// recordingJob records that it ran.
+type recordingJob struct {
+	id  string
+	ran *sync.Map
+}
+
+func (j *recordingJob) Type() string           { return "recording" }
+func (j *recordingJob) ID() string             { return j.id }
+func (j *recordingJob) AllowConcurrency() bool { return true }
+func (j *recordingJob) Run(_ context.Context) error {
+	j.ran.Store(j.id, true)
+	return nil
+}
+
+// newPolicyCronJob creates a CronJob with policy, whose events add recording
+// jobs, numbered from 1, to the returned executor.
+func newPolicyCronJob(t *testing.T, policy ConcurrencyPolicy, ran *sync.Map) (*CronJob, *executor.Executor) {
+	exe, err := executor.New(executor.Config{QueueSize: 5, Workers: 1, BaseRetryDelay: time.Millisecond, PerWorkerQPS: 500, Timeout: time.Second})
+	assert.NoError(t, err)
+	c := (&CronJob{}).New().(*CronJob)
+	fires := 0
+	err = c.Init(&runtime.RawExtension{Raw: []byte(`{"schedule":"0 * * * *","concurrencyPolicy":"` + string(policy) + `"}`)},
+		func(_ string, _ interface{}, data interface{}) error {
+			_, run := eventhandler.Untrack(data)
+			fires++
+			return exe.AddJob(executor.Track(&recordingJob{id: fmt.Sprint(fires), ran: ran}, run))
+		})
+	assert.NoError(t, err)
+	return c, exe
+}
+
+func TestCronJob_Forbid(t *testing.T) {
+	a := assert.New(t)
+	ran := &sync.Map{}
+	c, exe := newPolicyCronJob(t, ConcurrencyPolicyForbid, ran)
+	j := c.jobs[0]
+	now := time.Now()
+
+	// Skipped while the job of the previous run is queued.
+	c.fire(j, now, false)
+	c.fire(j, now, true)
+	a.True(j.guard.run.Pending())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exe.RunJobs(ctx)
+	a.Eventually(func() bool { return !j.guard.run.Pending() }, time.Second, time.Millisecond)
+	_, ok := ran.Load("1")
+	a.True(ok)
+
+	// Fired again once it finished.
+	c.fire(j, now, false)
+	a.Eventually(func() bool { _, ok := ran.Load("2"); return ok }, time.Second, time.Millisecond)
+}
+
+func TestCronJob_Replace(t *testing.T) {
+	a := assert.New(t)
+	ran := &sync.Map{}
+	c, exe := newPolicyCronJob(t, ConcurrencyPolicyReplace, ran)
+	j := c.jobs[0]
+	now := time.Now()
+
+	// A run waiting for jitter is cancelled.
+	c.jitter = time.Hour
+	done := make(chan struct{})
+	go func() {
+		c.fire(j, now, false)
+		close(done)
+	}()
+	a.Eventually(func() bool {
+		j.guard.mu.Lock()
+		defer j.guard.mu.Unlock()
+		return j.guard.firing != 0
+	}, time.Second, time.Millisecond)
+	c.jitter = 0
+	c.fire(j, now, true)
+	<-done
+
+	// The queued job of a run is dropped.
+	first := j.guard.run
+	c.fire(j, now, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exe.RunJobs(ctx)
+	a.Eventually(func() bool { _, ok := ran.Load("2"); return ok }, time.Second, time.Millisecond)
+	a.False(first.Pending())
+	_, ok := ran.Load("1")
+	a.False(ok)
+}
@@ -19,15 +19,102 @@ package cronjob
 import (
 	"fmt"
 	"strings"
+
+	rwtypes "github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
 )
 
+const defaultScheduleAnnotation = "trigger.oam.dev/schedule"
+
 // Config is the config for CronJob.
 type Config struct {
+	// Name identifies the CronJob where last fire times are persisted, so
+	// that they are kept when other properties change. CronJobs with the same
+	// schedules and catch-up need different names.
+	Name     string `json:"name,omitempty"`
 	Schedule string `json:"schedule"`
-	TimeZone string `json:"timeZone"`
+	// Schedules are extra schedules, fired the same way as Schedule.
+	Schedules []string `json:"schedules,omitempty"`
+	TimeZone  string   `json:"timeZone"`
+	// Jitter delays each firing by a random duration up to Jitter, e.g. 30s,
+	// so that the same schedule in many clusters does not fire at once.
+	Jitter string `json:"jitter,omitempty"`
+	// ExcludeWindows are blackout periods, e.g. holidays, where schedules are
+	// not fired.
+	ExcludeWindows []ExcludeWindow `json:"excludeWindows,omitempty"`
+	// StartingDeadlineSeconds enables catching up missed runs, e.g. when
+	// kube-trigger was down at the scheduled time. Missed runs older than this
+	// are not fired. Last fire times are persisted in a ConfigMap.
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// CatchUpLimit is the max number of missed runs fired when catching up.
+	// Defaults to 1.
+	CatchUpLimit int `json:"catchUpLimit,omitempty"`
+	// State is the ConfigMap where last fire times are persisted.
+	// Defaults to vela-system/kube-trigger-cronjob-state.
+	State *StateConfig `json:"state,omitempty"`
+	// ConcurrencyPolicy is one of Allow (default), Forbid, and Replace.
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// Resource, if set, reads schedules from an annotation of each matching
+	// object instead of using Schedule. One cron entry is kept per object.
+	Resource *ResourceConfig `json:"resource,omitempty"`
+}
+
+// ResourceConfig selects the objects whose annotations hold their schedules.
+type ResourceConfig struct {
+	APIVersion     string            `json:"apiVersion"`
+	Kind           string            `json:"kind"`
+	Namespace      string            `json:"namespace,omitempty"`
+	MatchingLabels map[string]string `json:"matchingLabels,omitempty"`
+	Clusters       []string          `json:"clusters,omitempty"`
+	// Annotation is the annotation key holding the schedule.
+	// Defaults to trigger.oam.dev/schedule.
+	Annotation string `json:"annotation,omitempty"`
+}
+
+func (r *ResourceConfig) annotation() string {
+	if r.Annotation == "" {
+		return defaultScheduleAnnotation
+	}
+	return r.Annotation
+}
+
+func (r *ResourceConfig) watcherConfig() rwtypes.Config {
+	return rwtypes.Config{
+		APIVersion:     r.APIVersion,
+		Kind:           r.Kind,
+		Namespace:      r.Namespace,
+		MatchingLabels: r.MatchingLabels,
+	}
+}
+
+func (c *Config) catchUpLimit() int {
+	if c.CatchUpLimit <= 0 {
+		return defaultCatchUpLimit
+	}
+	return c.CatchUpLimit
+}
+
+// allSchedules returns Schedule and Schedules together.
+func (c *Config) allSchedules() []string {
+	var ret []string
+	if c.Schedule != "" {
+		ret = append(ret, c.Schedule)
+	}
+	return append(ret, c.Schedules...)
 }
 
 func (c *Config) String() string {
+	if c.Resource != nil {
+		return fmt.Sprintf("%s of %s %s", c.Resource.annotation(), c.Resource.APIVersion, c.Resource.Kind)
+	}
+	if len(c.Schedules) > 0 {
+		var ss []string
+		for _, sched := range c.allSchedules() {
+			single := Config{Schedule: sched, TimeZone: c.TimeZone}
+			ss = append(ss, single.String())
+		}
+		return strings.Join(ss, ", ")
+	}
+
 	// When TZ is set in schedule, ignore timeZone, just use schedule as is.
 	// This is not the intended use case, but we want to support it.
 	if strings.Contains(c.Schedule, "TZ") {
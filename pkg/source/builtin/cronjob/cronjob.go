@@ -19,6 +19,10 @@ package cronjob
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
@@ -27,7 +31,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/executor"
 	"github.com/kubevela/kube-trigger/pkg/source/types"
+	"github.com/kubevela/kube-trigger/pkg/util/client"
 )
 
 func init() {
@@ -43,6 +49,20 @@ var (
 type CronJob struct {
 	config     Config
 	cronRunner *cron.Cron
+	eh         eventhandler.EventHandler
+	// ctx is cancelled when the CronJob stops, to abort jittered firings.
+	ctx            context.Context
+	jitter         time.Duration
+	excludeWindows []excludeWindow
+
+	// jobs are the static schedules.
+	jobs []*scheduledJob
+	// store persists last fire times when catch-up is enabled.
+	store stateStore
+
+	// objects are the per-object entries when schedules are read from
+	// annotations of resources.
+	objects *objectSchedules
 }
 
 var _ types.Source = &CronJob{}
@@ -64,27 +84,189 @@ func (c *CronJob) Init(properties *runtime.RawExtension, eh eventhandler.EventHa
 	}
 
 	c.cronRunner = cron.New()
-	sched, err := cron.ParseStandard(formatSchedule(c.config))
-	if err != nil {
-		return errors.Wrapf(err, "error when parsing schedule for %s", c.Type())
+	c.eh = eh
+	c.ctx = context.Background()
+	if c.config.Jitter != "" {
+		c.jitter, err = time.ParseDuration(c.config.Jitter)
+		if err != nil || c.jitter < 0 {
+			return fmt.Errorf("invalid jitter %q for %s", c.config.Jitter, c.Type())
+		}
+	}
+	for _, w := range c.config.ExcludeWindows {
+		window, err := parseExcludeWindow(w, c.config.TimeZone)
+		if err != nil {
+			return errors.Wrapf(err, "error when parsing exclude windows for %s", c.Type())
+		}
+		c.excludeWindows = append(c.excludeWindows, window)
+	}
+	switch c.config.ConcurrencyPolicy {
+	case "", ConcurrencyPolicyAllow, ConcurrencyPolicyForbid, ConcurrencyPolicyReplace:
+	default:
+		return fmt.Errorf("invalid concurrencyPolicy %q for %s", c.config.ConcurrencyPolicy, c.Type())
+	}
+	if d := c.config.StartingDeadlineSeconds; d != nil && *d <= 0 {
+		return fmt.Errorf("startingDeadlineSeconds must be greater than 0 for %s", c.Type())
 	}
-	c.cronRunner.Schedule(sched, cron.FuncJob(func() {
-		logger.Infof("schedule \"%s\" fired", c.config.String())
-		e := Event{
-			Config:    c.config,
-			TimeFired: metav1.Now(),
+	if c.config.Resource != nil {
+		if len(c.config.allSchedules()) > 0 {
+			return fmt.Errorf("schedule and resource cannot be set at the same time for %s", c.Type())
 		}
-		err := eh(c.Type(), e, e)
+		if c.config.Resource.APIVersion == "" || c.config.Resource.Kind == "" {
+			return fmt.Errorf("apiVersion and kind are required in resource for %s", c.Type())
+		}
+		c.objects = newObjectSchedules()
+		return nil
+	}
+	if len(c.config.allSchedules()) == 0 {
+		return fmt.Errorf("no schedule specified for %s", c.Type())
+	}
+	for _, s := range c.config.allSchedules() {
+		schedConfig := Config{Schedule: s, TimeZone: c.config.TimeZone}
+		sched, err := parseSchedule(schedConfig)
 		if err != nil {
-			logger.Infof("calling event handler failed: %s", err)
+			return errors.Wrapf(err, "error when parsing schedule for %s", c.Type())
 		}
-	}))
+		j := &scheduledJob{
+			sched: sched,
+			name:  schedConfig.String(),
+			key:   c.stateKey(s),
+			build: func(scheduled, fired metav1.Time, catchUp bool) (Event, interface{}) {
+				e := Event{
+					Config:        c.config,
+					TimeScheduled: scheduled,
+					TimeFired:     fired,
+					CatchUp:       catchUp,
+				}
+				e.Schedule = s
+				return e, e
+			},
+		}
+		c.jobs = append(c.jobs, j)
+		c.cronRunner.Schedule(sched, c.newJob(j))
+	}
 
 	return nil
 }
 
+// scheduledJob is what a schedule fires.
+type scheduledJob struct {
+	sched *trackedSchedule
+	name  string
+	// key identifies the schedule in the state store. Empty if the last fire
+	// time is not persisted.
+	key   string
+	guard runGuard
+	// build creates the event and data passed to the event handler.
+	build func(scheduled, fired metav1.Time, catchUp bool) (Event, interface{})
+}
+
+// stateKey returns a stable ConfigMap key for a schedule of this CronJob. It
+// only depends on the name of the CronJob and the schedule, so that last fire
+// times are kept when other properties change.
+func (c *CronJob) stateKey(schedule string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(c.config.Name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(schedule))
+	return fmt.Sprintf("%s-%x", c.Type(), h.Sum64())
+}
+
+// newJob creates a cron job that fires j for the current activation.
+func (c *CronJob) newJob(j *scheduledJob) cron.Job {
+	return cron.FuncJob(func() {
+		c.fire(j, j.sched.scheduledAt(time.Now()), false)
+	})
+}
+
+// fire calls the event handler with what j builds, unless the activation falls
+// into an exclude window or is skipped by the ConcurrencyPolicy.
+func (c *CronJob) fire(j *scheduledJob, scheduled time.Time, catchUp bool) {
+	for _, w := range c.excludeWindows {
+		if w.contains(scheduled) {
+			logger.Infof("schedule \"%s\" skipped because %s is in an exclude window", j.name, scheduled)
+			return
+		}
+	}
+	var delay time.Duration
+	if c.jitter > 0 {
+		//nolint:gosec // no need to use crypto/rand for jitter
+		delay = time.Duration(rand.Int63n(int64(c.jitter)))
+	}
+	ctx, fired, ok := j.guard.start(c.ctx, c.config.ConcurrencyPolicy)
+	if !ok {
+		logger.Infof("schedule \"%s\" skipped because the previous run is still in progress", j.name)
+		return
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			fired(nil)
+			logger.Infof("schedule \"%s\" cancelled", j.name)
+			return
+		}
+	}
+	logger.Infof("schedule \"%s\" fired, catch-up: %v", j.name, catchUp)
+	e, data := j.build(metav1.NewTime(scheduled), metav1.Now(), catchUp)
+	run := executor.NewRun()
+	err := c.eh(c.Type(), e, eventhandler.Tracked{Data: data, Run: run})
+	fired(run)
+	if err != nil {
+		logger.Infof("calling event handler failed: %s", err)
+	}
+	if c.store != nil && j.key != "" {
+		if err := c.store.SetLastFired(c.ctx, j.key, scheduled); err != nil {
+			logger.Errorf("cannot record last fire time of schedule \"%s\": %s", j.name, err)
+		}
+	}
+}
+
+// catchUp fires the runs of j that were missed since its last recorded fire
+// time, honoring StartingDeadlineSeconds and CatchUpLimit.
+func (c *CronJob) catchUp(j *scheduledJob, now time.Time) {
+	last, err := c.store.LastFired(c.ctx, j.key)
+	if err != nil {
+		logger.Errorf("cannot get last fire time of schedule \"%s\": %s", j.name, err)
+		return
+	}
+	if last.IsZero() {
+		// Never fired before, record now so that we can catch up next time.
+		if err := c.store.SetLastFired(c.ctx, j.key, now); err != nil {
+			logger.Errorf("cannot record last fire time of schedule \"%s\": %s", j.name, err)
+		}
+		return
+	}
+	deadline := time.Duration(*c.config.StartingDeadlineSeconds) * time.Second
+	missed := missedRuns(j.sched.Schedule, last, now, deadline, c.config.catchUpLimit())
+	if len(missed) > 0 {
+		logger.Infof("schedule \"%s\" missed %d runs since %s, catching up", j.name, len(missed), last)
+	}
+	for _, t := range missed {
+		c.fire(j, t, true)
+	}
+}
+
 // Run starts the CronJob.
 func (c *CronJob) Run(ctx context.Context) error {
+	c.ctx = ctx
+	if c.config.Resource != nil {
+		if err := c.watchResources(ctx); err != nil {
+			return err
+		}
+	}
+	if c.config.StartingDeadlineSeconds != nil && len(c.jobs) > 0 {
+		if c.store == nil {
+			cli, err := client.GetClient()
+			if err != nil {
+				return err
+			}
+			c.store = newConfigMapStore(cli, c.config.State)
+		}
+		now := time.Now()
+		for _, j := range c.jobs {
+			go c.catchUp(j, now)
+		}
+	}
 	go func() {
 		logger.Infof("cronjob \"%s\" started", c.config.String())
 		c.cronRunner.Start()
@@ -108,6 +290,14 @@ func (c *CronJob) Singleton() bool {
 
 // Event is the context passed to Actions.
 type Event struct {
-	Config    `json:",inline"`
+	Config `json:",inline"`
+	// TimeScheduled is when the schedule was due.
+	TimeScheduled metav1.Time `json:"timeScheduled"`
+	// TimeFired is when the event was actually fired, after jitter.
 	TimeFired metav1.Time `json:"timeFired"`
+	// CatchUp is true if this run was missed and is fired afterwards.
+	CatchUp bool `json:"catchUp"`
+	// Object is the object whose annotation fired this event. Only set when
+	// schedules are read from resources.
+	Object *ObjectReference `json:"object,omitempty"`
 }
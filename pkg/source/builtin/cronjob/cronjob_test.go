@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/filter"
 )
 
 func TestCronJob_Init(t *testing.T) {
@@ -51,6 +52,49 @@ func TestCronJob_Init(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "multiple_schedules_with_jitter",
+			config: Config{
+				Schedule:  "* * * * *",
+				Schedules: []string{"30 * * * * *", "@every 1h"},
+				Jitter:    "10s",
+				ExcludeWindows: []ExcludeWindow{
+					{Start: "2023-12-24", End: "2023-12-26"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "no_schedule",
+			config: Config{
+				TimeZone: "Asia/Shanghai",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid_jitter",
+			config: Config{
+				Schedule: "* * * * *",
+				Jitter:   "sometimes",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid_exclude_window",
+			config: Config{
+				Schedule:       "* * * * *",
+				ExcludeWindows: []ExcludeWindow{{Start: "tomorrow"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid_concurrency_policy",
+			config: Config{
+				Schedule:          "* * * * *",
+				ConcurrencyPolicy: "Sometimes",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid_timezone",
 			config: Config{
@@ -101,3 +145,43 @@ func TestCronJob_Run(t *testing.T) {
 	cancel()
 	time.Sleep(50 * time.Millisecond)
 }
+
+func TestCronJob_Job(t *testing.T) {
+	a := assert.New(t)
+	var events []Event
+	c := (&CronJob{}).New().(*CronJob)
+	err := c.Init(&runtime.RawExtension{Raw: []byte(`{"schedules":["* * * * *","@hourly"],"jitter":"1ms"}`)},
+		func(_ string, event interface{}, _ interface{}) error {
+			events = append(events, event.(Event))
+			return nil
+		})
+	a.NoError(err)
+	entries := c.cronRunner.Entries()
+	a.Len(entries, 2)
+
+	entries[0].Job.Run()
+	a.Len(events, 1)
+	a.Equal("* * * * *", events[0].Schedule)
+	a.False(events[0].TimeFired.Before(&events[0].TimeScheduled))
+
+	// Nothing is fired in an exclude window.
+	now := time.Now()
+	c.excludeWindows = []excludeWindow{{start: now.Add(-time.Hour), end: now.Add(time.Hour)}}
+	entries[1].Job.Run()
+	a.Len(events, 1)
+}
+
+func TestCronJob_Schema(t *testing.T) {
+	a := assert.New(t)
+	c := &CronJob{}
+	s, err := c.Schema(context.Background(), nil, &runtime.RawExtension{Raw: []byte(`{"schedule":"0 * * * *"}`)})
+	a.NoError(err)
+	a.Equal(s.Event, s.Data)
+	a.NoError(filter.CheckReferences(`context.data.timeScheduled != ""`, s.Event, s.Data))
+	a.Error(filter.CheckReferences(`context.event.schedul != ""`, s.Event, s.Data))
+
+	// The data of events is the object, if schedules are read from resources.
+	s, err = c.Schema(context.Background(), nil, &runtime.RawExtension{Raw: []byte(`{"resource":{"apiVersion":"v1","kind":"ConfigMap"}}`)})
+	a.NoError(err)
+	a.NoError(filter.CheckReferences(`context.data.data.key != ""`, s.Event, s.Data))
+}
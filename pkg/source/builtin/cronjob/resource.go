@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/controller"
+)
+
+const defaultCluster = "local"
+
+// ObjectReference refers to the object that fired a per-object schedule.
+type ObjectReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Cluster    string `json:"cluster"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+type objectEntry struct {
+	id       cron.EntryID
+	schedule string
+	obj      *unstructured.Unstructured
+}
+
+// objectSchedules keeps one cron entry for each object that has a schedule
+// annotation.
+type objectSchedules struct {
+	mu      sync.Mutex
+	entries map[string]*objectEntry
+}
+
+func newObjectSchedules() *objectSchedules {
+	return &objectSchedules{
+		entries: make(map[string]*objectEntry),
+	}
+}
+
+// watchResources starts informers for the objects in all clusters and keeps
+// their cron entries in sync with their annotations.
+func (c *CronJob) watchResources(ctx context.Context) error {
+	clusterGetter, err := k8sresourcewatcher.NewMultiClustersGetter(k8sresourcewatcher.MultiClusterConfigType)
+	if err != nil {
+		return err
+	}
+	clusters := c.config.Resource.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{defaultCluster}
+	}
+	for _, cluster := range clusters {
+		cli, mapper, err := clusterGetter.GetDynamicClientAndMapper(ctx, cluster)
+		if err != nil {
+			return err
+		}
+		multiCtx := multicluster.WithCluster(ctx, cluster)
+		informer, err := controller.NewInformer(multiCtx, cli, mapper, c.config.Resource.watcherConfig())
+		if err != nil {
+			return err
+		}
+		//nolint:errcheck // no need to check err here
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.upsertObject(cluster, obj)
+			},
+			UpdateFunc: func(_, new interface{}) {
+				c.upsertObject(cluster, new)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				c.removeObject(cluster, obj)
+			},
+		})
+		go informer.Run(multiCtx.Done())
+	}
+	return nil
+}
+
+func objectKey(cluster string, obj metav1.Object) string {
+	return cluster + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// upsertObject adds, updates, or removes the cron entry of obj according to
+// its schedule annotation.
+func (c *CronJob) upsertObject(cluster string, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	key := objectKey(cluster, u)
+	schedule := u.GetAnnotations()[c.config.Resource.annotation()]
+
+	c.objects.mu.Lock()
+	defer c.objects.mu.Unlock()
+	entry, exists := c.objects.entries[key]
+	if exists && entry.schedule == schedule {
+		// Schedule unchanged, just keep the latest object for firing.
+		entry.obj = u
+		return
+	}
+	if exists {
+		c.cronRunner.Remove(entry.id)
+		delete(c.objects.entries, key)
+		logger.Debugf("removed schedule %q of %s", entry.schedule, key)
+	}
+	if schedule == "" {
+		return
+	}
+
+	objConfig := Config{Schedule: schedule, TimeZone: c.config.TimeZone}
+	sched, err := parseSchedule(objConfig)
+	if err != nil {
+		logger.Warnf("invalid schedule %q in annotation %s of %s: %s", schedule, c.config.Resource.annotation(), key, err)
+		return
+	}
+	entry = &objectEntry{schedule: schedule, obj: u}
+	name := fmt.Sprintf("%s of %s", objConfig.String(), key)
+	entry.id = c.cronRunner.Schedule(sched, c.newJob(&scheduledJob{sched: sched, name: name, build: func(scheduled, fired metav1.Time, _ bool) (Event, interface{}) {
+		c.objects.mu.Lock()
+		latest := entry.obj
+		c.objects.mu.Unlock()
+		e := Event{
+			Config:        objConfig,
+			TimeScheduled: scheduled,
+			TimeFired:     fired,
+			Object: &ObjectReference{
+				APIVersion: latest.GetAPIVersion(),
+				Kind:       latest.GetKind(),
+				Cluster:    cluster,
+				Namespace:  latest.GetNamespace(),
+				Name:       latest.GetName(),
+			},
+		}
+		return e, latest
+	}}))
+	c.objects.entries[key] = entry
+	logger.Debugf("added schedule %q of %s", schedule, key)
+}
+
+// removeObject removes the cron entry of obj, if any.
+func (c *CronJob) removeObject(cluster string, obj interface{}) {
+	o, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	key := objectKey(cluster, o)
+
+	c.objects.mu.Lock()
+	defer c.objects.mu.Unlock()
+	if entry, ok := c.objects.entries[key]; ok {
+		c.cronRunner.Remove(entry.id)
+		delete(c.objects.entries, key)
+		logger.Debugf("removed schedule %q of %s", entry.schedule, key)
+	}
+}
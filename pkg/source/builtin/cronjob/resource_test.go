@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+)
+
+func newAnnotatedObject(name, schedule string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("core.oam.dev/v1beta1")
+	u.SetKind("Application")
+	u.SetNamespace("default")
+	u.SetName(name)
+	if schedule != "" {
+		u.SetAnnotations(map[string]string{defaultScheduleAnnotation: schedule})
+	}
+	return u
+}
+
+func TestCronJob_ResourceSchedules(t *testing.T) {
+	a := assert.New(t)
+	var events []Event
+	var data []interface{}
+	c := (&CronJob{}).New().(*CronJob)
+	err := c.Init(&runtime.RawExtension{Raw: []byte(`{"resource":{"apiVersion":"core.oam.dev/v1beta1","kind":"Application"}}`)},
+		func(_ string, event interface{}, d interface{}) error {
+			events = append(events, event.(Event))
+			d, _ = eventhandler.Untrack(d)
+			data = append(data, d)
+			return nil
+		})
+	a.NoError(err)
+
+	// Objects without the annotation are ignored.
+	c.upsertObject("local", newAnnotatedObject("app-1", ""))
+	a.Len(c.cronRunner.Entries(), 0)
+
+	c.upsertObject("local", newAnnotatedObject("app-1", "0 3 * * *"))
+	c.upsertObject("local", newAnnotatedObject("app-2", "0 4 * * *"))
+	a.Len(c.cronRunner.Entries(), 2)
+	id := c.objects.entries["local/default/app-1"].id
+
+	// Same schedule keeps the entry, but the latest object is fired.
+	latest := newAnnotatedObject("app-1", "0 3 * * *")
+	latest.SetLabels(map[string]string{"updated": "true"})
+	c.upsertObject("local", latest)
+	a.Equal(id, c.objects.entries["local/default/app-1"].id)
+	c.cronRunner.Entry(id).Job.Run()
+	a.Len(events, 1)
+	a.Equal("0 3 * * *", events[0].Schedule)
+	a.Equal("app-1", events[0].Object.Name)
+	a.Equal("local", events[0].Object.Cluster)
+	a.Equal(latest, data[0])
+
+	// Changed schedule replaces the entry.
+	c.upsertObject("local", newAnnotatedObject("app-1", "0 5 * * *"))
+	a.Len(c.cronRunner.Entries(), 2)
+	a.NotEqual(id, c.objects.entries["local/default/app-1"].id)
+
+	// Invalid or removed annotation removes the entry.
+	c.upsertObject("local", newAnnotatedObject("app-1", "not a schedule"))
+	a.Len(c.cronRunner.Entries(), 1)
+	c.upsertObject("local", newAnnotatedObject("app-2", ""))
+	a.Len(c.cronRunner.Entries(), 0)
+
+	// Deleted objects are removed.
+	c.upsertObject("local", newAnnotatedObject("app-3", "@daily"))
+	a.Len(c.cronRunner.Entries(), 1)
+	c.removeObject("local", newAnnotatedObject("app-3", "@daily"))
+	a.Len(c.cronRunner.Entries(), 0)
+}
+
+func TestCronJob_InitResource(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{name: "normal", raw: `{"resource":{"apiVersion":"v1","kind":"ConfigMap","annotation":"example.com/cron"}}`},
+		{name: "with_schedule", raw: `{"schedule":"* * * * *","resource":{"apiVersion":"v1","kind":"ConfigMap"}}`, wantErr: true},
+		{name: "without_kind", raw: `{"resource":{"apiVersion":"v1"}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := (&CronJob{}).New()
+			if err := c.Init(&runtime.RawExtension{Raw: []byte(tt.raw)}, nil); (err != nil) != tt.wantErr {
+				t.Errorf("Init() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parser accepts standard 5-field schedules, schedules with an optional
+// leading seconds field, and descriptors like @daily and @every 1h30m.
+var parser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// parseSchedule parses the Schedule of c, honoring its TimeZone.
+func parseSchedule(c Config) (*trackedSchedule, error) {
+	sched, err := parser.Parse(formatSchedule(c))
+	if err != nil {
+		return nil, err
+	}
+	return &trackedSchedule{Schedule: sched}, nil
+}
+
+// trackedSchedule remembers the activation times it returned, so that a job
+// can tell which activation it is running for.
+type trackedSchedule struct {
+	cron.Schedule
+	mu   sync.Mutex
+	prev time.Time
+	next time.Time
+}
+
+// Next implements cron.Schedule.
+func (s *trackedSchedule) Next(t time.Time) time.Time {
+	n := s.Schedule.Next(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prev, s.next = s.next, n
+	return n
+}
+
+// scheduledAt returns the activation time of a job running at now. Jobs are
+// started right before the cron runner computes the next activation, so
+// depending on timing, it is either next or prev.
+func (s *trackedSchedule) scheduledAt(now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.next.IsZero() && !s.next.After(now) {
+		return s.next
+	}
+	if !s.prev.IsZero() {
+		return s.prev
+	}
+	return now
+}
+
+// ExcludeWindow is a blackout period in which schedules do not fire. It is
+// either a fixed range from Start to End, or a recurring range that begins
+// every time Schedule fires and lasts for Duration.
+type ExcludeWindow struct {
+	// Start is the inclusive start of the range, in RFC3339 or 2006-01-02.
+	Start string `json:"start,omitempty"`
+	// End is the exclusive end of the range, in RFC3339 or 2006-01-02. A date
+	// means the end of that day, so Start and End can be the same date.
+	End string `json:"end,omitempty"`
+	// Schedule is when a recurring range begins, e.g. "0 0 25 12 *".
+	Schedule string `json:"schedule,omitempty"`
+	// Duration is how long a recurring range lasts, e.g. 24h.
+	Duration string `json:"duration,omitempty"`
+}
+
+// excludeWindow is a parsed ExcludeWindow.
+type excludeWindow struct {
+	start    time.Time
+	end      time.Time
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+func (w excludeWindow) contains(t time.Time) bool {
+	if w.schedule != nil {
+		// The latest beginning of the range no earlier than t-duration.
+		begin := w.schedule.Next(t.Add(-w.duration))
+		return !begin.After(t)
+	}
+	return !t.Before(w.start) && t.Before(w.end)
+}
+
+const dateLayout = "2006-01-02"
+
+func parseWindowTime(s string, loc *time.Location, isEnd bool) (time.Time, error) {
+	if t, err := time.ParseInLocation(dateLayout, s, loc); err == nil {
+		if isEnd {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func parseExcludeWindow(w ExcludeWindow, timeZone string) (excludeWindow, error) {
+	loc := time.Local
+	if timeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return excludeWindow{}, err
+		}
+	}
+
+	var ret excludeWindow
+	switch {
+	case w.Schedule != "" && w.Start == "" && w.End == "":
+		sched, err := parser.Parse(formatSchedule(Config{Schedule: w.Schedule, TimeZone: timeZone}))
+		if err != nil {
+			return ret, fmt.Errorf("invalid schedule %q: %w", w.Schedule, err)
+		}
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil || d <= 0 {
+			return ret, fmt.Errorf("invalid duration %q, must be a positive duration", w.Duration)
+		}
+		ret.schedule = sched
+		ret.duration = d
+	case w.Schedule == "" && w.Start != "" && w.End != "":
+		start, err := parseWindowTime(w.Start, loc, false)
+		if err != nil {
+			return ret, fmt.Errorf("invalid start %q: %w", w.Start, err)
+		}
+		end, err := parseWindowTime(w.End, loc, true)
+		if err != nil {
+			return ret, fmt.Errorf("invalid end %q: %w", w.End, err)
+		}
+		if !end.After(start) {
+			return ret, fmt.Errorf("end %q must be after start %q", w.End, w.Start)
+		}
+		ret.start = start
+		ret.end = end
+	default:
+		return ret, fmt.Errorf("either start and end, or schedule and duration must be set in an exclude window")
+	}
+	return ret, nil
+}
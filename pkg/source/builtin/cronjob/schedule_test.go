@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		wantErr  bool
+	}{
+		{schedule: "* * * * *"},
+		{schedule: "30 * * * * *"},
+		{schedule: "@every 1h30m"},
+		{schedule: "@daily"},
+		{schedule: "0 0 0 0 0", wantErr: true},
+		{schedule: "@every nope", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			_, err := parseSchedule(Config{Schedule: tt.schedule})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Seconds field is honored.
+	s, err := parseSchedule(Config{Schedule: "30 * * * * *", TimeZone: "UTC"})
+	assert.NoError(t, err)
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, base.Add(30*time.Second), s.Next(base))
+}
+
+func TestTrackedSchedule_ScheduledAt(t *testing.T) {
+	s, err := parseSchedule(Config{Schedule: "0 * * * *", TimeZone: "UTC"})
+	assert.NoError(t, err)
+	base := time.Date(2023, 1, 1, 0, 30, 0, 0, time.UTC)
+	due := time.Date(2023, 1, 1, 1, 0, 0, 0, time.UTC)
+	firedAt := due.Add(20 * time.Millisecond)
+
+	assert.Equal(t, due, s.Next(base))
+	// Job started before the runner computes the next activation.
+	assert.Equal(t, due, s.scheduledAt(firedAt))
+	// Job started after that.
+	s.Next(firedAt)
+	assert.Equal(t, due, s.scheduledAt(firedAt))
+}
+
+func TestExcludeWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  ExcludeWindow
+		in      []time.Time
+		out     []time.Time
+		wantErr bool
+	}{
+		{
+			name:   "dates",
+			window: ExcludeWindow{Start: "2023-12-24", End: "2023-12-26"},
+			in:     []time.Time{time.Date(2023, 12, 24, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 26, 23, 59, 0, 0, time.UTC)},
+			out:    []time.Time{time.Date(2023, 12, 23, 23, 59, 0, 0, time.UTC), time.Date(2023, 12, 27, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "rfc3339",
+			window: ExcludeWindow{Start: "2023-06-01T08:00:00Z", End: "2023-06-01T09:00:00Z"},
+			in:     []time.Time{time.Date(2023, 6, 1, 8, 30, 0, 0, time.UTC)},
+			out:    []time.Time{time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "recurring",
+			window: ExcludeWindow{Schedule: "0 0 * * 6", Duration: "48h"},
+			in:     []time.Time{time.Date(2023, 6, 3, 0, 0, 0, 0, time.UTC), time.Date(2023, 6, 4, 23, 0, 0, 0, time.UTC)},
+			out:    []time.Time{time.Date(2023, 6, 2, 23, 0, 0, 0, time.UTC), time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)},
+		},
+		{name: "end_before_start", window: ExcludeWindow{Start: "2023-12-26", End: "2023-12-24"}, wantErr: true},
+		{name: "missing_duration", window: ExcludeWindow{Schedule: "0 0 * * 6"}, wantErr: true},
+		{name: "mixed", window: ExcludeWindow{Start: "2023-12-24", Schedule: "0 0 * * 6"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := parseExcludeWindow(tt.window, "UTC")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExcludeWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, in := range tt.in {
+				assert.True(t, w.contains(in), "%s should be excluded", in)
+			}
+			for _, out := range tt.out {
+				assert.False(t, w.contains(out), "%s should not be excluded", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronjob
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+var _ types.SchemaSource = &CronJob{}
+
+// Schema implements types.SchemaSource. The data of events is the event
+// itself, or the object whose annotation fired it if schedules are read from
+// resources.
+func (c *CronJob) Schema(_ context.Context, _ client.Client, properties *runtime.RawExtension) (*types.Schema, error) {
+	conf := Config{}
+	if properties != nil && len(properties.Raw) > 0 {
+		if err := json.Unmarshal(properties.Raw, &conf); err != nil {
+			return nil, errors.Wrapf(err, "error when parsing properties for %s", c.Type())
+		}
+	}
+	s := &types.Schema{Event: types.SchemaOf(Event{})}
+	s.Data = s.Event
+	if conf.Resource != nil {
+		s.Data = types.ObjectSchema
+	}
+	return s, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrelease
+
+import (
+	"fmt"
+)
+
+// Config is the config for HelmReleaseWatcher.
+type Config struct {
+	// Namespace limits the watched releases to a namespace. All namespaces
+	// are watched if it is empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Releases are the names of the watched releases. All releases are
+	// watched if empty.
+	Releases []string `json:"releases,omitempty"`
+	// Clusters to watch. Defaults to the local cluster.
+	Clusters []string `json:"clusters,omitempty"`
+	// Events to fire. All events are fired if empty.
+	Events []EventType `json:"events,omitempty"`
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	for _, e := range c.Events {
+		switch e {
+		case EventTypeInstalled, EventTypeUpgraded, EventTypeRolledBack, EventTypeFailed, EventTypeUninstalled:
+		default:
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+func contains[T comparable](values []T, v T) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrelease
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/controller"
+	rwtypes "github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+func init() {
+	logger = logrus.WithField("source", helmReleaseWatcherType)
+}
+
+var (
+	logger                 *logrus.Entry
+	helmReleaseWatcherType = "helm-release-watcher"
+)
+
+const defaultCluster = "local"
+
+// EventType is the type of Helm release events.
+type EventType string
+
+// EventTypes
+const (
+	// EventTypeInstalled is fired when the first revision is deployed.
+	EventTypeInstalled EventType = "installed"
+	// EventTypeUpgraded is fired when a later revision is deployed.
+	EventTypeUpgraded EventType = "upgraded"
+	// EventTypeRolledBack is fired when a rollback is deployed.
+	EventTypeRolledBack EventType = "rolledBack"
+	// EventTypeFailed is fired when an install, upgrade or rollback fails.
+	EventTypeFailed EventType = "failed"
+	// EventTypeUninstalled is fired when a release is uninstalled, whether
+	// its history is kept or not.
+	EventTypeUninstalled EventType = "uninstalled"
+)
+
+// Event is the brief event passed to filters and actions. The decoded
+// release, including values, is passed as data, see Data.
+type Event struct {
+	Type         EventType `json:"type"`
+	Cluster      string    `json:"cluster"`
+	Namespace    string    `json:"namespace"`
+	Release      string    `json:"release"`
+	Revision     int       `json:"revision"`
+	Chart        string    `json:"chart"`
+	ChartVersion string    `json:"chartVersion"`
+	AppVersion   string    `json:"appVersion,omitempty"`
+	Description  string    `json:"description,omitempty"`
+}
+
+// HelmReleaseWatcher watches Secrets where Helm stores releases, and fires
+// events when releases are installed, upgraded, rolled back, failed or
+// uninstalled.
+type HelmReleaseWatcher struct {
+	config Config
+	eh     eventhandler.EventHandler
+
+	mu sync.Mutex
+	// latest are the latest revisions of releases, keyed by
+	// cluster/namespace/name.
+	latest map[string]latestRevision
+}
+
+type latestRevision struct {
+	revision    int
+	uninstalled bool
+	// reinstalled is the revision installing the release again on top of
+	// the history kept when it was uninstalled, 0 if none.
+	reinstalled int
+}
+
+var _ types.Source = &HelmReleaseWatcher{}
+
+// New creates a new HelmReleaseWatcher.
+func (w *HelmReleaseWatcher) New() types.Source {
+	return &HelmReleaseWatcher{latest: make(map[string]latestRevision)}
+}
+
+// Init initializes the HelmReleaseWatcher.
+func (w *HelmReleaseWatcher) Init(properties *runtime.RawExtension, eh eventhandler.EventHandler) error {
+	b, err := properties.MarshalJSON()
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	err = json.Unmarshal(b, &w.config)
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	if err := w.config.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid properties for %s", w.Type())
+	}
+	w.eh = eh
+	return nil
+}
+
+// Run starts the HelmReleaseWatcher.
+func (w *HelmReleaseWatcher) Run(ctx context.Context) error {
+	clusterGetter, err := k8sresourcewatcher.NewMultiClustersGetter(k8sresourcewatcher.MultiClusterConfigType)
+	if err != nil {
+		return err
+	}
+	clusters := w.config.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{defaultCluster}
+	}
+	for _, cluster := range clusters {
+		cli, mapper, err := clusterGetter.GetDynamicClientAndMapper(ctx, cluster)
+		if err != nil {
+			return err
+		}
+		multiCtx := multicluster.WithCluster(ctx, cluster)
+		informer, err := controller.NewInformer(multiCtx, cli, mapper, rwtypes.Config{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Namespace:  w.config.Namespace,
+			// Set by Helm on all release Secrets.
+			MatchingLabels: map[string]string{"owner": "helm"},
+		})
+		if err != nil {
+			return err
+		}
+		//nolint:errcheck // no need to check err here
+		informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc: func(obj interface{}, isInInitialList bool) {
+				// Existing releases are not events.
+				if !isInInitialList {
+					w.handle(cluster, nil, obj)
+				} else if rls := w.decode(obj); rls != nil {
+					w.observe(cluster, rls)
+				}
+			},
+			UpdateFunc: func(old, new interface{}) {
+				w.handle(cluster, old, new)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				w.handleDelete(cluster, obj)
+			},
+		})
+		go informer.Run(multiCtx.Done())
+		logger.Infof("watching Helm releases in cluster %s", cluster)
+	}
+	return nil
+}
+
+func (w *HelmReleaseWatcher) decode(obj interface{}) *Release {
+	secret, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	rls, err := decodeRelease(secret)
+	if err != nil {
+		logger.Debugf("skipping Secret %s/%s: %s", secret.GetNamespace(), secret.GetName(), err)
+		return nil
+	}
+	if !contains(w.config.Releases, rls.Name) {
+		return nil
+	}
+	return rls
+}
+
+// handle fires an event when the status of a release changes. old is nil for
+// new releases.
+func (w *HelmReleaseWatcher) handle(cluster string, old, new interface{}) {
+	rls := w.decode(new)
+	if rls == nil {
+		return
+	}
+	w.observe(cluster, rls)
+	if old != nil {
+		if oldRls := w.decode(old); oldRls != nil && oldRls.Info.Status == rls.Info.Status {
+			return
+		}
+	}
+	typ, ok := rls.eventType()
+	if !ok {
+		return
+	}
+	switch typ {
+	case EventTypeUninstalled:
+		// helm uninstall --keep-history. Purging the history later does not
+		// fire again.
+		if !w.uninstalled(cluster, rls) {
+			return
+		}
+	case EventTypeUpgraded:
+		if w.reinstalled(cluster, rls) {
+			typ = EventTypeInstalled
+		}
+	}
+	w.fire(cluster, typ, rls)
+}
+
+// observe records the latest revision of rls. After an uninstall, revisions
+// that are not uninstalled install the release again, from its first
+// revision, or on top of the kept history.
+func (w *HelmReleaseWatcher) observe(cluster string, rls *Release) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := cluster + "/" + rls.Namespace + "/" + rls.Name
+	l, ok := w.latest[key]
+	switch {
+	case !ok:
+	case l.uninstalled:
+		switch rls.Info.Status {
+		case statusUninstalling, statusUninstalled, statusSuperseded:
+			return
+		}
+		if rls.Revision > 1 {
+			w.latest[key] = latestRevision{revision: rls.Revision, reinstalled: rls.Revision}
+			return
+		}
+	case l.revision >= rls.Revision:
+		return
+	}
+	w.latest[key] = latestRevision{revision: rls.Revision}
+}
+
+// reinstalled tells whether rls installs its release again on top of the
+// history kept when it was uninstalled.
+func (w *HelmReleaseWatcher) reinstalled(cluster string, rls *Release) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	l := w.latest[cluster+"/"+rls.Namespace+"/"+rls.Name]
+	return l.reinstalled != 0 && l.reinstalled == rls.Revision
+}
+
+// handleDelete fires an uninstalled event when the Secret of the latest
+// revision is deleted, which is how Helm uninstalls releases without keeping
+// history. Helm marks it uninstalling first. Deleted Secrets of other
+// revisions are history being pruned.
+func (w *HelmReleaseWatcher) handleDelete(cluster string, obj interface{}) {
+	rls := w.decode(obj)
+	if rls == nil || !w.uninstalled(cluster, rls) {
+		return
+	}
+	w.fire(cluster, EventTypeUninstalled, rls)
+}
+
+// uninstalled tells whether the deleted rls means that its release is
+// uninstalled, and fires at most once for the revisions being deleted.
+func (w *HelmReleaseWatcher) uninstalled(cluster string, rls *Release) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := cluster + "/" + rls.Namespace + "/" + rls.Name
+	l, known := w.latest[key]
+	if known && l.uninstalled {
+		return false
+	}
+	var ok bool
+	switch {
+	case rls.Info.Status == statusUninstalling || rls.Info.Status == statusUninstalled:
+		ok = true
+	case known:
+		ok = rls.Revision >= l.revision
+	default:
+		ok = rls.Info.Status == statusDeployed
+	}
+	if ok {
+		w.latest[key] = latestRevision{revision: rls.Revision, uninstalled: true}
+	}
+	return ok
+}
+
+func (w *HelmReleaseWatcher) fire(cluster string, typ EventType, rls *Release) {
+	if !contains(w.config.Events, typ) {
+		return
+	}
+	meta := rls.Chart.Metadata
+	e := Event{
+		Type:         typ,
+		Cluster:      cluster,
+		Namespace:    rls.Namespace,
+		Release:      rls.Name,
+		Revision:     rls.Revision,
+		Chart:        meta.Name,
+		ChartVersion: meta.Version,
+		AppVersion:   meta.AppVersion,
+		Description:  rls.Info.Description,
+	}
+	logger.Infof("release %s/%s/%s %s (revision %d, chart %s-%s), calling event handler",
+		cluster, rls.Namespace, rls.Name, typ, rls.Revision, meta.Name, meta.Version)
+	if err := w.eh(w.Type(), e, rls.data()); err != nil {
+		logger.Infof("calling event handler failed: %s", err)
+	}
+}
+
+// Type returns the type of the HelmReleaseWatcher.
+func (w *HelmReleaseWatcher) Type() string {
+	return helmReleaseWatcherType
+}
+
+// Singleton .
+func (w *HelmReleaseWatcher) Singleton() bool {
+	return false
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrelease
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// releaseSecret builds a Secret like Helm does.
+func releaseSecret(t *testing.T, name string, revision int, status, description string, compress bool) *unstructured.Unstructured {
+	rls := fmt.Sprintf(`{"name":%q,"namespace":"default","version":%d,
+"info":{"status":%q,"description":%q},
+"chart":{"metadata":{"name":"nginx","version":"1.2.%d","appVersion":"1.25"},"templates":[]},
+"config":{"replicaCount":%d},"manifest":"---"}`, name, revision, status, description, revision, revision)
+	b := []byte(rls)
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write(b)
+		assert.NoError(t, err)
+		assert.NoError(t, zw.Close())
+		b = buf.Bytes()
+	}
+	helmEncoded := base64.StdEncoding.EncodeToString(b)
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      fmt.Sprintf("sh.helm.release.v1.%s.v%d", name, revision),
+			"namespace": "default",
+		},
+		"type": releaseSecretType,
+		"data": map[string]interface{}{
+			releaseKey: base64.StdEncoding.EncodeToString([]byte(helmEncoded)),
+		},
+	}}
+}
+
+func TestDecodeRelease(t *testing.T) {
+	a := assert.New(t)
+	for _, compress := range []bool{true, false} {
+		rls, err := decodeRelease(releaseSecret(t, "web", 2, statusDeployed, "Upgrade complete", compress))
+		a.NoError(err)
+		a.Equal("web", rls.Name)
+		a.Equal(2, rls.Revision)
+		a.Equal("nginx", rls.Chart.Metadata.Name)
+		a.Equal("1.25", rls.Chart.Metadata.AppVersion)
+		d := rls.data()
+		a.Equal(float64(2), d.Values["replicaCount"])
+		a.Equal(statusDeployed, d.Status)
+	}
+
+	s := releaseSecret(t, "web", 1, statusDeployed, "", true)
+	s.Object["type"] = "Opaque"
+	_, err := decodeRelease(s)
+	a.Error(err)
+}
+
+func TestHelmReleaseWatcher_handle(t *testing.T) {
+	a := assert.New(t)
+	var events []Event
+	var data []Data
+	w := (&HelmReleaseWatcher{}).New().(*HelmReleaseWatcher)
+	a.NoError(w.Init(&runtime.RawExtension{Raw: []byte(`{"releases":["web"]}`)}, func(_ string, e interface{}, d interface{}) error {
+		events = append(events, e.(Event))
+		data = append(data, d.(Data))
+		return nil
+	}))
+	last := func() EventType {
+		if len(events) == 0 {
+			return ""
+		}
+		e := events[len(events)-1]
+		events = nil
+		return e.Type
+	}
+
+	// helm install
+	v1Pending := releaseSecret(t, "web", 1, "pending-install", "Initial install underway", true)
+	v1 := releaseSecret(t, "web", 1, statusDeployed, "Install complete", true)
+	w.handle("local", nil, v1Pending)
+	a.Equal(EventType(""), last())
+	w.handle("local", v1Pending, v1)
+	a.Equal(EventTypeInstalled, last())
+
+	// Resync without changes.
+	w.handle("local", v1, v1)
+	a.Equal(EventType(""), last())
+
+	// helm upgrade
+	v2Pending := releaseSecret(t, "web", 2, "pending-upgrade", "Preparing upgrade", true)
+	v2 := releaseSecret(t, "web", 2, statusDeployed, "Upgrade complete", true)
+	w.handle("local", nil, v2Pending)
+	w.handle("local", v1, releaseSecret(t, "web", 1, "superseded", "Install complete", true))
+	a.Equal(EventType(""), last())
+	w.handle("local", v2Pending, v2)
+	a.Equal(EventTypeUpgraded, events[0].Type)
+	a.Equal(2, events[0].Revision)
+	a.Equal("nginx", events[0].Chart)
+	a.Equal("1.2.2", events[0].ChartVersion)
+	a.Equal("1.25", events[0].AppVersion)
+	a.Equal(float64(2), data[len(data)-1].Values["replicaCount"])
+	events = nil
+
+	// helm upgrade failed
+	w.handle("local", nil, releaseSecret(t, "web", 3, statusFailed, "Upgrade failed", true))
+	a.Equal(EventTypeFailed, last())
+
+	// helm rollback
+	w.handle("local", nil, releaseSecret(t, "web", 4, statusDeployed, "Rollback to 2", true))
+	a.Equal(EventTypeRolledBack, last())
+
+	// helm uninstall --keep-history
+	v4 := releaseSecret(t, "web", 4, statusDeployed, "Rollback to 2", true)
+	v4Uninstalling := releaseSecret(t, "web", 4, statusUninstalling, "Deletion in progress", true)
+	v4Uninstalled := releaseSecret(t, "web", 4, statusUninstalled, "Uninstallation complete", true)
+	w.handle("local", v4, v4Uninstalling)
+	a.Equal(EventType(""), last())
+	w.handle("local", v4Uninstalling, v4Uninstalled)
+	a.Equal(EventTypeUninstalled, last())
+	w.handle("local", v4Uninstalled, v4Uninstalled)
+	a.Equal(EventType(""), last())
+
+	// helm install on top of the kept history
+	v5Pending := releaseSecret(t, "web", 5, "pending-install", "Initial install underway", true)
+	w.handle("local", nil, v5Pending)
+	a.Equal(EventType(""), last())
+	w.handle("local", v5Pending, releaseSecret(t, "web", 5, statusDeployed, "Install complete", true))
+	a.Equal(EventTypeInstalled, last())
+	w.handle("local", nil, releaseSecret(t, "web", 6, statusDeployed, "Upgrade complete", true))
+	a.Equal(EventTypeUpgraded, last())
+
+	// helm uninstall marks the latest revision uninstalling, and deletes all
+	// revisions. Only one event fires.
+	w.handleDelete("local", releaseSecret(t, "web", 1, "superseded", "", true))
+	a.Equal(EventType(""), last())
+	w.handleDelete("local", releaseSecret(t, "web", 6, statusUninstalling, "Deletion in progress", true))
+	a.Equal(EventTypeUninstalled, last())
+	a.Equal(6, data[len(data)-1].Revision)
+	w.handleDelete("local", v4Uninstalled)
+	a.Equal(EventType(""), last())
+
+	// Installed again, the latest revision fires whatever its status.
+	w.handle("local", nil, releaseSecret(t, "web", 1, statusDeployed, "Install complete", true))
+	a.Equal(EventTypeInstalled, last())
+	w.handle("local", nil, releaseSecret(t, "web", 2, statusFailed, "Upgrade failed", true))
+	a.Equal(EventTypeFailed, last())
+	w.handleDelete("local", releaseSecret(t, "web", 1, statusDeployed, "Install complete", true))
+	a.Equal(EventType(""), last())
+	w.handleDelete("local", releaseSecret(t, "web", 2, statusFailed, "Upgrade failed", true))
+	a.Equal(EventTypeUninstalled, last())
+
+	// Purging the history kept by helm uninstall --keep-history does not fire
+	// again.
+	purge1 := releaseSecret(t, "web", 1, statusDeployed, "Install complete", true)
+	w.handle("purge", nil, purge1)
+	a.Equal(EventTypeInstalled, last())
+	purge1Uninstalled := releaseSecret(t, "web", 1, statusUninstalled, "Uninstallation complete", true)
+	w.handle("purge", purge1, purge1Uninstalled)
+	a.Equal(EventTypeUninstalled, last())
+	w.handleDelete("purge", purge1Uninstalled)
+	a.Equal(EventType(""), last())
+
+	// Releases deleted before they are observed fire when deployed.
+	w.handleDelete("other", releaseSecret(t, "web", 1, "superseded", "", true))
+	a.Equal(EventType(""), last())
+	w.handleDelete("other", releaseSecret(t, "web", 2, statusDeployed, "", true))
+	a.Equal(EventTypeUninstalled, last())
+
+	// Other releases are ignored.
+	w.handle("local", nil, releaseSecret(t, "db", 1, statusDeployed, "Install complete", true))
+	a.Equal(EventType(""), last())
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helmrelease
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	releaseSecretType = "helm.sh/release.v1"
+	releaseKey        = "release"
+
+	// Statuses of Helm releases.
+	statusDeployed     = "deployed"
+	statusFailed       = "failed"
+	statusSuperseded   = "superseded"
+	statusUninstalling = "uninstalling"
+	statusUninstalled  = "uninstalled"
+
+	rollbackDescriptionPrefix = "Rollback to "
+)
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// Data is the release passed to filters and actions.
+type Data struct {
+	Name          string        `json:"name"`
+	Namespace     string        `json:"namespace"`
+	Revision      int           `json:"revision"`
+	Status        string        `json:"status"`
+	Description   string        `json:"description,omitempty"`
+	FirstDeployed string        `json:"firstDeployed,omitempty"`
+	LastDeployed  string        `json:"lastDeployed,omitempty"`
+	Notes         string        `json:"notes,omitempty"`
+	Chart         ChartMetadata `json:"chart"`
+	// Values are the values supplied by the user.
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// Release is a decoded Helm release. Only the fields useful to filters are
+// kept. Manifests and templates are dropped.
+type Release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Revision  int    `json:"version"`
+	Info      struct {
+		FirstDeployed string `json:"first_deployed,omitempty"`
+		LastDeployed  string `json:"last_deployed,omitempty"`
+		Deleted       string `json:"deleted,omitempty"`
+		Description   string `json:"description,omitempty"`
+		Status        string `json:"status,omitempty"`
+		Notes         string `json:"notes,omitempty"`
+	} `json:"info"`
+	Chart struct {
+		Metadata ChartMetadata `json:"metadata"`
+	} `json:"chart"`
+	// Config is the values supplied by the user.
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// ChartMetadata is the metadata of a chart.
+type ChartMetadata struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	AppVersion  string `json:"appVersion,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// decodeRelease decodes the release stored in a helm.sh/release.v1 Secret.
+// The release is JSON, gzipped, then base64-encoded by Helm, then
+// base64-encoded again as Secret data.
+func decodeRelease(secret *unstructured.Unstructured) (*Release, error) {
+	if typ, _, _ := unstructured.NestedString(secret.Object, "type"); typ != releaseSecretType {
+		return nil, fmt.Errorf("not a Helm release Secret, type is %q", typ)
+	}
+	data, _, _ := unstructured.NestedString(secret.Object, "data", releaseKey)
+	if data == "" {
+		return nil, fmt.Errorf("no %s in Secret", releaseKey)
+	}
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	b, err = base64.StdEncoding.DecodeString(string(b))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(b, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	rls := &Release{}
+	if err := json.Unmarshal(b, rls); err != nil {
+		return nil, err
+	}
+	return rls, nil
+}
+
+func (r *Release) data() Data {
+	return Data{
+		Name:          r.Name,
+		Namespace:     r.Namespace,
+		Revision:      r.Revision,
+		Status:        r.Info.Status,
+		Description:   r.Info.Description,
+		FirstDeployed: r.Info.FirstDeployed,
+		LastDeployed:  r.Info.LastDeployed,
+		Notes:         r.Info.Notes,
+		Chart:         r.Chart.Metadata,
+		Values:        r.Config,
+	}
+}
+
+// eventType returns the event to fire when a release reaches its current
+// status. ok is false for intermediate statuses like pending-upgrade and
+// superseded.
+func (r *Release) eventType() (EventType, bool) {
+	switch r.Info.Status {
+	case statusDeployed:
+		switch {
+		case strings.HasPrefix(r.Info.Description, rollbackDescriptionPrefix):
+			return EventTypeRolledBack, true
+		case r.Revision <= 1:
+			return EventTypeInstalled, true
+		default:
+			return EventTypeUpgraded, true
+		}
+	case statusFailed:
+		return EventTypeFailed, true
+	case statusUninstalled:
+		return EventTypeUninstalled, true
+	}
+	return "", false
+}
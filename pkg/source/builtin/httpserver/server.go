@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package httpserver is the shared HTTP(S) server for HTTP-based Sources.
+// Sources listening on the same port share one server, each on its own path.
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxBodySize is the max size of request bodies read by DecodeJSON.
+	MaxBodySize = 10 * 1024 * 1024
+
+	shutdownTimeout   = 5 * time.Second
+	readHeaderTimeout = 10 * time.Second
+)
+
+var logger = logrus.WithField("source", "httpserver")
+
+// Config is how a Source wants to be served. Sources embed it in their own
+// configs.
+type Config struct {
+	// Port to listen on.
+	Port int `json:"port,omitempty"`
+	// Path to serve requests on.
+	Path string `json:"path,omitempty"`
+	// CertFile and KeyFile enable HTTPS.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// ClientCAFile, if set, verifies client certificates against the CAs in
+	// it. Sources use VerifiedClient to require them.
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+}
+
+// WithDefaults returns c with an empty Port and Path set to the given ones.
+func (c Config) WithDefaults(port int, path string) Config {
+	if c.Port == 0 {
+		c.Port = port
+	}
+	if c.Path == "" {
+		c.Path = path
+	}
+	return c
+}
+
+// Server is an HTTP(S) server shared by Sources on the same port.
+type Server struct {
+	port         int
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientCAs    *x509.CertPool
+
+	mu    sync.Mutex
+	mux   *http.ServeMux
+	paths map[string]bool
+	// ctx is the context the server is started with, nil before Start.
+	ctx  context.Context
+	once sync.Once
+	// stopped is closed once the server is shut down.
+	stopped chan struct{}
+	// prev is the server shut down on the same port before this one, which
+	// has to release the port before this one listens.
+	prev *Server
+}
+
+var (
+	serversMu sync.Mutex
+	servers   = make(map[int]*Server)
+)
+
+// Register registers h to be served on c.Path of the server on c.Port,
+// creating the server if it does not exist yet, or is shut down, e.g. when
+// the config is reloaded. Call Start on the returned server to start
+// serving.
+func Register(c Config, h http.Handler) (*Server, error) {
+	if c.Port <= 0 {
+		return nil, fmt.Errorf("invalid port %d", c.Port)
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, fmt.Errorf("certFile and keyFile must be set together")
+	}
+	if c.ClientCAFile != "" && c.CertFile == "" {
+		return nil, fmt.Errorf("clientCAFile needs certFile and keyFile")
+	}
+
+	serversMu.Lock()
+	defer serversMu.Unlock()
+	s, ok := servers[c.Port]
+	if !ok || s.shutDown() {
+		prev := s
+		s = &Server{
+			port:         c.Port,
+			certFile:     c.CertFile,
+			keyFile:      c.KeyFile,
+			clientCAFile: c.ClientCAFile,
+			mux:          http.NewServeMux(),
+			paths:        make(map[string]bool),
+			stopped:      make(chan struct{}),
+			prev:         prev,
+		}
+		if c.ClientCAFile != "" {
+			pool, err := loadCAs(c.ClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+			s.clientCAs = pool
+		}
+		servers[c.Port] = s
+	}
+	if s.certFile != c.CertFile || s.keyFile != c.KeyFile || s.clientCAFile != c.ClientCAFile {
+		return nil, fmt.Errorf("port %d is already used with different TLS settings", c.Port)
+	}
+	if err := s.handle(c.Path, h); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func loadCAs(file string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read clientCAFile: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates in clientCAFile %s", file)
+	}
+	return pool, nil
+}
+
+// shutDown tells whether s is started, and its context is done.
+func (s *Server) shutDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx != nil && s.ctx.Err() != nil
+}
+
+func (s *Server) handle(path string, h http.Handler) error {
+	if path == "" || path[0] != '/' {
+		return fmt.Errorf("invalid path %q, must start with /", path)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paths[path] {
+		return fmt.Errorf("path %s is already registered on port %d", path, s.port)
+	}
+	s.paths[path] = true
+	s.mux.Handle(path, h)
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Start starts serving in the background until ctx is cancelled. It is safe to
+// call Start multiple times, the server is only started once. Once ctx is
+// cancelled, sources registering on the same port get a new server.
+func (s *Server) Start(ctx context.Context) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.ctx = ctx
+		s.mu.Unlock()
+		srv := &http.Server{
+			Addr:              fmt.Sprintf(":%d", s.port),
+			Handler:           s,
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+		// Client certificates are optional on the server, because it is
+		// shared, and required by the Sources that need them.
+		if s.clientCAs != nil {
+			srv.TLSConfig = &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				ClientAuth: tls.VerifyClientCertIfGiven,
+				ClientCAs:  s.clientCAs,
+			}
+		}
+		go func() {
+			if s.prev != nil {
+				<-s.prev.stopped
+				s.prev = nil
+			}
+			var err error
+			logger.Infof("listening on :%d, TLS enabled: %v", s.port, s.certFile != "")
+			if s.certFile != "" {
+				err = srv.ListenAndServeTLS(s.certFile, s.keyFile)
+			} else {
+				err = srv.ListenAndServe()
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Errorf("server on :%d stopped: %s", s.port, err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			serversMu.Lock()
+			if servers[s.port] == s {
+				delete(servers, s.port)
+			}
+			serversMu.Unlock()
+			defer close(s.stopped)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			//nolint:contextcheck // ctx is already done
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				logger.Errorf("error when shutting down server on :%d: %s", s.port, err)
+			}
+		}()
+	})
+}
+
+// VerifiedClient tells whether r is sent over TLS with a client certificate
+// verified against the clientCAFile of the server.
+func VerifiedClient(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
+// DecodeJSON decodes the JSON body of r into v, reading at most MaxBodySize.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if r.Method != http.MethodPost {
+		return fmt.Errorf("method %s not allowed", r.Method)
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// WriteJSON writes v as a JSON response.
+func WriteJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("error when writing response: %s", err)
+	}
+}
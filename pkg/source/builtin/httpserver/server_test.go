@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	a := assert.New(t)
+	ok := func(text string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(text))
+		})
+	}
+
+	s1, err := Register(Config{Port: 18080, Path: "/a"}, ok("a"))
+	a.NoError(err)
+	s2, err := Register(Config{Port: 18080, Path: "/b"}, ok("b"))
+	a.NoError(err)
+	a.Same(s1, s2)
+
+	rec := httptest.NewRecorder()
+	s1.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/b", nil))
+	a.Equal("b", rec.Body.String())
+
+	_, err = Register(Config{Port: 18080, Path: "/a"}, ok("a"))
+	a.Error(err, "duplicated path")
+	_, err = Register(Config{Port: 18080, Path: "/c", CertFile: "tls.crt", KeyFile: "tls.key"}, ok("c"))
+	a.Error(err, "different TLS settings")
+	_, err = Register(Config{Port: 18081, Path: "/c", CertFile: "tls.crt"}, ok("c"))
+	a.Error(err, "no key file")
+	_, err = Register(Config{Port: 18081, Path: "/c", ClientCAFile: "ca.crt"}, ok("c"))
+	a.Error(err, "client CA without TLS")
+	_, err = Register(Config{Port: 18081, Path: "/c", CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "missing.crt"}, ok("c"))
+	a.Error(err, "missing client CA")
+	_, err = Register(Config{Port: 18081, Path: "c"}, ok("c"))
+	a.Error(err, "invalid path")
+	_, err = Register(Config{Path: "/c"}, ok("c"))
+	a.Error(err, "no port")
+}
+
+func TestRestart(t *testing.T) {
+	ok := func(text string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(text))
+		})
+	}
+	get := func() string {
+		resp, err := http.Get("http://127.0.0.1:18082/a")
+		if err != nil {
+			return ""
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s1, err := Register(Config{Port: 18082, Path: "/a"}, ok("old"))
+	require.NoError(t, err)
+	s1.Start(ctx)
+	require.Eventually(t, func() bool { return get() == "old" }, 5*time.Second, 10*time.Millisecond)
+
+	// After a reload, the same path is served by a new server.
+	cancel()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	s2, err := Register(Config{Port: 18082, Path: "/a"}, ok("new"))
+	require.NoError(t, err)
+	assert.NotSame(t, s1, s2)
+	s2.Start(ctx)
+	require.Eventually(t, func() bool { return get() == "new" }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestConfig_WithDefaults(t *testing.T) {
+	c := Config{Path: "/custom"}.WithDefaults(9443, "/default")
+	assert.Equal(t, Config{Port: 9443, Path: "/custom"}, c)
+}
@@ -33,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/kubevela/kube-trigger/api/v1alpha1"
@@ -44,33 +45,73 @@ import (
 
 const maxRetries = 5
 
-var serverStartTime time.Time
-
 // Controller object
 type Controller struct {
-	logger   *logrus.Entry
-	queue    workqueue.RateLimitingInterface
-	informer cache.SharedIndexInformer
+	logger       *logrus.Entry
+	queue        workqueue.RateLimitingInterface
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
 
 	eventHandlers  []eventhandler.EventHandler
 	sourceConf     types.Config
 	listenEvents   map[types.EventType]bool
+	ignoreManagers map[string]bool
+	projection     *projection
 	controllerType string
 	cluster        string
+	// startTime is when the controller started. Only objects created after
+	// it fire create events.
+	startTime time.Time
+}
+
+// Clients are the clients of a cluster used by informers. Metadata is only
+// required for metadata-only informers.
+type Clients struct {
+	Dynamic  dynamic.Interface
+	Metadata metadata.Interface
+	Mapper   meta.RESTMapper
 }
 
-// Setup prepares controllers
-func Setup(ctx context.Context, cli dynamic.Interface, mapper meta.RESTMapper, ctrlConf types.Config, eh []eventhandler.EventHandler) *Controller {
+// Setup prepares controllers. The informer of the cluster in ctx is taken from
+// informers, so that controllers watching the same resources share it.
+func Setup(ctx context.Context, informers *SharedInformers, clients Clients, ctrlConf types.Config, eh []eventhandler.EventHandler) *Controller {
 	logger := logrus.WithField("source", v1alpha1.SourceTypeResourceWatcher)
-	gv, err := schema.ParseGroupVersion(ctrlConf.APIVersion)
+	cluster, _ := multicluster.ClusterFrom(ctx)
+	informer, err := informers.Get(ctx, cluster, clients, ctrlConf)
 	if err != nil {
-		logrus.WithField("source", v1alpha1.SourceTypeResourceWatcher).Fatal(err)
+		logger.Fatal(err)
+	}
+
+	c := newResourceController(ctx, logger, informer, newSelector(ctrlConf), ctrlConf.Kind)
+	// precheck ->
+	c.sourceConf = ctrlConf
+	c.eventHandlers = eh
+
+	listenEvents := make(map[types.EventType]bool)
+	for _, e := range c.sourceConf.Events {
+		listenEvents[e] = true
+	}
+	c.listenEvents = listenEvents
+
+	ignoreManagers := make(map[string]bool)
+	for _, m := range c.sourceConf.IgnoreManagers {
+		ignoreManagers[m] = true
 	}
-	gvk := gv.WithKind(ctrlConf.Kind)
+	c.ignoreManagers = ignoreManagers
+	c.projection = newProjection(ctrlConf)
 
-	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gv.Version)
+	c.controllerType = v1alpha1.SourceTypeResourceWatcher
+
+	return c
+}
+
+// NewInformer creates an informer for the resources described by ctrlConf. The
+// informer is not started. Sources other than the resource-watcher can use it to
+// keep an up-to-date cache of the objects they are interested in.
+func NewInformer(ctx context.Context, cli dynamic.Interface, mapper meta.RESTMapper, ctrlConf types.Config) (cache.SharedIndexInformer, error) {
+	mapping, err := restMapping(mapper, ctrlConf)
 	if err != nil {
-		logrus.WithField("source", v1alpha1.SourceTypeResourceWatcher).Fatal(err)
+		return nil, err
 	}
 
 	informer := cache.NewSharedIndexInformer(
@@ -92,73 +133,111 @@ func Setup(ctx context.Context, cli dynamic.Interface, mapper meta.RESTMapper, c
 		0, // Skip resync
 		cache.Indexers{},
 	)
+	return informer, nil
+}
 
-	c := newResourceController(ctx, logger, informer, ctrlConf.Kind)
-	// precheck ->
-	c.sourceConf = ctrlConf
-	c.eventHandlers = eh
-
-	listenEvents := make(map[types.EventType]bool)
-	for _, e := range c.sourceConf.Events {
-		listenEvents[e] = true
+// NewMetadataInformer is like NewInformer, but only watches and caches the
+// metadata of objects, as *metav1.PartialObjectMetadata.
+func NewMetadataInformer(ctx context.Context, cli metadata.Interface, mapper meta.RESTMapper, ctrlConf types.Config) (cache.SharedIndexInformer, error) {
+	mapping, err := restMapping(mapper, ctrlConf)
+	if err != nil {
+		return nil, err
 	}
-	c.listenEvents = listenEvents
 
-	c.controllerType = v1alpha1.SourceTypeResourceWatcher
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if len(ctrlConf.MatchingLabels) > 0 {
+					options.LabelSelector = labels.FormatLabels(ctrlConf.MatchingLabels)
+				}
+				return cli.Resource(mapping.Resource).Namespace(ctrlConf.Namespace).List(ctx, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if len(ctrlConf.MatchingLabels) > 0 {
+					options.LabelSelector = labels.FormatLabels(ctrlConf.MatchingLabels)
+				}
+				return cli.Resource(mapping.Resource).Namespace(ctrlConf.Namespace).Watch(ctx, options)
+			},
+		},
+		&metav1.PartialObjectMetadata{},
+		0, // Skip resync
+		cache.Indexers{},
+	)
+	return informer, nil
+}
 
-	return c
+func restMapping(mapper meta.RESTMapper, ctrlConf types.Config) (*meta.RESTMapping, error) {
+	gv, err := schema.ParseGroupVersion(ctrlConf.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.RESTMapping(gv.WithKind(ctrlConf.Kind).GroupKind(), gv.Version)
 }
 
-func newResourceController(ctx context.Context, logger *logrus.Entry, informer cache.SharedIndexInformer, kind string) *Controller {
+// newResourceController creates a Controller handling objects selected by sel
+// from the shared informer. Like watches with selectors, an object that starts
+// matching is added, and one that stops matching is deleted.
+func newResourceController(ctx context.Context, logger *logrus.Entry, informer cache.SharedIndexInformer, sel *selector, kind string) *Controller {
 	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
-	var newEvent types.InformerEvent
-	var err error
 	cluster, _ := multicluster.ClusterFrom(ctx)
-	//nolint:errcheck // no need to check err here
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	add := func(obj interface{}) {
+		meta := utils.GetObjectMetaData(obj)
+		logger.Tracef("received add event: %v %s/%s", kind, meta.GetName(), meta.GetNamespace())
+		queue.Add(&types.InformerEvent{
+			Event:    types.Event{Type: types.EventTypeCreate, Cluster: cluster},
+			EventObj: obj,
+		})
+	}
+	del := func(obj interface{}) {
+		meta := utils.GetObjectMetaData(obj)
+		logger.Tracef("received delete event: %v %s/%s", kind, meta.GetName(), meta.GetNamespace())
+		queue.Add(&types.InformerEvent{
+			Event:    types.Event{Type: types.EventTypeDelete, Cluster: cluster},
+			EventObj: obj,
+		})
+	}
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			newEvent.Event = types.Event{
-				Type:    types.EventTypeCreate,
-				Cluster: cluster,
-			}
-			newEvent.EventObj = obj
-			meta := utils.GetObjectMetaData(obj)
-			logger.Tracef("received add event: %v %s/%s", kind, meta.GetName(), meta.GetNamespace())
-			if err == nil {
-				queue.Add(newEvent)
+			if sel.matches(obj) {
+				add(obj)
 			}
 		},
-		UpdateFunc: func(_, new interface{}) {
-			newEvent.Event = types.Event{
-				Type:    types.EventTypeUpdate,
-				Cluster: cluster,
-			}
-			newEvent.EventObj = new
-			meta := utils.GetObjectMetaData(new)
-			logger.Tracef("received update event: %v %s/%s", kind, meta.GetName(), meta.GetNamespace())
-			if err == nil {
-				queue.Add(newEvent)
+		UpdateFunc: func(old, new interface{}) {
+			oldMatches, newMatches := sel.matches(old), sel.matches(new)
+			switch {
+			case oldMatches && newMatches:
+				meta := utils.GetObjectMetaData(new)
+				logger.Tracef("received update event: %v %s/%s", kind, meta.GetName(), meta.GetNamespace())
+				queue.Add(&types.InformerEvent{
+					Event:    types.Event{Type: types.EventTypeUpdate, Cluster: cluster},
+					EventObj: new,
+					OldObj:   old,
+				})
+			case newMatches:
+				add(new)
+			case oldMatches:
+				del(new)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
-			newEvent.Event = types.Event{
-				Type:    types.EventTypeDelete,
-				Cluster: cluster,
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
 			}
-			newEvent.EventObj = obj
-			meta := utils.GetObjectMetaData(obj)
-			logger.Tracef("received delete event: %v %s/%s", kind, meta.GetName(), meta.GetNamespace())
-			if err == nil {
-				queue.Add(newEvent)
+			if sel.matches(obj) {
+				del(obj)
 			}
 		},
 	})
+	if err != nil {
+		logger.Fatal(err)
+	}
 
 	return &Controller{
-		logger:   logger,
-		informer: informer,
-		queue:    queue,
-		cluster:  cluster,
+		logger:       logger,
+		informer:     informer,
+		registration: registration,
+		queue:        queue,
+		cluster:      cluster,
 	}
 }
 
@@ -172,9 +251,11 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 		"cluster":    c.cluster,
 	})
 	c.logger.Info("starting watch k8s resources...")
-	serverStartTime = time.Now().Local()
+	c.startTime = time.Now().Local()
 
-	go c.informer.Run(stopCh)
+	// The shared informer is run by SharedInformers.
+	//nolint:errcheck // no need to check err here
+	defer c.informer.RemoveEventHandler(c.registration)
 	if !cache.WaitForCacheSync(stopCh, c.HasSynced) {
 		utilruntime.HandleError(fmt.Errorf("timed out waiting for caches to sync"))
 		return
@@ -183,9 +264,10 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	wait.Until(c.runWorker, time.Second, stopCh)
 }
 
-// HasSynced is required for the cache.Controller interface.
+// HasSynced is required for the cache.Controller interface. It is true once
+// the existing objects are delivered to this controller.
 func (c *Controller) HasSynced() bool {
-	return c.informer.HasSynced()
+	return c.registration.HasSynced()
 }
 
 // LastSyncResourceVersion is required for the cache.Controller interface.
@@ -206,8 +288,8 @@ func (c *Controller) processNextItem() bool {
 	}
 	defer c.queue.Done(newEvent)
 
-	meta := utils.GetObjectMetaData(newEvent.(types.InformerEvent).EventObj)
-	err := c.processItem(newEvent.(types.InformerEvent))
+	meta := utils.GetObjectMetaData(newEvent.(*types.InformerEvent).EventObj)
+	err := c.processItem(newEvent.(*types.InformerEvent))
 	//nolint:gocritic // no need to use switch statement here
 	if err == nil {
 		// No error, reset the ratelimit counters
@@ -225,7 +307,7 @@ func (c *Controller) processNextItem() bool {
 	return true
 }
 
-func (c *Controller) processItem(newEvent types.InformerEvent) error {
+func (c *Controller) processItem(newEvent *types.InformerEvent) error {
 	// Get object's metadata
 	objectMeta := utils.GetObjectMetaData(newEvent.EventObj)
 	// Fetching (create,update,delete) event Obj of k8s
@@ -239,13 +321,24 @@ func (c *Controller) processItem(newEvent types.InformerEvent) error {
 	// Process events based on its type
 	switch newEvent.Type {
 	case types.EventTypeCreate:
-		// Compare CreationTimestamp and serverStartTime and alert only on latest events
+		// Compare CreationTimestamp and startTime and alert only on latest events
 		// Could be Replaced by using Delta or DeltaFIFO
-		if objectMeta.GetCreationTimestamp().Sub(serverStartTime).Seconds() > 0 {
+		if objectMeta.GetCreationTimestamp().Sub(c.startTime).Seconds() > 0 {
 			c.logger.Debugf("add %s event: %s/%s", newEvent.Type, objectMeta.GetName(), objectMeta.GetNamespace())
 			c.callEventHandler(objectMeta, newEvent.Event)
 			return nil
 		}
+	case types.EventTypeUpdate:
+		if newEvent.OldObj != nil {
+			newEvent.ChangedFields = changedFields(utils.GetObjectMetaData(newEvent.OldObj), objectMeta)
+			newEvent.Manager = latestManager(newEvent.ChangedFields)
+		}
+		if onlyIgnoredManagers(newEvent.ChangedFields, c.ignoreManagers) {
+			c.logger.Debugf("object filtered out because it is changed by ignored manager %s: %s/%s", newEvent.Manager, objectMeta.GetName(), objectMeta.GetNamespace())
+			return nil
+		}
+		c.logger.Debugf("add %s event by %s: %s/%s", newEvent.Type, newEvent.Manager, objectMeta.GetName(), objectMeta.GetNamespace())
+		c.callEventHandler(objectMeta, newEvent.Event)
 	default:
 		c.logger.Debugf("add %s event: %s/%s", newEvent.Type, objectMeta.GetName(), objectMeta.GetNamespace())
 		c.callEventHandler(objectMeta, newEvent.Event)
@@ -255,8 +348,9 @@ func (c *Controller) processItem(newEvent types.InformerEvent) error {
 
 func (c *Controller) callEventHandler(obj metav1.Object, e types.Event) {
 	c.logger.Infof("%s event %s/%s/%s happened, calling event handlers", e.Type, e.Cluster, obj.GetNamespace(), obj.GetName())
+	data := c.projection.project(obj)
 	for _, fn := range c.eventHandlers {
-		err := fn(c.controllerType, e, obj)
+		err := fn(c.controllerType, e, data)
 		if err != nil {
 			c.logger.Infof("calling event handler failed: %s", err)
 		}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+)
+
+func managedFieldsKey(e metav1.ManagedFieldsEntry) string {
+	return e.Manager + "/" + string(e.Operation) + "/" + e.Subresource
+}
+
+// changedFields returns the managedFields entries of newObj that are added or
+// changed since oldObj, with the paths of fields whose ownership changed. The
+// API server updates the time of an entry whenever its manager changes the
+// object.
+func changedFields(oldObj, newObj metav1.Object) []types.FieldsChange {
+	old := make(map[string]metav1.ManagedFieldsEntry)
+	for _, e := range oldObj.GetManagedFields() {
+		old[managedFieldsKey(e)] = e
+	}
+	var changes []types.FieldsChange
+	for _, e := range newObj.GetManagedFields() {
+		o, ok := old[managedFieldsKey(e)]
+		if ok && o.Time.Equal(e.Time) && fieldsEqual(o.FieldsV1, e.FieldsV1) {
+			continue
+		}
+		fields := fieldSet(e.FieldsV1)
+		if ok {
+			oldFields := fieldSet(o.FieldsV1)
+			fields = fields.Difference(oldFields).Union(oldFields.Difference(fields))
+		}
+		changes = append(changes, types.FieldsChange{
+			Manager:     e.Manager,
+			Operation:   string(e.Operation),
+			Subresource: e.Subresource,
+			Time:        e.Time,
+			Fields:      fieldPaths(fields),
+		})
+	}
+	return changes
+}
+
+func fieldsEqual(a, b *metav1.FieldsV1) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return bytes.Equal(a.Raw, b.Raw)
+}
+
+// fieldSet parses f, which is empty if it cannot be parsed.
+func fieldSet(f *metav1.FieldsV1) *fieldpath.Set {
+	set := &fieldpath.Set{}
+	if f == nil || len(f.Raw) == 0 {
+		return set
+	}
+	if err := set.FromJSON(bytes.NewReader(f.Raw)); err != nil {
+		return &fieldpath.Set{}
+	}
+	return set
+}
+
+// fieldPaths returns the paths of the leaf fields in set.
+func fieldPaths(set *fieldpath.Set) []string {
+	var paths []string
+	set.Leaves().Iterate(func(p fieldpath.Path) {
+		paths = append(paths, p.String())
+	})
+	return paths
+}
+
+// latestManager returns the manager of the latest change.
+func latestManager(changes []types.FieldsChange) string {
+	var latest *types.FieldsChange
+	for i := range changes {
+		c := &changes[i]
+		if latest == nil || (c.Time != nil && (latest.Time == nil || latest.Time.Before(c.Time))) {
+			latest = c
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Manager
+}
+
+// onlyIgnoredManagers tells whether all changes are made by ignored managers.
+// It returns false if no changes are found, since the actor is unknown.
+func onlyIgnoredManagers(changes []types.FieldsChange, ignored map[string]bool) bool {
+	if len(changes) == 0 || len(ignored) == 0 {
+		return false
+	}
+	for _, c := range changes {
+		if !ignored[c.Manager] {
+			return false
+		}
+	}
+	return true
+}
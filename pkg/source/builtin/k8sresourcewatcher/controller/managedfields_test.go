@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+)
+
+func objWithManagedFields(entries ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetManagedFields(entries)
+	return u
+}
+
+func entry(manager string, t time.Time, fields string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "apps/v1",
+		Time:       &metav1.Time{Time: t},
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	a := assert.New(t)
+	t0 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	replicas := `{"f:spec":{"f:replicas":{}}}`
+	image := `{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{"f:image":{}}}}}}}`
+
+	oldObj := objWithManagedFields(entry("kubectl-client-side-apply", t0, replicas))
+	newObj := objWithManagedFields(
+		entry("kubectl-client-side-apply", t0, replicas),
+		entry("kube-trigger", t1, image),
+	)
+	changes := changedFields(oldObj, newObj)
+	a.Len(changes, 1)
+	a.Equal("kube-trigger", changes[0].Manager)
+	a.Equal("Update", changes[0].Operation)
+	a.Equal([]string{`.spec.template.spec.containers[name="app"].image`}, changes[0].Fields)
+	a.Equal("kube-trigger", latestManager(changes))
+
+	// Same fields, newer time: values changed, ownership did not.
+	newObj = objWithManagedFields(entry("kubectl-client-side-apply", t1, replicas))
+	changes = changedFields(oldObj, newObj)
+	a.Len(changes, 1)
+	a.Empty(changes[0].Fields)
+
+	// Only the fields whose ownership changed are listed.
+	labels := `"f:metadata":{"f:labels":{"f:app":{}}}`
+	changed := changedFields(
+		objWithManagedFields(entry("kubectl-client-side-apply", t0, `{`+labels+`,"f:spec":{"f:replicas":{}}}`)),
+		objWithManagedFields(entry("kubectl-client-side-apply", t1, `{`+labels+`,`+image[1:])),
+	)
+	a.Len(changed, 1)
+	a.ElementsMatch([]string{".spec.replicas", `.spec.template.spec.containers[name="app"].image`}, changed[0].Fields)
+
+	// Nothing changed in managedFields.
+	a.Empty(changedFields(oldObj, oldObj))
+	a.Equal("", latestManager(nil))
+}
+
+func TestLatestManager(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	t1 := metav1.NewTime(t0.Add(time.Second))
+	changes := []types.FieldsChange{
+		{Manager: "vela-core", Time: &t0},
+		{Manager: "argocd", Time: &t1},
+		{Manager: "no-time"},
+	}
+	assert.Equal(t, "argocd", latestManager(changes))
+}
+
+func TestOnlyIgnoredManagers(t *testing.T) {
+	ignored := map[string]bool{"kube-trigger": true}
+	a := assert.New(t)
+	a.True(onlyIgnoredManagers([]types.FieldsChange{{Manager: "kube-trigger"}}, ignored))
+	a.False(onlyIgnoredManagers([]types.FieldsChange{{Manager: "kube-trigger"}, {Manager: "kubectl-edit"}}, ignored))
+	a.False(onlyIgnoredManagers(nil, ignored))
+	a.False(onlyIgnoredManagers([]types.FieldsChange{{Manager: "kube-trigger"}}, nil))
+}
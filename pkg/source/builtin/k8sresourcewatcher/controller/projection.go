@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+)
+
+// alwaysKeptFields are kept by every projection.
+var alwaysKeptFields = [][]string{
+	{"apiVersion"},
+	{"kind"},
+	{"metadata", "name"},
+	{"metadata", "namespace"},
+}
+
+// projection trims objects before they are passed to filters and actions.
+type projection struct {
+	apiVersion string
+	kind       string
+	fields     [][]string
+}
+
+func newProjection(ctrlConf types.Config) *projection {
+	p := &projection{apiVersion: ctrlConf.APIVersion, kind: ctrlConf.Kind}
+	for _, f := range ctrlConf.Fields {
+		p.fields = append(p.fields, strings.Split(f, "."))
+	}
+	return p
+}
+
+// objectMap returns the fields of obj. Objects that are not unstructured are
+// converted.
+func objectMap(obj interface{}) (map[string]interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object, nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+// project returns obj with only the fields of the projection. Metadata-only
+// objects get the apiVersion and kind of the watched resource instead of
+// PartialObjectMetadata. obj itself is not modified, since it is shared with
+// the informer cache.
+func (p *projection) project(obj metav1.Object) metav1.Object {
+	u, isUnstructured := obj.(*unstructured.Unstructured)
+	if isUnstructured && len(p.fields) == 0 {
+		return obj
+	}
+	m, err := objectMap(obj)
+	if err != nil {
+		return obj
+	}
+	if !isUnstructured {
+		// Already a copy.
+		u = &unstructured.Unstructured{Object: m}
+		u.SetAPIVersion(p.apiVersion)
+		u.SetKind(p.kind)
+	}
+	if len(p.fields) == 0 {
+		return u
+	}
+	out := make(map[string]interface{})
+	keep := func(fields []string) {
+		v, found, err := unstructured.NestedFieldNoCopy(u.Object, fields...)
+		if err != nil || !found {
+			return
+		}
+		_ = unstructured.SetNestedField(out, runtime.DeepCopyJSONValue(v), fields...)
+	}
+	for _, fields := range alwaysKeptFields {
+		keep(fields)
+	}
+	for _, fields := range p.fields {
+		keep(fields)
+	}
+	return &unstructured.Unstructured{Object: out}
+}
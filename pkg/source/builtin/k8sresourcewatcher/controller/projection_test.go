@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metadatafake "k8s.io/client-go/metadata/fake"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+)
+
+func TestProjection(t *testing.T) {
+	pod := newPod("web-0", map[string]string{"app": "web"}, "Running")
+	pod.Object["spec"] = map[string]interface{}{"nodeName": "node-1", "containers": []interface{}{}}
+
+	// No fields, the object is passed as is.
+	p := newProjection(types.Config{APIVersion: "v1", Kind: "Pod"})
+	assert.Same(t, pod, p.project(pod))
+
+	p = newProjection(types.Config{APIVersion: "v1", Kind: "Pod", Fields: []string{"metadata.labels", "status.phase", "spec.missing"}})
+	got := p.project(pod).(*unstructured.Unstructured)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      "web-0",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"app": "web"},
+		},
+		"status": map[string]interface{}{"phase": "Running"},
+	}, got.Object)
+	// The cached object is untouched.
+	assert.Equal(t, "node-1", pod.Object["spec"].(map[string]interface{})["nodeName"])
+
+	// Metadata-only objects get the watched apiVersion and kind.
+	meta := &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "meta.k8s.io/v1", Kind: "PartialObjectMetadata"},
+		ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default", Annotations: map[string]string{"a": "b"}},
+	}
+	p = newProjection(types.Config{APIVersion: "v1", Kind: "ConfigMap"})
+	got = p.project(meta).(*unstructured.Unstructured)
+	assert.Equal(t, "v1", got.GetAPIVersion())
+	assert.Equal(t, "ConfigMap", got.GetKind())
+	assert.Equal(t, map[string]string{"a": "b"}, got.GetAnnotations())
+	assert.Equal(t, "PartialObjectMetadata", meta.Kind)
+}
+
+func TestMetadataOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(multicluster.WithCluster(context.Background(), "local"))
+	defer cancel()
+	pod := &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "web-0",
+			Namespace:         "default",
+			Labels:            map[string]string{"app": "web"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+	}
+	scheme := metadatafake.NewTestScheme()
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, &metav1.PartialObjectMetadata{})
+	mcli := metadatafake.NewSimpleMetadataClient(scheme, pod)
+	_, mapper := newFakeClient()
+	informers := NewSharedInformers()
+
+	conf := types.Config{
+		APIVersion: "v1", Kind: "Pod", Namespace: "default", MetadataOnly: true,
+		MatchingFields: map[string]string{"metadata.labels.app": "web"},
+		Fields:         []string{"metadata.labels"},
+	}
+	_, err := informers.Get(ctx, "local", Clients{Mapper: mapper}, conf)
+	assert.Error(t, err, "no metadata client")
+
+	var mu sync.Mutex
+	var data []*unstructured.Unstructured
+	c := Setup(ctx, informers, Clients{Metadata: mcli, Mapper: mapper}, conf, []eventhandler.EventHandler{
+		func(_ string, _ interface{}, d interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			data = append(data, d.(*unstructured.Unstructured))
+			return nil
+		},
+	})
+	go c.Run(ctx.Done())
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(data) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "Pod", data[0].GetKind())
+	assert.Equal(t, map[string]string{"app": "web"}, data[0].GetLabels())
+	assert.Empty(t, data[0].GetCreationTimestamp())
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+)
+
+// selector selects objects from a shared informer client-side.
+type selector struct {
+	labels labels.Selector
+	fields []fieldRequirement
+}
+
+type fieldRequirement struct {
+	path  []string
+	value string
+}
+
+func newSelector(ctrlConf types.Config) *selector {
+	s := &selector{
+		labels: labels.SelectorFromSet(ctrlConf.MatchingLabels),
+	}
+	for path, value := range ctrlConf.MatchingFields {
+		s.fields = append(s.fields, fieldRequirement{path: strings.Split(path, "."), value: value})
+	}
+	return s
+}
+
+// matches tells whether obj matches the labels and fields of the selector.
+func (s *selector) matches(obj interface{}) bool {
+	o, ok := obj.(metav1.Object)
+	if !ok {
+		return false
+	}
+	if !s.labels.Matches(labels.Set(o.GetLabels())) {
+		return false
+	}
+	if len(s.fields) == 0 {
+		return true
+	}
+	m, err := objectMap(obj)
+	if err != nil {
+		return false
+	}
+	for _, f := range s.fields {
+		v, found, err := unstructured.NestedFieldNoCopy(m, f.path...)
+		if err != nil || !found || fmt.Sprint(v) != f.value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+)
+
+// SharedInformers keeps one informer per cluster, GVK and namespace, shared by
+// all Controllers watching them. Controllers select objects by labels and
+// fields client-side, so triggers with different selectors do not create
+// separate watches and caches.
+type SharedInformers struct {
+	mu        sync.Mutex
+	informers map[string]cache.SharedIndexInformer
+}
+
+// NewSharedInformers creates an empty SharedInformers.
+func NewSharedInformers() *SharedInformers {
+	return &SharedInformers{
+		informers: make(map[string]cache.SharedIndexInformer),
+	}
+}
+
+// Get returns the informer of the cluster, GVK and namespace of ctrlConf,
+// creating and starting it if it does not exist yet. MatchingLabels and
+// MatchingFields of ctrlConf are not used. Metadata-only informers are
+// separate from full ones. The informer stops when ctx is
+// done.
+func (s *SharedInformers) Get(ctx context.Context, cluster string, clients Clients, ctrlConf types.Config) (cache.SharedIndexInformer, error) {
+	key := strings.Join([]string{cluster, ctrlConf.APIVersion, ctrlConf.Kind, ctrlConf.Namespace}, "/")
+	if ctrlConf.MetadataOnly {
+		key += "/metadata"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if informer, ok := s.informers[key]; ok {
+		return informer, nil
+	}
+	conf := types.Config{
+		APIVersion: ctrlConf.APIVersion,
+		Kind:       ctrlConf.Kind,
+		Namespace:  ctrlConf.Namespace,
+	}
+	var informer cache.SharedIndexInformer
+	var err error
+	if ctrlConf.MetadataOnly {
+		if clients.Metadata == nil {
+			return nil, fmt.Errorf("no metadata client for cluster %s", cluster)
+		}
+		informer, err = NewMetadataInformer(ctx, clients.Metadata, clients.Mapper, conf)
+	} else {
+		informer, err = NewInformer(ctx, clients.Dynamic, clients.Mapper, conf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.informers[key] = informer
+	go informer.Run(ctx.Done())
+	return informer, nil
+}
+
+// Len returns the number of informers.
+func (s *SharedInformers) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.informers)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher/types"
+)
+
+var podsGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+func newPod(name string, labels map[string]string, phase string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"phase": phase},
+	}}
+	u.SetAPIVersion("v1")
+	u.SetKind("Pod")
+	u.SetNamespace("default")
+	u.SetName(name)
+	u.SetLabels(labels)
+	// Create events are only fired for objects created after the controller
+	// started.
+	u.SetCreationTimestamp(metav1.NewTime(time.Now().Add(time.Hour)))
+	return u
+}
+
+func newFakeClient(objs ...runtime.Object) (*dynamicfake.FakeDynamicClient, meta.RESTMapper) {
+	cli := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{podsGVR: "PodList"}, objs...)
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	return cli, mapper
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) handler() eventhandler.EventHandler {
+	return func(_ string, event interface{}, data interface{}) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.events = append(r.events, fmt.Sprintf("%s %s", event.(types.Event).Type, data.(metav1.Object).GetName()))
+		return nil
+	}
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestSharedInformers(t *testing.T) {
+	ctx, cancel := context.WithCancel(multicluster.WithCluster(context.Background(), "local"))
+	defer cancel()
+	cli, mapper := newFakeClient()
+	informers := NewSharedInformers()
+
+	web, running := &recorder{}, &recorder{}
+	webCtrl := Setup(ctx, informers, Clients{Dynamic: cli, Mapper: mapper}, types.Config{
+		APIVersion: "v1", Kind: "Pod", Namespace: "default",
+		MatchingLabels: map[string]string{"app": "web"},
+	}, []eventhandler.EventHandler{web.handler()})
+	runningCtrl := Setup(ctx, informers, Clients{Dynamic: cli, Mapper: mapper}, types.Config{
+		APIVersion: "v1", Kind: "Pod", Namespace: "default",
+		MatchingFields: map[string]string{"status.phase": "Running"},
+	}, []eventhandler.EventHandler{running.handler()})
+	// Another namespace has its own informer.
+	_ = Setup(ctx, informers, Clients{Dynamic: cli, Mapper: mapper}, types.Config{APIVersion: "v1", Kind: "Pod", Namespace: "other"}, nil)
+	assert.Equal(t, 2, informers.Len())
+
+	go webCtrl.Run(ctx.Done())
+	go runningCtrl.Run(ctx.Done())
+	assert.Eventually(t, func() bool { return webCtrl.HasSynced() && runningCtrl.HasSynced() }, 5*time.Second, 10*time.Millisecond)
+
+	pods := cli.Resource(podsGVR).Namespace("default")
+	_, err := pods.Create(ctx, newPod("web-0", map[string]string{"app": "web"}, "Pending"), metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = pods.Create(ctx, newPod("db-0", map[string]string{"app": "db"}, "Running"), metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(web.get()) == 1 && len(running.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// web-0 starts running and matches the field selector, then loses its
+	// label.
+	_, err = pods.Update(ctx, newPod("web-0", map[string]string{"app": "web"}, "Running"), metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(web.get()) == 2 && len(running.get()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	_, err = pods.Update(ctx, newPod("web-0", nil, "Running"), metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(web.get()) == 3 && len(running.get()) == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"create web-0", "update web-0", "delete web-0"}, web.get())
+	assert.Equal(t, []string{"create db-0", "create web-0", "update web-0"}, running.get())
+}
+
+func TestSelector(t *testing.T) {
+	sel := newSelector(types.Config{
+		MatchingLabels: map[string]string{"app": "web"},
+		MatchingFields: map[string]string{"status.phase": "Running", "metadata.name": "web-0"},
+	})
+	assert.True(t, sel.matches(newPod("web-0", map[string]string{"app": "web", "tier": "fe"}, "Running")))
+	assert.False(t, sel.matches(newPod("web-0", map[string]string{"app": "web"}, "Pending")))
+	assert.False(t, sel.matches(newPod("web-1", map[string]string{"app": "web"}, "Running")))
+	assert.False(t, sel.matches(newPod("web-0", nil, "Running")))
+	assert.False(t, sel.matches("not an object"))
+}
+
+const (
+	benchTriggers = 40
+	benchPods     = 2000
+)
+
+func benchPodObjects() []runtime.Object {
+	objs := make([]runtime.Object, 0, benchPods)
+	for i := 0; i < benchPods; i++ {
+		// Half of the pods are selected by all triggers, like a shared
+		// label, the rest by one trigger each.
+		labels := map[string]string{"trigger": fmt.Sprint(i % benchTriggers)}
+		if i%2 == 0 {
+			labels = map[string]string{"tier": "all"}
+		}
+		objs = append(objs, newPod(fmt.Sprintf("pod-%d", i), labels, "Running"))
+	}
+	return objs
+}
+
+func benchConfig(i int) types.Config {
+	labels := map[string]string{"trigger": fmt.Sprint(i)}
+	if i%2 == 0 {
+		labels = map[string]string{"tier": "all"}
+	}
+	return types.Config{APIVersion: "v1", Kind: "Pod", Namespace: "default", MatchingLabels: labels}
+}
+
+// BenchmarkInformers compares syncing benchTriggers resource-watcher triggers
+// on Pods with one informer per trigger, as before, and with a shared
+// informer. objects/op is the number of objects held in informer caches.
+func BenchmarkInformers(b *testing.B) {
+	objs := benchPodObjects()
+
+	b.Run("separate", func(b *testing.B) {
+		b.ReportAllocs()
+		var cached int
+		for n := 0; n < b.N; n++ {
+			cli, mapper := newFakeClient(objs...)
+			ctx, cancel := context.WithCancel(context.Background())
+			var synced []cache.InformerSynced
+			var stores []cache.Store
+			for i := 0; i < benchTriggers; i++ {
+				informer, err := NewInformer(ctx, cli, mapper, benchConfig(i))
+				if err != nil {
+					b.Fatal(err)
+				}
+				go informer.Run(ctx.Done())
+				synced = append(synced, informer.HasSynced)
+				stores = append(stores, informer.GetStore())
+			}
+			cache.WaitForCacheSync(ctx.Done(), synced...)
+			cached = 0
+			for _, s := range stores {
+				cached += len(s.ListKeys())
+			}
+			cancel()
+		}
+		b.ReportMetric(float64(cached), "objects/op")
+	})
+
+	b.Run("shared", func(b *testing.B) {
+		b.ReportAllocs()
+		var cached int
+		for n := 0; n < b.N; n++ {
+			cli, mapper := newFakeClient(objs...)
+			ctx, cancel := context.WithCancel(multicluster.WithCluster(context.Background(), "local"))
+			informers := NewSharedInformers()
+			var synced []cache.InformerSynced
+			for i := 0; i < benchTriggers; i++ {
+				c := Setup(ctx, informers, Clients{Dynamic: cli, Mapper: mapper}, benchConfig(i), nil)
+				synced = append(synced, c.HasSynced)
+			}
+			cache.WaitForCacheSync(ctx.Done(), synced...)
+			informer, _ := informers.Get(ctx, "local", Clients{Dynamic: cli, Mapper: mapper}, benchConfig(0))
+			cached = len(informer.GetStore().ListKeys())
+			cancel()
+		}
+		b.ReportMetric(float64(cached), "objects/op")
+	})
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -133,6 +134,7 @@ func (w *K8sResourceWatcher) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	informers := controller.NewSharedInformers()
 	for k, config := range w.configs {
 		if len(config.Clusters) == 0 {
 			config.Clusters = []string{defaultCluster}
@@ -142,11 +144,17 @@ func (w *K8sResourceWatcher) Run(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
+			clients := controller.Clients{Dynamic: cli, Mapper: mapper}
+			if config.MetadataOnly {
+				if clients.Metadata, err = clusterGetter.GetMetadataClient(ctx, cluster); err != nil {
+					return err
+				}
+			}
 			multiCtx := multicluster.WithCluster(ctx, cluster)
-			go func(multiCtx context.Context, cli dynamic.Interface, mapper meta.RESTMapper, c *types.Config, handlers []eventhandler.EventHandler) {
-				resourceController := controller.Setup(multiCtx, cli, mapper, *c, handlers)
+			go func(multiCtx context.Context, clients controller.Clients, c *types.Config, handlers []eventhandler.EventHandler) {
+				resourceController := controller.Setup(multiCtx, informers, clients, *c, handlers)
 				resourceController.Run(multiCtx.Done())
-			}(multiCtx, cli, mapper, config, w.eventHandlers[k])
+			}(multiCtx, clients, config, w.eventHandlers[k])
 		}
 	}
 	return nil
@@ -165,6 +173,7 @@ func (w *K8sResourceWatcher) Singleton() bool {
 // MultiClustersGetter .
 type MultiClustersGetter interface {
 	GetDynamicClientAndMapper(ctx context.Context, cluster string) (dynamic.Interface, meta.RESTMapper, error)
+	GetMetadataClient(ctx context.Context, cluster string) (metadata.Interface, error)
 }
 
 // NewMultiClustersGetter new a MultiClustersGetter
@@ -190,6 +199,10 @@ func (c *clusterGatewayGetter) GetDynamicClientAndMapper(_ context.Context, _ st
 	return singleton.DynamicClient.Get(), singleton.RESTMapper.Get(), nil
 }
 
+func (c *clusterGatewayGetter) GetMetadataClient(_ context.Context, _ string) (metadata.Interface, error) {
+	return metadata.NewForConfig(singleton.KubeConfig.Get())
+}
+
 type clusterGatewaySecretGetter struct {
 	cli    client.Client
 	config *rest.Config
@@ -207,6 +220,17 @@ func (c *clusterGatewaySecretGetter) GetDynamicClientAndMapper(ctx context.Conte
 	return c.getDynamicClientAndMapperFromConfig(ctx, config)
 }
 
+func (c *clusterGatewaySecretGetter) GetMetadataClient(ctx context.Context, cluster string) (metadata.Interface, error) {
+	if cluster == defaultCluster {
+		return metadata.NewForConfig(c.config)
+	}
+	config, err := c.getRestConfigFromSecret(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.NewForConfig(config)
+}
+
 func (c *clusterGatewaySecretGetter) getDynamicClientAndMapperFromConfig(_ context.Context, config *rest.Config) (dynamic.Interface, meta.RESTMapper, error) {
 	cli, err := dynamic.NewForConfig(config)
 	if err != nil {
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sresourcewatcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/encoding/jsonschema"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sourcetypes "github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+var _ sourcetypes.SchemaSource = &K8sResourceWatcher{}
+
+// eventSchema is the schema of types.Event.
+const eventSchema = `
+type:     "create" | "update" | "delete"
+cluster:  string
+manager?: string
+changedFields?: [...{
+	manager:      string
+	operation:    string
+	subresource?: string
+	time?:        string
+	fields?: [...string]
+}]
+`
+
+// metadataSchema is the schema of objects watched with metadataOnly.
+const metadataSchema = `
+apiVersion: string
+kind:       string
+metadata: {...}
+`
+
+var crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+// Schema implements sourcetypes.SchemaSource. The schema of objects is
+// derived from the OpenAPI schema in the CRD of their kind. Objects of kinds
+// without CRDs, like Deployments, are not checked.
+func (w *K8sResourceWatcher) Schema(ctx context.Context, cli client.Client, properties *runtime.RawExtension) (*sourcetypes.Schema, error) {
+	conf, err := w.Parse(properties)
+	if err != nil {
+		return nil, err
+	}
+	s := &sourcetypes.Schema{Event: eventSchema}
+	if len(conf.Fields) > 0 {
+		s.Data = projectedSchema(conf.Fields, conf.MetadataOnly)
+		return s, nil
+	}
+	if conf.MetadataOnly {
+		s.Data = metadataSchema
+		return s, nil
+	}
+	if s.Data, err = crdSchema(ctx, cli, conf.APIVersion, conf.Kind); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// projectedSchema is the schema of objects projected to fields. Fields can be
+// anything, since kinds without CRDs have no schema. Only metadata fields are
+// kept if metadataOnly.
+func projectedSchema(fields []string, metadataOnly bool) string {
+	root := schemaNode{
+		"apiVersion": nil,
+		"kind":       nil,
+		"metadata":   schemaNode{"name": nil, "namespace": nil},
+	}
+	for _, f := range fields {
+		path := strings.Split(f, ".")
+		if metadataOnly && path[0] != "metadata" {
+			continue
+		}
+		root.add(path)
+	}
+	b := &strings.Builder{}
+	root.write(b, "")
+	return b.String()
+}
+
+// schemaNode is a field with subfields. A nil node is a whole field.
+type schemaNode map[string]schemaNode
+
+func (n schemaNode) add(path []string) {
+	child, ok := n[path[0]]
+	if ok && child == nil {
+		return
+	}
+	if len(path) == 1 {
+		n[path[0]] = nil
+		return
+	}
+	if !ok {
+		child = schemaNode{}
+		n[path[0]] = child
+	}
+	child.add(path[1:])
+}
+
+func (n schemaNode) write(b *strings.Builder, indent string) {
+	keys := make([]string, 0, len(n))
+	for k := range n {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(indent + strconv.Quote(k) + "?: ")
+		if n[k] == nil {
+			b.WriteString("_\n")
+			continue
+		}
+		b.WriteString("{\n")
+		n[k].write(b, indent+"\t")
+		b.WriteString(indent + "}\n")
+	}
+}
+
+// crdSchema gets the schema of a kind from its CRD in CUE, or "" if it has no
+// CRD.
+func crdSchema(ctx context.Context, cli client.Client, apiVersion, kind string) (string, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return "", err
+	}
+	if gv.Group == "" {
+		return "", nil
+	}
+	mapping, err := cli.RESTMapper().RESTMapping(schema.GroupKind{Group: gv.Group, Kind: kind}, gv.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	if err := cli.Get(ctx, client.ObjectKey{Name: mapping.Resource.Resource + "." + gv.Group}, crd); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		v, ok := v.(map[string]interface{})
+		if !ok || v["name"] != gv.Version {
+			continue
+		}
+		openAPI, ok, _ := unstructured.NestedMap(v, "schema", "openAPIV3Schema")
+		if !ok {
+			return "", nil
+		}
+		// CRDs do not have the schema of metadata.
+		if err := unstructured.SetNestedField(openAPI, map[string]interface{}{
+			"type":                                 "object",
+			"x-kubernetes-preserve-unknown-fields": true,
+		}, "properties", "metadata"); err != nil {
+			return "", err
+		}
+		f, err := jsonschema.Extract(cuecontext.New().Encode(openAPI), &jsonschema.Config{
+			DefaultVersion: jsonschema.VersionKubernetesCRD,
+		})
+		if err != nil {
+			return "", fmt.Errorf("cannot convert the schema of %s: %w", crd.GetName(), err)
+		}
+		b, err := format.Node(f)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", nil
+}
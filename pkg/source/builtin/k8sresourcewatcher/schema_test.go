@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sresourcewatcher
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubevela/kube-trigger/pkg/filter"
+)
+
+func TestSchema(t *testing.T) {
+	b, err := os.ReadFile("../../../../config/crd/standard.oam.dev_triggerservices.yaml")
+	require.NoError(t, err)
+	crd := &unstructured.Unstructured{}
+	require.NoError(t, yaml.Unmarshal(b, &crd.Object))
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "standard.oam.dev", Version: "v1alpha1", Kind: "TriggerService"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithObjects(crd).Build()
+
+	w := &K8sResourceWatcher{}
+	properties := func(s string) *runtime.RawExtension {
+		return &runtime.RawExtension{Raw: []byte(s)}
+	}
+	testcases := map[string]struct {
+		properties string
+		filter     string
+		unknown    string
+	}{
+		"from the CRD": {
+			properties: `{"apiVersion": "standard.oam.dev/v1alpha1", "kind": "TriggerService"}`,
+			filter:     `context.event.type == "update" && context.data.metadata.name == "t" && context.data.spec.triggers[0].action.type == "task"`,
+		},
+		"unknown field in the CRD": {
+			properties: `{"apiVersion": "standard.oam.dev/v1alpha1", "kind": "TriggerService"}`,
+			filter:     `context.data.spec.triggers[0].actions.type == "task"`,
+			unknown:    "context.data.spec.triggers[0].actions",
+		},
+		"unknown field of events": {
+			properties: `{"apiVersion": "apps/v1", "kind": "Deployment"}`,
+			filter:     `context.event.kind == "update"`,
+			unknown:    "context.event.kind",
+		},
+		"kind without a CRD": {
+			properties: `{"apiVersion": "apps/v1", "kind": "Deployment"}`,
+			filter:     `context.data.spec.replicaz > 1`,
+		},
+		"projected fields": {
+			properties: `{"apiVersion": "standard.oam.dev/v1alpha1", "kind": "TriggerService", "fields": ["status", "metadata.labels"]}`,
+			filter:     `context.data.kind == "TriggerService" && context.data.metadata.name == "t" && context.data.metadata.labels.app == "a" && context.data.status.x == 1`,
+		},
+		"field not projected": {
+			properties: `{"apiVersion": "standard.oam.dev/v1alpha1", "kind": "TriggerService", "fields": ["status", "metadata.labels"]}`,
+			filter:     `context.data.spec.triggers[0].action.type == "task"`,
+			unknown:    "context.data.spec",
+		},
+		"metadata field not projected": {
+			properties: `{"apiVersion": "apps/v1", "kind": "Deployment", "fields": ["metadata.labels"]}`,
+			filter:     `context.data.metadata.annotations.a == "b"`,
+			unknown:    "context.data.metadata.annotations",
+		},
+		"metadata only": {
+			properties: `{"apiVersion": "standard.oam.dev/v1alpha1", "kind": "TriggerService", "metadataOnly": true}`,
+			filter:     `context.data.spec.triggers[0].action.type == "task"`,
+			unknown:    "context.data.spec",
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			s, err := w.Schema(context.Background(), cli, properties(tc.properties))
+			require.NoError(t, err)
+			err = filter.CheckReferences(tc.filter, s.Event, s.Data)
+			if tc.unknown == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.unknown)
+			}
+		})
+	}
+}
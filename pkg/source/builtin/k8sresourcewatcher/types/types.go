@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/kubevela/pkg/util/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Config is the config for resource controller
@@ -30,7 +31,22 @@ type Config struct {
 	Namespace      string            `json:"namespace,omitempty"`
 	Events         []EventType       `json:"events,omitempty"`
 	MatchingLabels map[string]string `json:"matchingLabels,omitempty"`
+	// MatchingFields selects objects by the string values of fields, keyed by
+	// dot-separated paths, e.g. status.phase or spec.nodeName.
+	MatchingFields map[string]string `json:"matchingFields,omitempty"`
 	Clusters       []string          `json:"clusters,omitempty"`
+	// MetadataOnly watches and caches only the metadata of objects, using the
+	// metadata client. Filters and actions only see apiVersion, kind and
+	// metadata, and matchingFields can only select metadata fields.
+	MetadataOnly bool `json:"metadataOnly,omitempty"`
+	// Fields are dot-separated paths of the fields to keep in the object passed
+	// to filters and actions, e.g. metadata.labels or status.phase. apiVersion,
+	// kind, metadata.name and metadata.namespace are always kept. The whole
+	// object is passed if empty.
+	Fields []string `json:"fields,omitempty"`
+	// IgnoreManagers are field managers whose changes do not fire update
+	// events, e.g. the manager of kube-trigger itself to avoid trigger loops.
+	IgnoreManagers []string `json:"ignoreManagers,omitempty"`
 }
 
 // Key returns the identifier of a Config.
@@ -41,7 +57,23 @@ func (c *Config) Key() string {
 			labels = string(b)
 		}
 	}
-	return strings.Join([]string{c.APIVersion, c.Kind, c.Namespace, labels}, "-")
+	key := []string{c.APIVersion, c.Kind, c.Namespace, labels}
+	if len(c.MatchingFields) > 0 {
+		if b, err := json.Marshal(c.MatchingFields); err == nil {
+			key = append(key, string(b))
+		}
+	}
+	if c.MetadataOnly {
+		key = append(key, "metadataOnly")
+	}
+	if len(c.Fields) > 0 {
+		key = append(key, strings.Join(c.Fields, ","))
+	}
+	// Configs ignoring different managers cannot be merged.
+	if len(c.IgnoreManagers) > 0 {
+		key = append(key, strings.Join(c.IgnoreManagers, ","))
+	}
+	return strings.Join(key, "-")
 }
 
 // Merge merges two Configs.
@@ -73,10 +105,30 @@ const (
 type Event struct {
 	Type    EventType `json:"type"`
 	Cluster string    `json:"cluster"`
+	// Manager is the field manager that made the latest change of an update
+	// event, found by comparing managedFields of the old and new object.
+	Manager string `json:"manager,omitempty"`
+	// ChangedFields are the managedFields entries changed by an update event.
+	ChangedFields []FieldsChange `json:"changedFields,omitempty"`
+}
+
+// FieldsChange is a managedFields entry changed by an update.
+type FieldsChange struct {
+	Manager     string       `json:"manager"`
+	Operation   string       `json:"operation"`
+	Subresource string       `json:"subresource,omitempty"`
+	Time        *metav1.Time `json:"time,omitempty"`
+	// Fields are the paths of fields the manager started or stopped owning
+	// with the update, e.g. .spec.replicas. All the fields it owns are listed
+	// when the manager is new. They are empty when the manager only changed
+	// values of fields it already owned, which managedFields do not tell.
+	Fields []string `json:"fields,omitempty"`
 }
 
 // InformerEvent indicate the informerEvent
 type InformerEvent struct {
 	Event
 	EventObj interface{}
+	// OldObj is the object before an update.
+	OldObj interface{}
 }
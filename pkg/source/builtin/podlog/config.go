@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podlog
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultRateLimitInterval = 10 * time.Second
+	defaultRateLimitBurst    = 1
+)
+
+// Config is the config for PodLogWatcher.
+type Config struct {
+	// Namespace of the pods. All namespaces are watched if it is empty.
+	Namespace string `json:"namespace,omitempty"`
+	// MatchingLabels selects the pods by labels.
+	MatchingLabels map[string]string `json:"matchingLabels,omitempty"`
+	// Containers limits the followed containers by name. All containers are
+	// followed if it is empty.
+	Containers []string `json:"containers,omitempty"`
+	// Patterns are matched against each log line. A line fires an event for
+	// each pattern it matches.
+	Patterns []Pattern `json:"patterns"`
+	// RateLimit limits the events fired for each pod.
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+}
+
+// Pattern describes log lines of interest. If both Regex and JSON are set,
+// a line must satisfy both.
+type Pattern struct {
+	// Name identifies the pattern in events.
+	Name string `json:"name"`
+	// Regex is matched against the whole line. Capture groups are passed to
+	// filters and actions.
+	Regex string `json:"regex,omitempty"`
+	// JSON are conditions on structured JSON lines. Keys are dot-separated
+	// field paths, e.g. error.code, and values are regexes the field values
+	// must match. Lines that are not JSON objects never match.
+	JSON map[string]string `json:"json,omitempty"`
+}
+
+// RateLimit is a token bucket, allowing Burst events at once and one more
+// event every Interval.
+type RateLimit struct {
+	Interval string `json:"interval,omitempty"`
+	Burst    int    `json:"burst,omitempty"`
+}
+
+func (c *Config) rateLimit() (time.Duration, int, error) {
+	interval, burst := defaultRateLimitInterval, defaultRateLimitBurst
+	if c.RateLimit == nil {
+		return interval, burst, nil
+	}
+	if c.RateLimit.Interval != "" {
+		d, err := time.ParseDuration(c.RateLimit.Interval)
+		if err != nil || d < 0 {
+			return 0, 0, fmt.Errorf("invalid rateLimit.interval %q", c.RateLimit.Interval)
+		}
+		interval = d
+	}
+	if c.RateLimit.Burst < 0 {
+		return 0, 0, fmt.Errorf("rateLimit.burst must be greater or equal to 0")
+	}
+	if c.RateLimit.Burst > 0 {
+		burst = c.RateLimit.Burst
+	}
+	return interval, burst, nil
+}
+
+func (c *Config) followContainer(name string) bool {
+	if len(c.Containers) == 0 {
+		return true
+	}
+	for _, n := range c.Containers {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
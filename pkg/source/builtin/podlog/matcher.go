@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Match is what a Pattern found in a line.
+type Match struct {
+	Pattern string `json:"pattern"`
+	// Groups are the capture groups of the regex, the whole match first.
+	Groups []string `json:"groups,omitempty"`
+	// NamedGroups are the named capture groups of the regex.
+	NamedGroups map[string]string `json:"namedGroups,omitempty"`
+	// JSON is the parsed line if the pattern has JSON conditions.
+	JSON map[string]interface{} `json:"json,omitempty"`
+}
+
+type fieldCondition struct {
+	path []string
+	re   *regexp.Regexp
+}
+
+type matcher struct {
+	name   string
+	re     *regexp.Regexp
+	fields []fieldCondition
+}
+
+func compilePatterns(patterns []Pattern) ([]matcher, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no patterns specified")
+	}
+	var ret []matcher
+	for i, p := range patterns {
+		if p.Regex == "" && len(p.JSON) == 0 {
+			return nil, fmt.Errorf("pattern %d has neither regex nor json", i)
+		}
+		m := matcher{name: p.Name}
+		if m.name == "" {
+			m.name = fmt.Sprintf("pattern-%d", i)
+		}
+		if p.Regex != "" {
+			re, err := regexp.Compile(p.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex in pattern %s: %w", m.name, err)
+			}
+			m.re = re
+		}
+		for path, expr := range p.JSON {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid json condition %s in pattern %s: %w", path, m.name, err)
+			}
+			m.fields = append(m.fields, fieldCondition{path: strings.Split(path, "."), re: re})
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
+// match returns the Match of line, or nil if line does not match.
+func (m *matcher) match(line string) *Match {
+	ret := &Match{Pattern: m.name}
+	if m.re != nil {
+		groups := m.re.FindStringSubmatch(line)
+		if groups == nil {
+			return nil
+		}
+		ret.Groups = groups
+		for i, name := range m.re.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			if ret.NamedGroups == nil {
+				ret.NamedGroups = make(map[string]string)
+			}
+			ret.NamedGroups[name] = groups[i]
+		}
+	}
+	if len(m.fields) > 0 {
+		obj := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			return nil
+		}
+		for _, f := range m.fields {
+			v, ok := lookup(obj, f.path)
+			if !ok || !f.re.MatchString(v) {
+				return nil
+			}
+		}
+		ret.JSON = obj
+	}
+	return ret
+}
+
+// lookup returns the value at path in obj, formatted as a string.
+func lookup(obj map[string]interface{}, path []string) (string, bool) {
+	var cur interface{} = obj
+	for _, p := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		cur, ok = m[p]
+		if !ok {
+			return "", false
+		}
+	}
+	switch v := cur.(type) {
+	case string:
+		return v, true
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		return string(b), err == nil
+	default:
+		return fmt.Sprint(v), true
+	}
+}
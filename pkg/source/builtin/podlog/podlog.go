@@ -0,0 +1,372 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/kubevela/kube-trigger/pkg/eventhandler"
+	"github.com/kubevela/kube-trigger/pkg/source/types"
+)
+
+func init() {
+	logger = logrus.WithField("source", podLogWatcherType)
+}
+
+var (
+	logger            *logrus.Entry
+	podLogWatcherType = "pod-log-watcher"
+)
+
+// maxLineSize is the longest log line that is matched. Longer lines are
+// dropped.
+const maxLineSize = 1024 * 1024
+
+// minRetryInterval and maxRetryInterval bound the backoff before following
+// logs again, when their stream ends while the container is still running.
+const (
+	minRetryInterval = time.Second
+	maxRetryInterval = time.Minute
+)
+
+// Event is the brief event passed to filters and actions.
+type Event struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Pattern   string `json:"pattern"`
+}
+
+// Data is the matched log line passed to filters and actions.
+type Data struct {
+	Match `json:",inline"`
+	Line  string `json:"line"`
+}
+
+// PodLogWatcher follows logs of pods and fires events when lines match
+// patterns.
+type PodLogWatcher struct {
+	config   Config
+	matchers []matcher
+	interval time.Duration
+	burst    int
+	eh       eventhandler.EventHandler
+	// retryInterval is the first backoff before following logs again.
+	retryInterval time.Duration
+
+	clientset kubernetes.Interface
+	started   time.Time
+
+	mu sync.Mutex
+	// followers are keyed by namespace/pod/container.
+	followers map[string]*follower
+	// limiters are keyed by namespace/pod.
+	limiters map[string]*rate.Limiter
+}
+
+type follower struct {
+	containerID string
+	cancel      context.CancelFunc
+}
+
+var _ types.Source = &PodLogWatcher{}
+
+// New creates a new PodLogWatcher.
+func (w *PodLogWatcher) New() types.Source {
+	return &PodLogWatcher{
+		retryInterval: minRetryInterval,
+		followers:     make(map[string]*follower),
+		limiters:      make(map[string]*rate.Limiter),
+	}
+}
+
+// Init initializes the PodLogWatcher.
+func (w *PodLogWatcher) Init(properties *runtime.RawExtension, eh eventhandler.EventHandler) error {
+	b, err := properties.MarshalJSON()
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	err = json.Unmarshal(b, &w.config)
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	w.matchers, err = compilePatterns(w.config.Patterns)
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing patterns for %s", w.Type())
+	}
+	w.interval, w.burst, err = w.config.rateLimit()
+	if err != nil {
+		return errors.Wrapf(err, "error when parsing properties for %s", w.Type())
+	}
+	w.eh = eh
+	return nil
+}
+
+// Run starts the PodLogWatcher.
+func (w *PodLogWatcher) Run(ctx context.Context) error {
+	if w.clientset == nil {
+		config, err := ctrl.GetConfig()
+		if err != nil {
+			return errors.Wrapf(err, "cannot get kubeconfig for %s", w.Type())
+		}
+		cs, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return err
+		}
+		w.clientset = cs
+	}
+	w.started = time.Now()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(w.clientset, 0,
+		informers.WithNamespace(w.config.Namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			if len(w.config.MatchingLabels) > 0 {
+				options.LabelSelector = labels.FormatLabels(w.config.MatchingLabels)
+			}
+		}),
+	)
+	//nolint:errcheck // no need to check err here
+	factory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				w.syncPod(ctx, pod)
+			}
+		},
+		UpdateFunc: func(_, new interface{}) {
+			if pod, ok := new.(*corev1.Pod); ok {
+				w.syncPod(ctx, pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				w.removePod(pod)
+			}
+		},
+	})
+	factory.Start(ctx.Done())
+	logger.Infof("pod-log-watcher started, namespace %q, labels %v", w.config.Namespace, w.config.MatchingLabels)
+	return nil
+}
+
+// syncPod starts following the running containers of pod. A restarted
+// container has a new container ID, so it is followed again.
+func (w *PodLogWatcher) syncPod(ctx context.Context, pod *corev1.Pod) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, cs := range pod.Status.ContainerStatuses {
+		if !w.config.followContainer(cs.Name) || cs.State.Running == nil {
+			continue
+		}
+		key := pod.Namespace + "/" + pod.Name + "/" + cs.Name
+		if f, ok := w.followers[key]; ok {
+			if f.containerID == cs.ContainerID {
+				continue
+			}
+			f.cancel()
+		}
+		// Containers that were already running when we started are followed
+		// from now on, others from their beginning.
+		opts := &corev1.PodLogOptions{Container: cs.Name, Follow: true}
+		if !cs.State.Running.StartedAt.Time.After(w.started) {
+			var tail int64
+			opts.TailLines = &tail
+		}
+		followCtx, cancel := context.WithCancel(ctx)
+		f := &follower{containerID: cs.ContainerID, cancel: cancel}
+		w.followers[key] = f
+		go w.follow(followCtx, pod.Namespace, pod.Name, opts, f)
+	}
+}
+
+// unfollow forgets f when the container it follows is no longer running, so
+// that syncPod follows the container again when it runs.
+func (w *PodLogWatcher) unfollow(key string, f *follower) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.followers[key] == f {
+		delete(w.followers, key)
+	}
+	f.cancel()
+}
+
+// removePod stops following all containers of pod.
+func (w *PodLogWatcher) removePod(pod *corev1.Pod) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	prefix := pod.Namespace + "/" + pod.Name + "/"
+	for key, f := range w.followers {
+		if strings.HasPrefix(key, prefix) {
+			f.cancel()
+			delete(w.followers, key)
+		}
+	}
+	delete(w.limiters, pod.Namespace+"/"+pod.Name)
+}
+
+// follow follows the logs of a container of f, until ctx is cancelled or the
+// container is no longer running. Logs are followed again, with a backoff and
+// from where they stopped, when their stream ends while the container is
+// still running, e.g. because the connection to the API server is lost.
+func (w *PodLogWatcher) follow(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions, f *follower) {
+	key := namespace + "/" + pod + "/" + opts.Container
+	l := logger.WithField("container", key)
+	backoff := w.retryInterval
+	for {
+		read, err := w.stream(ctx, namespace, pod, opts)
+		if ctx.Err() != nil {
+			l.Debugf("stopped following logs")
+			return
+		}
+		if err != nil {
+			l.Errorf("cannot follow logs: %s", err)
+		}
+		since := metav1.Now()
+		if read {
+			backoff = w.retryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxRetryInterval {
+			backoff = maxRetryInterval
+		}
+		running, err := w.running(ctx, namespace, pod, opts.Container, f.containerID)
+		if err != nil {
+			l.Errorf("cannot get pod: %s", err)
+			continue
+		}
+		if !running {
+			l.Debugf("stopped following logs because the container is not running")
+			w.unfollow(key, f)
+			return
+		}
+		l.Debugf("following logs again since %s", since)
+		opts = opts.DeepCopy()
+		opts.TailLines = nil
+		opts.SinceTime = &since
+	}
+}
+
+// stream reads the logs of a container until their stream ends, and returns
+// whether any line was read.
+func (w *PodLogWatcher) stream(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (bool, error) {
+	stream, err := w.clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close()
+	logger.Debugf("following logs of %s/%s/%s", namespace, pod, opts.Container)
+
+	read := false
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		read = true
+		w.handleLine(namespace, pod, opts.Container, scanner.Text())
+	}
+	return read, scanner.Err()
+}
+
+// running checks whether the container with containerID is still running.
+func (w *PodLogWatcher) running(ctx context.Context, namespace, pod, container, containerID string) (bool, error) {
+	p, err := w.clientset.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.Name == container {
+			return cs.ContainerID == containerID && cs.State.Running != nil, nil
+		}
+	}
+	return false, nil
+}
+
+func (w *PodLogWatcher) handleLine(namespace, pod, container, line string) {
+	for i := range w.matchers {
+		m := w.matchers[i].match(line)
+		if m == nil {
+			continue
+		}
+		if !w.limiter(namespace + "/" + pod).Allow() {
+			logger.Debugf("line of %s/%s/%s matching %s is dropped because of rate limiting", namespace, pod, container, m.Pattern)
+			continue
+		}
+		e := Event{
+			Namespace: namespace,
+			Pod:       pod,
+			Container: container,
+			Pattern:   m.Pattern,
+		}
+		logger.Infof("line of %s/%s/%s matched %s, calling event handlers", namespace, pod, container, m.Pattern)
+		err := w.eh(w.Type(), e, Data{Match: *m, Line: line})
+		if err != nil {
+			logger.Infof("calling event handler failed: %s", err)
+		}
+	}
+}
+
+func (w *PodLogWatcher) limiter(key string) *rate.Limiter {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	l, ok := w.limiters[key]
+	if !ok {
+		limit := rate.Inf
+		if w.interval > 0 {
+			limit = rate.Every(w.interval)
+		}
+		l = rate.NewLimiter(limit, w.burst)
+		w.limiters[key] = l
+	}
+	return l
+}
+
+// Type returns the type of the PodLogWatcher.
+func (w *PodLogWatcher) Type() string {
+	return podLogWatcherType
+}
+
+// Singleton .
+func (w *PodLogWatcher) Singleton() bool {
+	return false
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestWatcher(t *testing.T, props string, eh func(e Event, d Data)) *PodLogWatcher {
+	w := (&PodLogWatcher{}).New().(*PodLogWatcher)
+	err := w.Init(&runtime.RawExtension{Raw: []byte(props)}, func(_ string, event interface{}, data interface{}) error {
+		eh(event.(Event), data.(Data))
+		return nil
+	})
+	assert.NoError(t, err)
+	return w
+}
+
+func TestMatcher(t *testing.T) {
+	matchers, err := compilePatterns([]Pattern{
+		{Name: "oom", Regex: `OutOfMemoryError: (?P<area>\w+)`},
+		{Name: "fatal", JSON: map[string]string{"level": "^fatal$", "error.code": "^5\\d\\d$"}},
+		{Regex: "panic", JSON: map[string]string{"msg": "panic"}},
+	})
+	assert.NoError(t, err)
+
+	m := matchers[0].match("java.lang.OutOfMemoryError: heap space")
+	assert.NotNil(t, m)
+	assert.Equal(t, "oom", m.Pattern)
+	assert.Equal(t, []string{"OutOfMemoryError: heap", "heap"}, m.Groups)
+	assert.Equal(t, map[string]string{"area": "heap"}, m.NamedGroups)
+	assert.Nil(t, matchers[0].match("all good"))
+
+	m = matchers[1].match(`{"level":"fatal","error":{"code":503}}`)
+	assert.NotNil(t, m)
+	assert.Equal(t, "fatal", m.JSON["level"])
+	assert.Nil(t, matchers[1].match(`{"level":"fatal","error":{"code":404}}`))
+	assert.Nil(t, matchers[1].match(`{"level":"fatal"}`))
+	assert.Nil(t, matchers[1].match(`fatal 503`))
+
+	assert.Equal(t, "pattern-2", matchers[2].name)
+	assert.NotNil(t, matchers[2].match(`{"msg":"panic: oops"}`))
+
+	_, err = compilePatterns(nil)
+	assert.Error(t, err)
+	_, err = compilePatterns([]Pattern{{Name: "empty"}})
+	assert.Error(t, err)
+	_, err = compilePatterns([]Pattern{{Regex: "("}})
+	assert.Error(t, err)
+}
+
+func TestPodLogWatcher_RateLimit(t *testing.T) {
+	var events []Event
+	w := newTestWatcher(t, `{"patterns":[{"name":"err","regex":"error"}],"rateLimit":{"interval":"1h","burst":2}}`,
+		func(e Event, _ Data) {
+			events = append(events, e)
+		})
+	for i := 0; i < 5; i++ {
+		w.handleLine("default", "pod-a", "app", "error")
+	}
+	w.handleLine("default", "pod-b", "app", "error")
+	w.handleLine("default", "pod-b", "app", "fine")
+	assert.Len(t, events, 3)
+	assert.Equal(t, "pod-b", events[2].Pod)
+}
+
+func TestPodLogWatcher_Follow(t *testing.T) {
+	got := make(chan Data, 10)
+	w := newTestWatcher(t, `{"containers":["app"],"rateLimit":{"interval":"0s"},"patterns":[{"name":"fake","regex":"fake (?P<what>\\w+)"}]}`,
+		func(_ Event, d Data) {
+			got <- d
+		})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", ContainerID: "c1", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.Now()}}},
+			{Name: "sidecar", ContainerID: "c2", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		}},
+	}
+	w.clientset = fake.NewSimpleClientset(pod)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w.syncPod(ctx, pod)
+	select {
+	case d := <-got:
+		assert.Equal(t, "fake logs", d.Line)
+		assert.Equal(t, "logs", d.NamedGroups["what"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the log line")
+	}
+	assert.Len(t, w.followers, 1)
+
+	// Same container is not followed twice.
+	w.syncPod(ctx, pod)
+	assert.Equal(t, "c1", w.followers["default/pod/app"].containerID)
+
+	// Restarted container is followed again.
+	restarted := pod.DeepCopy()
+	restarted.Status.ContainerStatuses[0].ContainerID = "c3"
+	_, err := w.clientset.CoreV1().Pods("default").UpdateStatus(ctx, restarted, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	w.syncPod(ctx, restarted)
+	assert.Equal(t, "c3", w.followers["default/pod/app"].containerID)
+	select {
+	case <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the log line")
+	}
+
+	w.removePod(pod)
+	assert.Empty(t, w.followers)
+}
+
+func TestPodLogWatcher_FollowAgain(t *testing.T) {
+	got := make(chan Data, 10)
+	w := newTestWatcher(t, `{"rateLimit":{"interval":"0s"},"patterns":[{"name":"fake","regex":"fake"}]}`,
+		func(_ Event, d Data) {
+			got <- d
+		})
+	w.retryInterval = 10 * time.Millisecond
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", ContainerID: "c1", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.Now()}}},
+		}},
+	}
+	w.clientset = fake.NewSimpleClientset(pod)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Logs are followed again when their stream ends while the container is
+	// running.
+	w.syncPod(ctx, pod)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-got:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the log line")
+		}
+	}
+
+	// The follower is forgotten when the container stops, so that it is
+	// followed again when it runs.
+	stopped := pod.DeepCopy()
+	stopped.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}
+	_, err := w.clientset.CoreV1().Pods("default").UpdateStatus(ctx, stopped, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		for len(got) > 0 {
+			<-got
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return len(w.followers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	w.syncPod(ctx, pod)
+	w.mu.Lock()
+	assert.Len(t, w.followers, 1)
+	w.mu.Unlock()
+}
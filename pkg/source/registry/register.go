@@ -17,8 +17,14 @@ limitations under the License.
 package registry
 
 import (
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/admissionwebhook"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/appwatcher"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/auditwebhook"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/certexpiry"
 	"github.com/kubevela/kube-trigger/pkg/source/builtin/cronjob"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/helmrelease"
 	"github.com/kubevela/kube-trigger/pkg/source/builtin/k8sresourcewatcher"
+	"github.com/kubevela/kube-trigger/pkg/source/builtin/podlog"
 	"github.com/kubevela/kube-trigger/pkg/source/types"
 )
 
@@ -26,6 +32,12 @@ import (
 func RegisterBuiltinSources(reg *Registry) {
 	registerFromInstance(reg, &k8sresourcewatcher.K8sResourceWatcher{})
 	registerFromInstance(reg, &cronjob.CronJob{})
+	registerFromInstance(reg, &certexpiry.CertExpiryWatcher{})
+	registerFromInstance(reg, &podlog.PodLogWatcher{})
+	registerFromInstance(reg, &admissionwebhook.AdmissionWebhook{})
+	registerFromInstance(reg, &auditwebhook.AuditWebhook{})
+	registerFromInstance(reg, &appwatcher.ApplicationWatcher{})
+	registerFromInstance(reg, &helmrelease.HelmReleaseWatcher{})
 }
 
 func registerFromInstance(reg *Registry, act types.Source) {
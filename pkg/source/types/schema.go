@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectSchema is the schema of Kubernetes objects of any kind.
+const ObjectSchema = `
+apiVersion: string
+kind:       string
+metadata: {...}
+...
+`
+
+var (
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	// timeTypes are marshalled as strings.
+	timeTypes = map[reflect.Type]bool{
+		reflect.TypeOf(time.Time{}):        true,
+		reflect.TypeOf(metav1.Time{}):      true,
+		reflect.TypeOf(metav1.MicroTime{}): true,
+	}
+)
+
+// SchemaOf derives the schema of v, an event or data of a Source, from its Go
+// type, following the rules of encoding/json. Fields with omitempty or of
+// pointer types are optional. Maps, interfaces, and types marshalled by
+// themselves are not checked below.
+func SchemaOf(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	b := &strings.Builder{}
+	if t.Kind() != reflect.Struct {
+		b.WriteString("...\n")
+		return b.String()
+	}
+	writeFields(b, t, "", map[reflect.Type]bool{})
+	return b.String()
+}
+
+// writeFields writes the fields of struct t, with embedded structs inlined.
+func writeFields(b *strings.Builder, t reflect.Type, indent string, seen map[reflect.Type]bool) {
+	type field struct {
+		name     string
+		optional bool
+		t        reflect.Type
+	}
+	var fields []field
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			ft := f.Type
+			if f.Anonymous && name == "" {
+				for ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					collect(ft)
+					continue
+				}
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			optional := strings.Contains(","+opts+",", ",omitempty,") || ft.Kind() == reflect.Pointer
+			fields = append(fields, field{name: name, optional: optional, t: ft})
+		}
+	}
+	collect(t)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+	for _, f := range fields {
+		b.WriteString(indent + strconv.Quote(f.name))
+		if f.optional {
+			b.WriteString("?")
+		}
+		b.WriteString(": ")
+		writeType(b, f.t, indent, seen)
+		b.WriteString("\n")
+	}
+}
+
+// writeType writes the schema of a value of t.
+func writeType(b *strings.Builder, t reflect.Type, indent string, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case timeTypes[t]:
+		b.WriteString("string")
+		return
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		b.WriteString("_")
+		return
+	}
+	switch t.Kind() {
+	case reflect.String:
+		b.WriteString("string")
+	case reflect.Bool:
+		b.WriteString("bool")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString("int")
+	case reflect.Float32, reflect.Float64:
+		b.WriteString("number")
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b.WriteString("string")
+			return
+		}
+		b.WriteString("[...")
+		writeType(b, t.Elem(), indent, seen)
+		b.WriteString("]")
+	case reflect.Map:
+		b.WriteString("{...}")
+	case reflect.Struct:
+		// Recursive types are not checked below the first level.
+		if seen[t] {
+			b.WriteString("_")
+			return
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		b.WriteString("{\n")
+		writeFields(b, t, indent+"\t", seen)
+		b.WriteString(indent + "}")
+	default:
+		b.WriteString("_")
+	}
+}
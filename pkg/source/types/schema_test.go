@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubevela/kube-trigger/pkg/filter"
+)
+
+type testInner struct {
+	Name string `json:"name"`
+}
+
+type testNode struct {
+	Next *testNode `json:"next,omitempty"`
+}
+
+type testEvent struct {
+	testInner `json:",inline"`
+	Type      string                 `json:"type"`
+	Count     int                    `json:"count,omitempty"`
+	Time      metav1.Time            `json:"time"`
+	Labels    map[string]string      `json:"labels"`
+	Items     []testInner            `json:"items"`
+	Object    *runtime.RawExtension  `json:"object"`
+	Any       interface{}            `json:"any"`
+	Node      testNode               `json:"node"`
+	Ignored   string                 `json:"-"`
+	hidden    string                 //nolint:unused
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := SchemaOf(&testEvent{})
+	testcases := map[string]struct {
+		src   string
+		valid bool
+	}{
+		"inlined":              {src: `context.event.name`, valid: true},
+		"field":                {src: `context.event.type`, valid: true},
+		"optional":             {src: `context.event.count`, valid: true},
+		"time":                 {src: `context.event.time`, valid: true},
+		"below a map":          {src: `context.event.labels.app`, valid: true},
+		"element of a list":    {src: `context.event.items[0].name`, valid: true},
+		"below a marshaler":    {src: `context.event.object.spec`, valid: true},
+		"below an interface":   {src: `context.event.any.spec`, valid: true},
+		"recursive":            {src: `context.event.node.next.next`, valid: true},
+		"typo":                 {src: `context.event.tpye`, valid: false},
+		"typo in a list":       {src: `context.event.items[0].nmae`, valid: false},
+		"ignored":              {src: `context.event.Ignored`, valid: false},
+		"unexported":           {src: `context.event.hidden`, valid: false},
+		"below a scalar field": {src: `context.event.type.name`, valid: false},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := filter.CheckReferences(tc.src, schema, "")
+			assert.Equal(t, tc.valid, err == nil, "error: %v", err)
+		})
+	}
+}
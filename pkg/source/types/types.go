@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kubevela/kube-trigger/pkg/eventhandler"
 )
@@ -50,6 +51,29 @@ type Source interface {
 	Singleton() bool
 }
 
+// SchemaSource is a Source that knows what its events look like. Filters and
+// actions are checked against its Schema when the config is loaded, so that
+// typos fail early instead of filtering out every event. Implementing it is
+// optional.
+type SchemaSource interface {
+	Source
+
+	// Schema returns the Schema of the events of a Source with properties.
+	// cli may be used to fetch schemas from the cluster. A nil Schema is not
+	// checked.
+	Schema(ctx context.Context, cli client.Client, properties *runtime.RawExtension) (*Schema, error)
+}
+
+// Schema describes context.event and context.data of the events of a Source
+// in CUE, e.g. `type: "create" | "update"`. Structs are closed, unless they
+// have "...".
+type Schema struct {
+	// Event is the schema of context.event. It is not checked if empty.
+	Event string
+	// Data is the schema of context.data. It is not checked if empty.
+	Data string
+}
+
 // SourceMeta is what users type in their configurations, specifying what source
 // they want to use and what properties they provided.
 type SourceMeta struct {
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigMap is a Store kept in memory, and written through to a ConfigMap, so
+// that its Entries survive restarts. Only the keys of updated Entries are
+// patched, so that other keys in the ConfigMap are kept.
+type ConfigMap struct {
+	*Memory
+	cli client.Client
+	key client.ObjectKey
+	// stale are keys of expired Entries still in the ConfigMap.
+	stale []string
+}
+
+var _ Store = &ConfigMap{}
+
+// NewConfigMap creates a ConfigMap store, loading the Entries in the
+// ConfigMap, if it exists.
+func NewConfigMap(ctx context.Context, cli client.Client, namespace, name string) (*ConfigMap, error) {
+	c := &ConfigMap{
+		Memory: NewMemory(),
+		cli:    cli,
+		key:    client.ObjectKey{Namespace: namespace, Name: name},
+	}
+	cm := &corev1.ConfigMap{}
+	err := cli.Get(ctx, c.key, cm)
+	if apierrors.IsNotFound(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := c.now()
+	for k, v := range cm.Data {
+		e := &Entry{}
+		if err := json.Unmarshal([]byte(v), e); err != nil {
+			continue
+		}
+		if e.expired(now) {
+			c.stale = append(c.stale, k)
+			continue
+		}
+		c.entries[k] = e
+	}
+	return c, nil
+}
+
+// hashKey hashes key, since ConfigMap keys are limited.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Get implements Store.
+func (c *ConfigMap) Get(ctx context.Context, key string) (*Entry, error) {
+	return c.Memory.Get(ctx, hashKey(key))
+}
+
+// Update implements Store. Expired Entries are deleted from the ConfigMap with
+// the update. The Entry is only changed in memory once the ConfigMap is
+// patched, so that a failed update changes neither.
+func (c *ConfigMap) Update(ctx context.Context, key string, fn UpdateFunc) error {
+	key = hashKey(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	patch := make(map[string]interface{})
+	for _, k := range c.stale {
+		patch[k] = nil
+	}
+	now := c.now()
+	var expired []string
+	for k, e := range c.entries {
+		if e.expired(now) {
+			expired = append(expired, k)
+			patch[k] = nil
+		}
+	}
+	next, changed := c.next(key, fn, now)
+	if changed {
+		patch[key] = nil
+		if next != nil {
+			b, err := json.Marshal(next)
+			if err != nil {
+				return err
+			}
+			patch[key] = string(b)
+		}
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	if err := c.patch(ctx, patch); err != nil {
+		return err
+	}
+	c.stale = nil
+	for _, k := range expired {
+		delete(c.entries, k)
+	}
+	if changed {
+		c.set(key, next, now)
+	}
+	return nil
+}
+
+// patch merges data into the data of the ConfigMap, creating it if it does
+// not exist. Keys with nil values are deleted. c.mu must be held.
+func (c *ConfigMap) patch(ctx context.Context, data map[string]interface{}) error {
+	b, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+	p := client.RawPatch(types.MergePatchType, b)
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: c.key.Namespace, Name: c.key.Name}}
+	err = c.cli.Patch(ctx, cm, p)
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	cm.Data = make(map[string]string)
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			cm.Data[k] = s
+		}
+	}
+	err = c.cli.Create(ctx, cm)
+	if apierrors.IsAlreadyExists(err) {
+		// Created by another store in the meantime.
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: c.key.Namespace, Name: c.key.Name}}
+		return c.cli.Patch(ctx, cm, p)
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// minPruneSize is the min number of entries before expired entries are pruned.
+const minPruneSize = 1024
+
+// Memory is a Store in memory. Its Entries are lost on restart.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]*Entry
+	nextPrune int
+	now       func() time.Time
+}
+
+var _ Store = &Memory{}
+
+// NewMemory creates a Memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		entries:   make(map[string]*Entry),
+		nextPrune: minPruneSize,
+		now:       time.Now,
+	}
+}
+
+// Get implements Store.
+func (m *Memory) Get(_ context.Context, key string) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.entries[key]
+	if e == nil || e.expired(m.now()) {
+		return nil, nil
+	}
+	return e, nil
+}
+
+// Update implements Store.
+func (m *Memory) Update(_ context.Context, key string, fn UpdateFunc) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.update(key, fn)
+	return nil
+}
+
+// update updates the Entry of key, and reports whether it changed. m.mu must
+// be held.
+func (m *Memory) update(key string, fn UpdateFunc) bool {
+	now := m.now()
+	next, changed := m.next(key, fn, now)
+	if changed {
+		m.set(key, next, now)
+	}
+	return changed
+}
+
+// next returns the Entry of key updated by fn without storing it, and whether
+// it changed. m.mu must be held.
+func (m *Memory) next(key string, fn UpdateFunc, now time.Time) (*Entry, bool) {
+	current := m.entries[key]
+	if current != nil && current.expired(now) {
+		current = nil
+	}
+	next := fn(current)
+	return next, next != current
+}
+
+// set stores e as the Entry of key, deleting it if e is nil. m.mu must be
+// held.
+func (m *Memory) set(key string, e *Entry, now time.Time) {
+	if e == nil {
+		delete(m.entries, key)
+	} else {
+		m.entries[key] = e
+	}
+	if len(m.entries) >= m.nextPrune {
+		m.prune(now)
+	}
+}
+
+// prune deletes expired entries. m.mu must be held.
+func (m *Memory) prune(now time.Time) {
+	for k, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, k)
+		}
+	}
+	m.nextPrune = 2 * len(m.entries)
+	if m.nextPrune < minPruneSize {
+		m.nextPrune = minPruneSize
+	}
+}
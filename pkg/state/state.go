@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package state stores the state of triggers, e.g. what stateful filter
+// builtins have seen.
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+// Backends of stores.
+const (
+	BackendMemory    = "memory"
+	BackendConfigMap = "configmap"
+)
+
+// Entry is a value in a Store.
+type Entry struct {
+	Value string `json:"v"`
+	// Expires is when the Entry expires. It never expires if zero.
+	Expires time.Time `json:"e,omitempty"`
+}
+
+func (e *Entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// UpdateFunc gets the current Entry of a key, nil if there is none, and
+// returns the new Entry. Returning the current Entry leaves it unchanged.
+type UpdateFunc func(current *Entry) *Entry
+
+// Store stores Entries by key.
+type Store interface {
+	// Get gets the unexpired Entry of key, or nil if there is none. It must
+	// not be modified.
+	Get(ctx context.Context, key string) (*Entry, error)
+	// Update updates the Entry of key with fn, atomically for the key.
+	Update(ctx context.Context, key string, fn UpdateFunc) error
+}
+
+// Validate validates a State.
+func Validate(c v1alpha1.State) error {
+	switch c.Backend {
+	case "", BackendMemory:
+	case BackendConfigMap:
+		if c.ConfigMap == nil || c.ConfigMap.Name == "" || c.ConfigMap.Namespace == "" {
+			return fmt.Errorf("state backend %s needs the name and namespace of a ConfigMap", c.Backend)
+		}
+	default:
+		return fmt.Errorf("unknown state backend %q", c.Backend)
+	}
+	return nil
+}
+
+// New creates a Store from c. A nil c is a memory store.
+func New(ctx context.Context, cli client.Client, c *v1alpha1.State) (Store, error) {
+	if c == nil {
+		return NewMemory(), nil
+	}
+	if err := Validate(*c); err != nil {
+		return nil, err
+	}
+	if c.Backend == BackendConfigMap {
+		return NewConfigMap(ctx, cli, c.ConfigMap.Namespace, c.ConfigMap.Name)
+	}
+	return NewMemory(), nil
+}
+
+type storeKey struct{}
+
+// WithStore returns a copy of ctx with s.
+func WithStore(ctx context.Context, s Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, s)
+}
+
+// FromContext gets the Store of ctx, or nil if there is none.
+func FromContext(ctx context.Context) Store {
+	s, _ := ctx.Value(storeKey{}).(Store)
+	return s
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+func set(value string, expires time.Time) UpdateFunc {
+	return func(*Entry) *Entry {
+		return &Entry{Value: value, Expires: expires}
+	}
+}
+
+func get(t *testing.T, s Store, key string) *Entry {
+	var e *Entry
+	require.NoError(t, s.Update(context.Background(), key, func(current *Entry) *Entry {
+		e = current
+		return current
+	}))
+	return e
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	require.NoError(t, m.Update(ctx, "a", set("1", time.Time{})))
+	require.NoError(t, m.Update(ctx, "b", set("2", now.Add(time.Minute))))
+	assert.Equal(t, "1", get(t, m, "a").Value)
+	assert.Equal(t, "2", get(t, m, "b").Value)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "1", get(t, m, "a").Value)
+	assert.Nil(t, get(t, m, "b"))
+
+	require.NoError(t, m.Update(ctx, "a", func(*Entry) *Entry { return nil }))
+	assert.Nil(t, get(t, m, "a"))
+}
+
+func TestMemoryPrune(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < minPruneSize-1; i++ {
+		require.NoError(t, m.Update(ctx, string(rune(i)), set("", now.Add(time.Second))))
+	}
+	now = now.Add(time.Second)
+	require.NoError(t, m.Update(ctx, "kept", set("", time.Time{})))
+	assert.Len(t, m.entries, 1)
+	assert.Equal(t, minPruneSize, m.nextPrune)
+}
+
+func TestConfigMap(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	conf := &v1alpha1.State{
+		Backend:   BackendConfigMap,
+		ConfigMap: &v1alpha1.ConfigMapReference{Namespace: "default", Name: "state"},
+	}
+
+	s, err := New(ctx, cli, conf)
+	require.NoError(t, err)
+	require.NoError(t, s.Update(ctx, "a", set("1", time.Time{})))
+	require.NoError(t, s.Update(ctx, "b", set("2", time.Now().Add(time.Second))))
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "state"}, cm))
+	assert.Len(t, cm.Data, 2)
+
+	// Keys of other stores in the ConfigMap are kept.
+	cm.Data["other"] = "{}"
+	require.NoError(t, cli.Update(ctx, cm))
+	require.NoError(t, s.Update(ctx, "a", set("3", time.Time{})))
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "state"}, cm))
+	assert.Len(t, cm.Data, 3)
+
+	// A new store, e.g. after a restart, loads the entries.
+	s, err = New(ctx, cli, conf)
+	require.NoError(t, err)
+	assert.Equal(t, "3", get(t, s, "a").Value)
+	assert.Equal(t, "2", get(t, s, "b").Value)
+
+	// Expired entries are deleted from the ConfigMap with the next update.
+	time.Sleep(time.Second)
+	s, err = New(ctx, cli, conf)
+	require.NoError(t, err)
+	assert.Nil(t, get(t, s, "b"))
+	require.NoError(t, s.Update(ctx, "c", set("4", time.Time{})))
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "state"}, cm))
+	assert.Len(t, cm.Data, 3)
+	assert.NotContains(t, cm.Data, hashKey("b"))
+}
+
+func TestConfigMapPatchFailed(t *testing.T) {
+	ctx := context.Background()
+	fail := false
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, cli client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if fail {
+				return errors.New("unavailable")
+			}
+			return cli.Patch(ctx, obj, patch, opts...)
+		},
+	}).Build()
+	s, err := NewConfigMap(ctx, cli, "default", "state")
+	require.NoError(t, err)
+	require.NoError(t, s.Update(ctx, "a", set("1", time.Time{})))
+
+	// Entries are not changed in memory if the ConfigMap is not patched.
+	fail = true
+	assert.Error(t, s.Update(ctx, "a", set("2", time.Time{})))
+	assert.Error(t, s.Update(ctx, "b", set("3", time.Time{})))
+	fail = false
+	assert.Equal(t, "1", get(t, s, "a").Value)
+	assert.Nil(t, get(t, s, "b"))
+}
+
+func TestTx(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	require.NoError(t, m.Update(ctx, "a", set("1", time.Time{})))
+
+	tx := NewTx(m)
+	require.NoError(t, tx.Update(ctx, "a", set("2", time.Time{})))
+	require.NoError(t, tx.Update(ctx, "b", set("3", time.Time{})))
+	// Updates are seen in the Tx, not in the Store.
+	assert.Equal(t, "2", get(t, tx, "a").Value)
+	assert.Equal(t, "1", get(t, m, "a").Value)
+	assert.Nil(t, get(t, m, "b"))
+
+	require.NoError(t, tx.Commit(ctx))
+	assert.Equal(t, "2", get(t, m, "a").Value)
+	assert.Equal(t, "3", get(t, m, "b").Value)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(v1alpha1.State{}))
+	assert.NoError(t, Validate(v1alpha1.State{Backend: BackendMemory}))
+	assert.Error(t, Validate(v1alpha1.State{Backend: BackendConfigMap}))
+	assert.Error(t, Validate(v1alpha1.State{Backend: "redis"}))
+}
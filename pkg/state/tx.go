@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Tx is a Store that collects updates, and applies them to its Store when it
+// is committed. An event is filtered with a Tx, which is only committed if the
+// event is kept, so that stateful builtins do not change the state for events
+// dropped by other parts of the filter.
+type Tx struct {
+	store Store
+
+	mu sync.Mutex
+	// view has the Entries updated in the Tx.
+	view    map[string]*Entry
+	updates []txUpdate
+}
+
+type txUpdate struct {
+	key string
+	fn  UpdateFunc
+}
+
+var _ Store = &Tx{}
+
+// NewTx creates a Tx updating store.
+func NewTx(store Store) *Tx {
+	return &Tx{store: store, view: make(map[string]*Entry)}
+}
+
+// Get implements Store. Updates in the Tx are seen.
+func (t *Tx) Get(ctx context.Context, key string) (*Entry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.get(ctx, key)
+}
+
+func (t *Tx) get(ctx context.Context, key string) (*Entry, error) {
+	if e, ok := t.view[key]; ok {
+		return e, nil
+	}
+	return t.store.Get(ctx, key)
+}
+
+// Update implements Store. fn is called now with the Entry seen in the Tx,
+// and again with the Entry in the Store when the Tx is committed.
+func (t *Tx) Update(ctx context.Context, key string, fn UpdateFunc) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, err := t.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	next := fn(current)
+	if next == current {
+		return nil
+	}
+	t.view[key] = next
+	t.updates = append(t.updates, txUpdate{key: key, fn: fn})
+	return nil
+}
+
+// Commit applies the updates in the Tx to its Store.
+func (t *Tx) Commit(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var errs []error
+	for _, u := range t.updates {
+		if err := t.store.Update(ctx, u.key, u.fn); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	t.updates = nil
+	t.view = make(map[string]*Entry)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storm
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	stormMode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kube_trigger_storm_mode",
+		Help: "Whether a trigger, or all triggers, are in storm mode.",
+	}, []string{"trigger"})
+	stormEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kube_trigger_storm_events_total",
+		Help: "Events in storm mode, by whether they were sampled or summarized.",
+	}, []string{"trigger", "result"})
+	stormSummaries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kube_trigger_storm_summaries_total",
+		Help: "Actions run once for the events summarized in storm mode.",
+	}, []string{"trigger"})
+)
+
+func init() {
+	prometheus.MustRegister(stormMode, stormEvents, stormSummaries)
+}
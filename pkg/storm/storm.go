@@ -0,0 +1,263 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package storm protects the executor and downstream systems from event
+// storms, e.g. thousands of Pod updates when a node fails. Triggers in storm
+// mode run their actions once for many events, instead of once for each.
+package storm
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+const defaultWindow = 10 * time.Second
+
+// GlobalName is the name of the detector of storms across all triggers, in
+// logs and metrics.
+const GlobalName = "global"
+
+var logger = logrus.WithField("storm", "guard")
+
+// global detects storms across all triggers. It is nil if disabled.
+var global *detector
+
+// SetGlobal detects storms across all triggers. All triggers are in storm
+// mode while more than threshold events pass their filters in window. It is
+// disabled if threshold is 0. Guards created before are not affected.
+func SetGlobal(threshold int, window time.Duration) {
+	if threshold <= 0 {
+		global = nil
+		return
+	}
+	global = newDetector(GlobalName, threshold, window)
+}
+
+// Validate validates a Storm.
+func Validate(c v1alpha1.Storm) error {
+	_, err := parseWindow(c)
+	if err != nil {
+		return err
+	}
+	if c.Threshold <= 0 {
+		return fmt.Errorf("storm threshold must be positive")
+	}
+	if c.SamplePercent < 0 || c.SamplePercent > 100 {
+		return fmt.Errorf("storm samplePercent must be between 0 and 100")
+	}
+	return nil
+}
+
+func parseWindow(c v1alpha1.Storm) (time.Duration, error) {
+	if c.Window == "" {
+		return defaultWindow, nil
+	}
+	d, err := time.ParseDuration(c.Window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid storm window %q: %w", c.Window, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("storm window must be positive")
+	}
+	return d, nil
+}
+
+// detector counts events in windows. It is in storm mode from when more
+// events than threshold are in a window, until a window with no more events
+// than threshold.
+type detector struct {
+	name      string
+	threshold int
+	window    time.Duration
+
+	mu    sync.Mutex
+	start time.Time
+	count int
+	storm bool
+}
+
+func newDetector(name string, threshold int, window time.Duration) *detector {
+	// Only exports the gauge, without resetting a storm another detector of
+	// the same name is in.
+	stormMode.WithLabelValues(name).Add(0)
+	return &detector{name: name, threshold: threshold, window: window}
+}
+
+// observe counts an event at now, and reports whether it is in storm mode.
+func (d *detector) observe(now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.roll(now)
+	d.count++
+	if d.count > d.threshold && !d.storm {
+		d.storm = true
+		stormMode.WithLabelValues(d.name).Set(1)
+		logger.Warnf("%s is in storm mode, more than %d events in %s", d.name, d.threshold, d.window)
+	}
+	return d.storm
+}
+
+// check ends storm mode if it ended by now, without counting an event.
+func (d *detector) check(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.roll(now)
+}
+
+// roll starts a new window if the current one ended by now.
+func (d *detector) roll(now time.Time) {
+	if now.Before(d.start.Add(d.window)) {
+		return
+	}
+	// A window without any events may have passed since the last one.
+	calm := d.count <= d.threshold || !now.Before(d.start.Add(2*d.window))
+	if d.storm && calm {
+		d.storm = false
+		stormMode.WithLabelValues(d.name).Set(0)
+		logger.Infof("%s left storm mode", d.name)
+	}
+	d.start = now
+	d.count = 0
+}
+
+// SummarizeFunc runs the action of a trigger for the events summarized in
+// storm mode.
+type SummarizeFunc func(context map[string]interface{})
+
+// summary is the events summarized in a window.
+type summary struct {
+	latest     map[string]interface{}
+	summarized int
+	sampled    int
+	since      time.Time
+}
+
+// Guard decides which events of a trigger run its action, and summarizes
+// the others in storm mode. The action runs once every window for the events
+// that were summarized, with the context of the latest one, and the summary
+// in context.storm.
+type Guard struct {
+	name          string
+	trigger       *detector
+	global        *detector
+	window        time.Duration
+	samplePercent int
+	summarize     SummarizeFunc
+	now           func() time.Time
+	random        func() float64
+
+	mu      sync.Mutex
+	summary *summary
+}
+
+// New creates a Guard for the trigger called name, in logs and metrics, with
+// the Storm c of the trigger, and the storms across all triggers. It is nil if
+// c is nil, and storms across all triggers are not detected.
+func New(name string, c *v1alpha1.Storm, summarize SummarizeFunc) (*Guard, error) {
+	if c == nil && global == nil {
+		return nil, nil
+	}
+	g := &Guard{
+		name:      name,
+		global:    global,
+		summarize: summarize,
+		now:       time.Now,
+		random:    rand.Float64,
+	}
+	if global != nil {
+		g.window = global.window
+	}
+	if c != nil {
+		if err := Validate(*c); err != nil {
+			return nil, err
+		}
+		g.window, _ = parseWindow(*c)
+		g.samplePercent = c.SamplePercent
+		g.trigger = newDetector(name, c.Threshold, g.window)
+	}
+	return g, nil
+}
+
+// Pass counts the event with context, and reports whether it runs the action.
+// Events that do not are summarized.
+func (g *Guard) Pass(context map[string]interface{}) bool {
+	now := g.now()
+	// Events are counted by both detectors, even if one is in storm mode.
+	storm := false
+	if g.trigger != nil && g.trigger.observe(now) {
+		storm = true
+	}
+	if g.global != nil && g.global.observe(now) {
+		storm = true
+	}
+	if !storm {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.summary == nil {
+		g.summary = &summary{since: now}
+		time.AfterFunc(g.window, g.flush)
+	}
+	if g.random()*100 < float64(g.samplePercent) {
+		g.summary.sampled++
+		stormEvents.WithLabelValues(g.name, "sampled").Inc()
+		return true
+	}
+	g.summary.summarized++
+	g.summary.latest = context
+	stormEvents.WithLabelValues(g.name, "summarized").Inc()
+	return false
+}
+
+// flush runs the action for the events summarized since the summary started.
+func (g *Guard) flush() {
+	g.mu.Lock()
+	s := g.summary
+	g.summary = nil
+	g.mu.Unlock()
+
+	// Storm mode is left here, if no more events come.
+	now := g.now()
+	if g.trigger != nil {
+		g.trigger.check(now)
+	}
+	if g.global != nil {
+		g.global.check(now)
+	}
+	if s == nil || s.summarized == 0 {
+		return
+	}
+	context := make(map[string]interface{}, len(s.latest)+1)
+	for k, v := range s.latest {
+		context[k] = v
+	}
+	context["storm"] = map[string]interface{}{
+		"summarized": s.summarized,
+		"sampled":    s.sampled,
+		"since":      s.since.Format(time.RFC3339),
+	}
+	logger.Infof("running action of %s once for %d events in storm mode, %d more sampled", g.name, s.summarized, s.sampled)
+	stormSummaries.WithLabelValues(g.name).Inc()
+	g.summarize(context)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/kube-trigger/api/v1alpha1"
+)
+
+func TestDetector(t *testing.T) {
+	now := time.Now()
+	d := newDetector("test", 2, time.Minute)
+	assert.False(t, d.observe(now))
+	assert.False(t, d.observe(now))
+	assert.True(t, d.observe(now.Add(time.Second)))
+
+	// Still in storm mode in the next window, while it is stormy.
+	next := now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.True(t, d.observe(next))
+	}
+	// Left after a calm window.
+	next = next.Add(time.Minute)
+	assert.True(t, d.observe(next))
+	next = next.Add(time.Minute)
+	assert.False(t, d.observe(next))
+
+	// Or after a window without events.
+	for i := 0; i < 3; i++ {
+		d.observe(next)
+	}
+	d.check(next.Add(2 * time.Minute))
+	assert.False(t, d.storm)
+}
+
+func TestDetectorGauge(t *testing.T) {
+	now := time.Now()
+	d := newDetector("gauge", 1, time.Minute)
+	assert.Equal(t, float64(0), testutil.ToFloat64(stormMode.WithLabelValues("gauge")))
+	d.observe(now)
+	d.observe(now)
+	assert.Equal(t, float64(1), testutil.ToFloat64(stormMode.WithLabelValues("gauge")))
+	// Another detector of the same name does not reset the gauge.
+	newDetector("gauge", 1, time.Minute)
+	assert.Equal(t, float64(1), testutil.ToFloat64(stormMode.WithLabelValues("gauge")))
+}
+
+// fakeClock gives a Guard a time that only moves when told.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newGuard(t *testing.T, c *v1alpha1.Storm) (*Guard, *fakeClock, *[]map[string]interface{}) {
+	var summaries []map[string]interface{}
+	g, err := New("test", c, func(context map[string]interface{}) {
+		summaries = append(summaries, context)
+	})
+	require.NoError(t, err)
+	require.NotNil(t, g)
+	clock := &fakeClock{now: time.Now()}
+	g.now = clock.Now
+	return g, clock, &summaries
+}
+
+func TestGuard(t *testing.T) {
+	// The window is long, so that summaries are only flushed by the test.
+	g, clock, summaries := newGuard(t, &v1alpha1.Storm{Threshold: 2, Window: "1h", SamplePercent: 50})
+	random := []float64{0.9, 0.1, 0.9}
+	g.random = func() float64 {
+		r := random[0]
+		random = random[1:]
+		return r
+	}
+	for i := 0; i < 2; i++ {
+		assert.True(t, g.Pass(map[string]interface{}{"n": i}))
+	}
+	assert.False(t, g.Pass(map[string]interface{}{"n": 2}))
+	assert.True(t, g.Pass(map[string]interface{}{"n": 3}), "sampled")
+	assert.False(t, g.Pass(map[string]interface{}{"n": 4}))
+
+	g.flush()
+	require.Len(t, *summaries, 1)
+	summary := (*summaries)[0]
+	assert.Equal(t, 4, summary["n"])
+	assert.Equal(t, map[string]interface{}{
+		"summarized": 2,
+		"sampled":    1,
+		"since":      clock.now.Format(time.RFC3339),
+	}, summary["storm"])
+
+	// Nothing to summarize.
+	g.flush()
+	assert.Len(t, *summaries, 1)
+
+	// Calm again.
+	clock.now = clock.now.Add(2 * time.Hour)
+	g.flush()
+	assert.False(t, g.trigger.storm)
+	assert.True(t, g.Pass(nil))
+}
+
+func TestGlobal(t *testing.T) {
+	g, err := New("test", nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, g)
+
+	SetGlobal(1, time.Hour)
+	defer SetGlobal(0, 0)
+	a, _, summaries := newGuard(t, nil)
+	b, _, _ := newGuard(t, nil)
+	assert.Equal(t, time.Hour, a.window)
+	assert.True(t, a.Pass(nil))
+	// Events of all triggers count.
+	assert.False(t, b.Pass(nil))
+	assert.False(t, a.Pass(map[string]interface{}{"n": 1}))
+	a.flush()
+	require.Len(t, *summaries, 1)
+	assert.Equal(t, 1, (*summaries)[0]["n"])
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(v1alpha1.Storm{Threshold: 100}))
+	assert.NoError(t, Validate(v1alpha1.Storm{Threshold: 100, Window: "1m", SamplePercent: 10}))
+	assert.Error(t, Validate(v1alpha1.Storm{}))
+	assert.Error(t, Validate(v1alpha1.Storm{Threshold: 100, Window: "soon"}))
+	assert.Error(t, Validate(v1alpha1.Storm{Threshold: 100, Window: "-1s"}))
+	assert.Error(t, Validate(v1alpha1.Storm{Threshold: 100, SamplePercent: 101}))
+}
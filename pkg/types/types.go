@@ -21,4 +21,6 @@ const (
 	DefinitionTypeTriggerAction = "trigger-action"
 	// DefinitionTypeTriggerWorker .
 	DefinitionTypeTriggerWorker = "trigger-worker"
+	// DefinitionTypeTriggerFilter .
+	DefinitionTypeTriggerFilter = "trigger-filter"
 )